@@ -0,0 +1,41 @@
+// Package audit contains implementations of ladon's AuditLogger interface.
+package audit
+
+import (
+	"time"
+
+	. "github.com/ory/ladon"
+)
+
+const (
+	// DecisionGranted is the decision recorded when a request was allowed.
+	DecisionGranted = "granted"
+
+	// DecisionRejected is the decision recorded when a request was denied.
+	DecisionRejected = "rejected"
+)
+
+// decidingPolicy returns the policy which decided the request. The warden appends an explicitly denying
+// policy last, so that's the one responsible for a rejection. If no policy matched, nil is returned.
+func decidingPolicy(deciders Policies) Policy {
+	if len(deciders) == 0 {
+		return nil
+	}
+	return deciders[len(deciders)-1]
+}
+
+// newRecord returns the record of a decision made now.
+func newRecord(r *Request, deciders Policies, decision string) *JSONRecord {
+	record := &JSONRecord{
+		Timestamp: time.Now().UTC(),
+		Subject:   r.Subject,
+		Resource:  r.Resource,
+		Action:    r.Action,
+		Decision:  decision,
+	}
+	if policy := decidingPolicy(deciders); policy != nil {
+		record.Effect = policy.GetEffect()
+		record.PolicyID = policy.GetID()
+	}
+	return record
+}
@@ -0,0 +1,138 @@
+package audit
+
+import (
+	"sync"
+	"sync/atomic"
+
+	. "github.com/ory/ladon"
+)
+
+// Decision is passed to DecisionListeners for every decision.
+type Decision struct {
+	// Decision is DecisionGranted or DecisionRejected.
+	Decision string
+	// Policy is the policy which decided the request, or nil if no policy matched.
+	Policy Policy
+}
+
+// DecisionListener is notified of decisions by AsyncAuditLogger, e.g. to publish them to a message queue.
+type DecisionListener interface {
+	OnDecision(r *Request, d *Decision)
+}
+
+// DecisionListenerFunc is an adapter to use an ordinary function as DecisionListener.
+type DecisionListenerFunc func(r *Request, d *Decision)
+
+// OnDecision calls f(r, d).
+func (f DecisionListenerFunc) OnDecision(r *Request, d *Decision) {
+	f(r, d)
+}
+
+type decisionEvent struct {
+	request  *Request
+	decision *Decision
+}
+
+// AsyncAuditLogger notifies the registered DecisionListeners of every decision without blocking the
+// warden. Decisions are queued in a buffer and passed to the listeners, one after another, by a single
+// worker goroutine. If the buffer is full, the decision is dropped and counted instead. It is safe for
+// concurrent use.
+type AsyncAuditLogger struct {
+	mu        sync.RWMutex
+	listeners []DecisionListener
+
+	events    chan decisionEvent
+	dropped   uint64
+	quit      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewAsyncAuditLogger initializes a new AsyncAuditLogger buffering up to buffer decisions and starts its
+// worker. Call Close to stop it.
+func NewAsyncAuditLogger(buffer int, listeners ...DecisionListener) *AsyncAuditLogger {
+	a := &AsyncAuditLogger{
+		listeners: listeners,
+		events:    make(chan decisionEvent, buffer),
+		quit:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go a.work()
+	return a
+}
+
+// Register adds a listener, which is notified of all decisions queued from now on.
+func (a *AsyncAuditLogger) Register(l DecisionListener) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.listeners = append(a.listeners, l)
+}
+
+// Dropped returns how many decisions were dropped because the buffer was full or the logger was closed.
+func (a *AsyncAuditLogger) Dropped() uint64 {
+	return atomic.LoadUint64(&a.dropped)
+}
+
+// Close stops the worker after it passed all queued decisions to the listeners. Decisions logged after
+// Close are dropped.
+func (a *AsyncAuditLogger) Close() error {
+	a.closeOnce.Do(func() {
+		close(a.quit)
+	})
+	<-a.done
+	return nil
+}
+
+// LogRejectedAccessRequest queues a rejected decision.
+func (a *AsyncAuditLogger) LogRejectedAccessRequest(r *Request, p Policies, d Policies) {
+	a.enqueue(r, &Decision{Decision: DecisionRejected, Policy: decidingPolicy(d)})
+}
+
+// LogGrantedAccessRequest queues a granted decision.
+func (a *AsyncAuditLogger) LogGrantedAccessRequest(r *Request, p Policies, d Policies) {
+	a.enqueue(r, &Decision{Decision: DecisionGranted, Policy: decidingPolicy(d)})
+}
+
+func (a *AsyncAuditLogger) enqueue(r *Request, d *Decision) {
+	select {
+	case <-a.quit:
+		atomic.AddUint64(&a.dropped, 1)
+		return
+	default:
+	}
+
+	select {
+	case a.events <- decisionEvent{request: r, decision: d}:
+	default:
+		atomic.AddUint64(&a.dropped, 1)
+	}
+}
+
+func (a *AsyncAuditLogger) work() {
+	defer close(a.done)
+	for {
+		select {
+		case e := <-a.events:
+			a.dispatch(e)
+		case <-a.quit:
+			for {
+				select {
+				case e := <-a.events:
+					a.dispatch(e)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (a *AsyncAuditLogger) dispatch(e decisionEvent) {
+	a.mu.RLock()
+	listeners := a.listeners
+	a.mu.RUnlock()
+
+	for _, l := range listeners {
+		l.OnDecision(e.request, e.decision)
+	}
+}
@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+)
+
+func newListenedWarden(t *testing.T, logger AuditLogger) *Ladon {
+	m := memory.NewMemoryManager()
+	for _, p := range []*DefaultPolicy{
+		{ID: "allow-articles", Subjects: []string{"alice", "bob"}, Resources: []string{"articles:<.*>"}, Actions: []string{"get"}, Effect: AllowAccess},
+		{ID: "deny-bob", Subjects: []string{"bob"}, Resources: []string{"<.*>"}, Actions: []string{"<.*>"}, Effect: DenyAccess},
+	} {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &Ladon{Manager: m, AuditLogger: logger}
+}
+
+func TestAsyncAuditLogger(t *testing.T) {
+	t.Run("Notify listeners of allow and deny decisions", func(t *testing.T) {
+		received := make(chan string, 3)
+		logger := NewAsyncAuditLogger(10, DecisionListenerFunc(func(r *Request, d *Decision) {
+			id := ""
+			if d.Policy != nil {
+				id = d.Policy.GetID()
+			}
+			received <- r.Subject + " " + d.Decision + " " + id
+		}))
+		w := newListenedWarden(t, logger)
+
+		for _, subject := range []string{"alice", "bob", "carol"} {
+			w.IsAllowed(&Request{Subject: subject, Resource: "articles:1", Action: "get"})
+		}
+		if err := logger.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		for k, expected := range []string{
+			"alice granted allow-articles",
+			"bob rejected deny-bob",
+			"carol rejected ",
+		} {
+			if actual := <-received; actual != expected {
+				t.Errorf("Case %d: expected %q, got %q", k, expected, actual)
+			}
+		}
+		if n := logger.Dropped(); n != 0 {
+			t.Errorf("Expected no dropped decisions, got %d", n)
+		}
+	})
+
+	t.Run("Register listeners later", func(t *testing.T) {
+		logger := NewAsyncAuditLogger(10)
+		received := make(chan *Decision, 1)
+		logger.Register(DecisionListenerFunc(func(r *Request, d *Decision) {
+			received <- d
+		}))
+
+		newListenedWarden(t, logger).IsAllowed(&Request{Subject: "alice", Resource: "articles:1", Action: "get"})
+		logger.Close()
+		if d := <-received; d.Decision != DecisionGranted {
+			t.Fatalf("Expected a granted decision, got %+v", d)
+		}
+	})
+
+	t.Run("Don't block on slow listeners", func(t *testing.T) {
+		release := make(chan struct{})
+		logger := NewAsyncAuditLogger(1, DecisionListenerFunc(func(*Request, *Decision) {
+			<-release
+		}))
+		w := newListenedWarden(t, logger)
+
+		start := time.Now()
+		for i := 0; i < 100; i++ {
+			if err := w.IsAllowed(&Request{Subject: "alice", Resource: "articles:1", Action: "get"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("Expected IsAllowed not to wait for the listener, took %s", elapsed)
+		}
+
+		// The worker is blocked on the first decision and one more fits into the buffer.
+		if n := logger.Dropped(); n < 98 {
+			t.Fatalf("Expected at least 98 dropped decisions, got %d", n)
+		}
+
+		close(release)
+		logger.Close()
+		logger.LogGrantedAccessRequest(&Request{}, nil, nil)
+		if n := logger.Dropped(); n < 99 {
+			t.Fatalf("Expected decisions after Close to be dropped, got %d dropped", n)
+		}
+	})
+}
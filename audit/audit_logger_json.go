@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	. "github.com/ory/ladon"
+)
+
+// JSONRecord is the structure emitted by JSONAuditLogger for every decision.
+type JSONRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Subject   string    `json:"subject"`
+	Resource  string    `json:"resource"`
+	Action    string    `json:"action"`
+	Effect    string    `json:"effect,omitempty"`
+	PolicyID  string    `json:"policy_id,omitempty"`
+	Decision  string    `json:"decision"`
+}
+
+// JSONAuditLogger writes one JSON object per line for every decision. It is safe for concurrent use.
+type JSONAuditLogger struct {
+	Writer io.Writer
+
+	mu sync.Mutex
+}
+
+// NewJSONAuditLogger initializes a new JSONAuditLogger writing to w.
+func NewJSONAuditLogger(w io.Writer) *JSONAuditLogger {
+	return &JSONAuditLogger{Writer: w}
+}
+
+// LogRejectedAccessRequest writes a rejected decision.
+func (a *JSONAuditLogger) LogRejectedAccessRequest(r *Request, p Policies, d Policies) {
+	a.log(r, d, DecisionRejected)
+}
+
+// LogGrantedAccessRequest writes a granted decision.
+func (a *JSONAuditLogger) LogGrantedAccessRequest(r *Request, p Policies, d Policies) {
+	a.log(r, d, DecisionGranted)
+}
+
+func (a *JSONAuditLogger) log(r *Request, d Policies, decision string) {
+	b, err := json.Marshal(newRecord(r, d, decision))
+	if err != nil {
+		// JSONRecord only consists of strings and a timestamp, so this can't happen.
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.Writer == nil {
+		a.Writer = os.Stderr
+	}
+	a.Writer.Write(append(b, '\n'))
+}
@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+)
+
+func newWarden(t *testing.T, logger AuditLogger) *Ladon {
+	w := &Ladon{
+		Manager:     memory.NewMemoryManager(),
+		AuditLogger: logger,
+	}
+
+	for _, p := range []Policy{
+		&DefaultPolicy{
+			ID:        "allow-get",
+			Subjects:  []string{"alice", "bob"},
+			Resources: []string{"articles:1"},
+			Actions:   []string{"get"},
+			Effect:    AllowAccess,
+		},
+		&DefaultPolicy{
+			ID:        "deny-bob",
+			Subjects:  []string{"bob"},
+			Resources: []string{"articles:1"},
+			Actions:   []string{"get"},
+			Effect:    DenyAccess,
+		},
+	} {
+		if err := w.Manager.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w
+}
+
+func TestJSONAuditLogger(t *testing.T) {
+	var out bytes.Buffer
+	w := newWarden(t, NewJSONAuditLogger(&out))
+
+	for k, c := range []struct {
+		request *Request
+		allowed bool
+		record  JSONRecord
+	}{
+		{
+			request: &Request{Subject: "alice", Resource: "articles:1", Action: "get"},
+			allowed: true,
+			record: JSONRecord{
+				Subject:  "alice",
+				Resource: "articles:1",
+				Action:   "get",
+				Effect:   AllowAccess,
+				PolicyID: "allow-get",
+				Decision: DecisionGranted,
+			},
+		},
+		{
+			request: &Request{Subject: "bob", Resource: "articles:1", Action: "get"},
+			record: JSONRecord{
+				Subject:  "bob",
+				Resource: "articles:1",
+				Action:   "get",
+				Effect:   DenyAccess,
+				PolicyID: "deny-bob",
+				Decision: DecisionRejected,
+			},
+		},
+		{
+			request: &Request{Subject: "alice", Resource: "articles:1", Action: "delete"},
+			record: JSONRecord{
+				Subject:  "alice",
+				Resource: "articles:1",
+				Action:   "delete",
+				Decision: DecisionRejected,
+			},
+		},
+	} {
+		out.Reset()
+		if err := w.IsAllowed(c.request); (err == nil) != c.allowed {
+			t.Fatalf("Case %d: unexpected result from IsAllowed: %v", k, err)
+		}
+
+		var record JSONRecord
+		if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+			t.Fatalf("Case %d: could not parse %q: %s", k, out.String(), err)
+		}
+		if record.Timestamp.IsZero() {
+			t.Errorf("Case %d: timestamp is not set", k)
+		}
+		if !cmp.Equal(c.record, record, cmpopts.IgnoreFields(JSONRecord{}, "Timestamp")) {
+			t.Errorf("Case %d: unexpected record\n%s", k, cmp.Diff(c.record, record, cmpopts.IgnoreFields(JSONRecord{}, "Timestamp")))
+		}
+	}
+}
+
+func TestJSONAuditLoggerConcurrency(t *testing.T) {
+	var out bytes.Buffer
+	logger := NewJSONAuditLogger(&out)
+	r := &Request{Subject: "alice", Resource: "articles:1", Action: "get"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logger.LogGrantedAccessRequest(r, Policies{}, Policies{})
+		}()
+	}
+	wg.Wait()
+
+	var lines int
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var record JSONRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Could not parse line %q: %s", scanner.Text(), err)
+		}
+		lines++
+	}
+	if lines != 50 {
+		t.Fatalf("Expected 50 lines, got %d", lines)
+	}
+}
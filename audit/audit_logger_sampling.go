@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"sync/atomic"
+
+	. "github.com/ory/ladon"
+)
+
+// SamplingAuditLogger forwards every rejected request to the wrapped AuditLogger, but only one in every
+// N granted requests. It is safe for concurrent use if the wrapped logger is.
+type SamplingAuditLogger struct {
+	logger AuditLogger
+	n      uint64
+	grants uint64
+}
+
+// NewSamplingAuditLogger initializes a new SamplingAuditLogger which logs one in n granted requests to
+// logger. A value of n smaller than 2 logs every granted request.
+func NewSamplingAuditLogger(logger AuditLogger, n uint64) *SamplingAuditLogger {
+	if n == 0 {
+		n = 1
+	}
+
+	return &SamplingAuditLogger{
+		logger: logger,
+		n:      n,
+	}
+}
+
+// LogRejectedAccessRequest always forwards to the wrapped logger.
+func (a *SamplingAuditLogger) LogRejectedAccessRequest(r *Request, p Policies, d Policies) {
+	a.logger.LogRejectedAccessRequest(r, p, d)
+}
+
+// LogGrantedAccessRequest forwards the first and then every n-th granted request to the wrapped logger.
+func (a *SamplingAuditLogger) LogGrantedAccessRequest(r *Request, p Policies, d Policies) {
+	if (atomic.AddUint64(&a.grants, 1)-1)%a.n != 0 {
+		return
+	}
+	a.logger.LogGrantedAccessRequest(r, p, d)
+}
@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/ory/ladon"
+)
+
+type countingAuditLogger struct {
+	granted, rejected int64
+}
+
+func (a *countingAuditLogger) LogRejectedAccessRequest(r *Request, p Policies, d Policies) {
+	atomic.AddInt64(&a.rejected, 1)
+}
+
+func (a *countingAuditLogger) LogGrantedAccessRequest(r *Request, p Policies, d Policies) {
+	atomic.AddInt64(&a.granted, 1)
+}
+
+func TestSamplingAuditLogger(t *testing.T) {
+	for k, c := range []struct {
+		n        uint64
+		granted  int64
+		rejected int64
+	}{
+		{n: 0, granted: 100, rejected: 100},
+		{n: 1, granted: 100, rejected: 100},
+		{n: 10, granted: 10, rejected: 100},
+		{n: 30, granted: 4, rejected: 100},
+		{n: 1000, granted: 1, rejected: 100},
+	} {
+		inner := &countingAuditLogger{}
+		logger := NewSamplingAuditLogger(inner, c.n)
+		r := &Request{Subject: "alice"}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				logger.LogGrantedAccessRequest(r, Policies{}, Policies{})
+			}()
+			go func() {
+				defer wg.Done()
+				logger.LogRejectedAccessRequest(r, Policies{}, Policies{})
+			}()
+		}
+		wg.Wait()
+
+		if inner.granted != c.granted {
+			t.Errorf("Case %d: expected %d granted requests to be logged, got %d", k, c.granted, inner.granted)
+		}
+		if inner.rejected != c.rejected {
+			t.Errorf("Case %d: expected %d rejected requests to be logged, got %d", k, c.rejected, inner.rejected)
+		}
+	}
+}
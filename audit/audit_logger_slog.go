@@ -0,0 +1,49 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+
+	. "github.com/ory/ladon"
+)
+
+// SlogAuditLogger logs every decision through a log/slog logger, granted ones at Info and rejected ones
+// at Warn. The records carry the attributes subject, resource, action and decision, plus effect and
+// policy_id of the deciding policy if a policy applied, named like the fields of JSONRecord.
+type SlogAuditLogger struct {
+	// Logger receives the records. It defaults to slog.Default().
+	Logger *slog.Logger
+}
+
+// NewSlogAuditLogger initializes a new SlogAuditLogger logging to logger.
+func NewSlogAuditLogger(logger *slog.Logger) *SlogAuditLogger {
+	return &SlogAuditLogger{Logger: logger}
+}
+
+// LogRejectedAccessRequest logs a rejected decision at Warn.
+func (a *SlogAuditLogger) LogRejectedAccessRequest(r *Request, p Policies, d Policies) {
+	a.log(slog.LevelWarn, "Access request rejected", r, d, DecisionRejected)
+}
+
+// LogGrantedAccessRequest logs a granted decision at Info.
+func (a *SlogAuditLogger) LogGrantedAccessRequest(r *Request, p Policies, d Policies) {
+	a.log(slog.LevelInfo, "Access request granted", r, d, DecisionGranted)
+}
+
+func (a *SlogAuditLogger) log(level slog.Level, msg string, r *Request, d Policies, decision string) {
+	logger := a.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	attrs := []slog.Attr{
+		slog.String("subject", r.Subject),
+		slog.String("resource", r.Resource),
+		slog.String("action", r.Action),
+		slog.String("decision", decision),
+	}
+	if policy := decidingPolicy(d); policy != nil {
+		attrs = append(attrs, slog.String("effect", policy.GetEffect()), slog.String("policy_id", policy.GetID()))
+	}
+	logger.LogAttrs(context.Background(), level, msg, attrs...)
+}
@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+)
+
+// capturingHandler is a slog.Handler keeping the records it handles.
+type capturingHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *capturingHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h *capturingHandler) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h *capturingHandler) WithGroup(string) slog.Handler            { return h }
+
+func (h *capturingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+// attrs returns the attributes of r as strings.
+func attrs(r slog.Record) map[string]string {
+	values := map[string]string{}
+	r.Attrs(func(a slog.Attr) bool {
+		values[a.Key] = a.Value.String()
+		return true
+	})
+	return values
+}
+
+func TestSlogAuditLogger(t *testing.T) {
+	h := &capturingHandler{}
+	w := newWarden(t, NewSlogAuditLogger(slog.New(h)))
+
+	for k, c := range []struct {
+		request *Request
+		allowed bool
+		level   slog.Level
+		attrs   map[string]string
+	}{
+		{
+			request: &Request{Subject: "alice", Resource: "articles:1", Action: "get"},
+			allowed: true,
+			level:   slog.LevelInfo,
+			attrs: map[string]string{
+				"subject":   "alice",
+				"resource":  "articles:1",
+				"action":    "get",
+				"decision":  DecisionGranted,
+				"effect":    AllowAccess,
+				"policy_id": "allow-get",
+			},
+		},
+		{
+			request: &Request{Subject: "bob", Resource: "articles:1", Action: "get"},
+			level:   slog.LevelWarn,
+			attrs: map[string]string{
+				"subject":   "bob",
+				"resource":  "articles:1",
+				"action":    "get",
+				"decision":  DecisionRejected,
+				"effect":    DenyAccess,
+				"policy_id": "deny-bob",
+			},
+		},
+		{
+			request: &Request{Subject: "alice", Resource: "articles:1", Action: "delete"},
+			level:   slog.LevelWarn,
+			attrs: map[string]string{
+				"subject":  "alice",
+				"resource": "articles:1",
+				"action":   "delete",
+				"decision": DecisionRejected,
+			},
+		},
+	} {
+		if err := w.IsAllowed(c.request); (err == nil) != c.allowed {
+			t.Fatalf("Case %d: expected allowed to be %t, got error %v", k, c.allowed, err)
+		}
+		if len(h.records) != k+1 {
+			t.Fatalf("Case %d: expected a single record per decision, got %d records", k, len(h.records))
+		}
+
+		record := h.records[k]
+		if record.Level != c.level {
+			t.Errorf("Case %d: expected level %s, got %s", k, c.level, record.Level)
+		}
+		if got := attrs(record); !cmp.Equal(c.attrs, got) {
+			t.Errorf("Case %d: unexpected attributes\n%s", k, cmp.Diff(c.attrs, got))
+		}
+	}
+}
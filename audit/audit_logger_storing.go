@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "github.com/ory/ladon"
+)
+
+// DecisionRecord is a decision persisted by StoringAuditLogger. It has the same fields and JSON encoding
+// as the lines written by JSONAuditLogger.
+type DecisionRecord = JSONRecord
+
+// DecisionFilter selects decision records. Empty fields match every record.
+type DecisionFilter struct {
+	Subject  string
+	Resource string
+	Action   string
+	Decision string
+
+	// Since and Until bound the records' timestamps, inclusively.
+	Since time.Time
+	Until time.Time
+
+	// Limit caps the number of returned records, zero returns all of them.
+	Limit int
+}
+
+// Matches returns true if record is selected by the filter.
+func (f *DecisionFilter) Matches(record *DecisionRecord) bool {
+	switch {
+	case f.Subject != "" && f.Subject != record.Subject,
+		f.Resource != "" && f.Resource != record.Resource,
+		f.Action != "" && f.Action != record.Action,
+		f.Decision != "" && f.Decision != record.Decision,
+		!f.Since.IsZero() && record.Timestamp.Before(f.Since),
+		!f.Until.IsZero() && record.Timestamp.After(f.Until):
+		return false
+	}
+	return true
+}
+
+// DecisionStore persists decision records for StoringAuditLogger.
+type DecisionStore interface {
+	// StoreDecision persists a record.
+	StoreDecision(record *DecisionRecord) error
+
+	// QueryDecisions returns the records selected by filter, newest first.
+	QueryDecisions(filter *DecisionFilter) ([]DecisionRecord, error)
+}
+
+// StoringAuditLogger persists every decision in a DecisionStore, so decisions can be queried later, e.g.
+// to find out who accessed a resource last week. Decisions are stored synchronously, so the store should
+// be fast. Failures are counted but don't affect the decision. It is safe for concurrent use if the store
+// is.
+type StoringAuditLogger struct {
+	store  DecisionStore
+	failed uint64
+}
+
+// NewStoringAuditLogger initializes a new StoringAuditLogger persisting decisions in store.
+func NewStoringAuditLogger(store DecisionStore) *StoringAuditLogger {
+	return &StoringAuditLogger{store: store}
+}
+
+// LogRejectedAccessRequest stores a rejected decision.
+func (a *StoringAuditLogger) LogRejectedAccessRequest(r *Request, p Policies, d Policies) {
+	a.log(r, d, DecisionRejected)
+}
+
+// LogGrantedAccessRequest stores a granted decision.
+func (a *StoringAuditLogger) LogGrantedAccessRequest(r *Request, p Policies, d Policies) {
+	a.log(r, d, DecisionGranted)
+}
+
+func (a *StoringAuditLogger) log(r *Request, d Policies, decision string) {
+	if err := a.store.StoreDecision(newRecord(r, d, decision)); err != nil {
+		atomic.AddUint64(&a.failed, 1)
+	}
+}
+
+// Failed returns how many decisions couldn't be stored.
+func (a *StoringAuditLogger) Failed() uint64 {
+	return atomic.LoadUint64(&a.failed)
+}
+
+// QueryDecisions returns the stored decisions selected by filter, newest first.
+func (a *StoringAuditLogger) QueryDecisions(filter *DecisionFilter) ([]DecisionRecord, error) {
+	return a.store.QueryDecisions(filter)
+}
+
+// MemoryDecisionStore keeps the most recent decision records in memory. It is safe for concurrent use.
+type MemoryDecisionStore struct {
+	mu       sync.RWMutex
+	records  []DecisionRecord
+	capacity int
+}
+
+// NewMemoryDecisionStore initializes a new MemoryDecisionStore keeping up to capacity records. Older
+// records are discarded. A capacity of zero or less keeps all records.
+func NewMemoryDecisionStore(capacity int) *MemoryDecisionStore {
+	return &MemoryDecisionStore{capacity: capacity}
+}
+
+// StoreDecision keeps a record, discarding the oldest one if the store is full.
+func (s *MemoryDecisionStore) StoreDecision(record *DecisionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, *record)
+	if s.capacity > 0 && len(s.records) > s.capacity {
+		s.records = append(s.records[:0], s.records[len(s.records)-s.capacity:]...)
+	}
+	return nil
+}
+
+// QueryDecisions returns the records selected by filter, newest first.
+func (s *MemoryDecisionStore) QueryDecisions(filter *DecisionFilter) ([]DecisionRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	records := []DecisionRecord{}
+	for i := len(s.records) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && len(records) == filter.Limit {
+			break
+		}
+		if filter.Matches(&s.records[i]) {
+			records = append(records, s.records[i])
+		}
+	}
+	return records, nil
+}
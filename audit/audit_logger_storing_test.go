@@ -0,0 +1,99 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+type failingDecisionStore struct {
+	*MemoryDecisionStore
+}
+
+func (failingDecisionStore) StoreDecision(*DecisionRecord) error {
+	return errors.New("unavailable")
+}
+
+func TestStoringAuditLogger(t *testing.T) {
+	logger := NewStoringAuditLogger(NewMemoryDecisionStore(4))
+	w := newWarden(t, logger)
+	for _, r := range []*Request{
+		{Subject: "carol", Resource: "articles:1", Action: "get"},
+		{Subject: "alice", Resource: "articles:1", Action: "get"},
+		{Subject: "bob", Resource: "articles:1", Action: "get"},
+		{Subject: "alice", Resource: "articles:2", Action: "get"},
+		{Subject: "alice", Resource: "articles:1", Action: "get"},
+	} {
+		w.IsAllowed(r)
+	}
+
+	ignoreTimestamp := cmpopts.IgnoreFields(DecisionRecord{}, "Timestamp")
+	for k, c := range []struct {
+		filter   *DecisionFilter
+		expected []DecisionRecord
+	}{
+		{
+			filter: &DecisionFilter{Subject: "alice"},
+			expected: []DecisionRecord{
+				{Subject: "alice", Resource: "articles:1", Action: "get", Effect: AllowAccess, PolicyID: "allow-get", Decision: DecisionGranted},
+				{Subject: "alice", Resource: "articles:2", Action: "get", Decision: DecisionRejected},
+				{Subject: "alice", Resource: "articles:1", Action: "get", Effect: AllowAccess, PolicyID: "allow-get", Decision: DecisionGranted},
+			},
+		},
+		{
+			filter: &DecisionFilter{Resource: "articles:1", Limit: 2},
+			expected: []DecisionRecord{
+				{Subject: "alice", Resource: "articles:1", Action: "get", Effect: AllowAccess, PolicyID: "allow-get", Decision: DecisionGranted},
+				{Subject: "bob", Resource: "articles:1", Action: "get", Effect: DenyAccess, PolicyID: "deny-bob", Decision: DecisionRejected},
+			},
+		},
+		{
+			// The oldest decision by carol was discarded.
+			filter:   &DecisionFilter{Subject: "carol"},
+			expected: []DecisionRecord{},
+		},
+		{
+			filter: &DecisionFilter{Resource: "articles:1", Decision: DecisionRejected},
+			expected: []DecisionRecord{
+				{Subject: "bob", Resource: "articles:1", Action: "get", Effect: DenyAccess, PolicyID: "deny-bob", Decision: DecisionRejected},
+			},
+		},
+	} {
+		records, err := logger.QueryDecisions(c.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(c.expected, records, ignoreTimestamp) {
+			t.Errorf("Case %d: unexpected records\n%s", k, cmp.Diff(c.expected, records, ignoreTimestamp))
+		}
+	}
+
+	t.Run("Count failures", func(t *testing.T) {
+		logger := NewStoringAuditLogger(failingDecisionStore{NewMemoryDecisionStore(1)})
+		if err := newWarden(t, logger).IsAllowed(&Request{Subject: "alice", Resource: "articles:1", Action: "get"}); err != nil {
+			t.Fatalf("Expected store failures not to affect the decision, got %v", err)
+		}
+		if n := logger.Failed(); n != 1 {
+			t.Fatalf("Expected one failure, got %d", n)
+		}
+	})
+
+	t.Run("Keep all records without a capacity", func(t *testing.T) {
+		for _, capacity := range []int{0, -1} {
+			s := NewMemoryDecisionStore(capacity)
+			for i := 0; i < 3; i++ {
+				if err := s.StoreDecision(&DecisionRecord{Subject: "alice"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if records, err := s.QueryDecisions(&DecisionFilter{}); err != nil {
+				t.Fatal(err)
+			} else if len(records) != 3 {
+				t.Fatalf("Expected capacity %d to keep all 3 records, got %d", capacity, len(records))
+			}
+		}
+	})
+}
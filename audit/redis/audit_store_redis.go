@@ -0,0 +1,74 @@
+// Package redis contains a Redis backed audit.DecisionStore.
+package redis
+
+import (
+	"encoding/json"
+
+	"github.com/go-redis/redis"
+	"github.com/ory/ladon-community/audit"
+	"github.com/pkg/errors"
+)
+
+// RedisDecisionStore keeps the most recent decision records in a capped Redis list, newest first. It is
+// safe for concurrent use.
+type RedisDecisionStore struct {
+	db       redis.UniversalClient
+	key      string
+	capacity int64
+}
+
+// NewRedisDecisionStore initializes a new RedisDecisionStore keeping up to capacity records in the list
+// {keyPrefix}_decisions. Older records are discarded. A capacity of zero or less keeps all records.
+func NewRedisDecisionStore(db redis.UniversalClient, keyPrefix string, capacity int64) *RedisDecisionStore {
+	if keyPrefix == "" {
+		keyPrefix = "ladon"
+	}
+	return &RedisDecisionStore{
+		db:       db,
+		key:      "{" + keyPrefix + "}_decisions",
+		capacity: capacity,
+	}
+}
+
+// StoreDecision pushes a record onto the list and, if the store is capped, trims it to the store's
+// capacity in a single transaction.
+func (s *RedisDecisionStore) StoreDecision(record *audit.DecisionRecord) error {
+	b, err := json.Marshal(record)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	_, err = s.db.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.LPush(s.key, b)
+		if s.capacity > 0 {
+			pipe.LTrim(s.key, 0, s.capacity-1)
+		}
+		return nil
+	})
+	return err
+}
+
+// QueryDecisions returns the records selected by filter, newest first. The list isn't indexed, so every
+// query reads all stored records.
+func (s *RedisDecisionStore) QueryDecisions(filter *audit.DecisionFilter) ([]audit.DecisionRecord, error) {
+	values, err := s.db.LRange(s.key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	records := []audit.DecisionRecord{}
+	for _, v := range values {
+		if filter.Limit > 0 && len(records) == filter.Limit {
+			break
+		}
+
+		var record audit.DecisionRecord
+		if err := json.Unmarshal([]byte(v), &record); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if filter.Matches(&record) {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
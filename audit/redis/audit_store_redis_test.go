@@ -0,0 +1,109 @@
+package redis
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/google/go-cmp/cmp"
+	"github.com/ory/ladon-community/audit"
+	"gopkg.in/ory-am/dockertest.v3"
+)
+
+var (
+	db       *redis.Client
+	redisURL string
+)
+
+func TestMain(m *testing.M) {
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		log.Fatalf("Could not connect to docker: %s", err)
+	}
+
+	resource, err := pool.Run("redis", "3.2.11", nil)
+	if err != nil {
+		log.Fatalf("Could not start resource: %s", err)
+	}
+	redisURL = fmt.Sprintf("redis://localhost:%s", resource.GetPort("6379/tcp"))
+	settings, err := redis.ParseURL(redisURL)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+	db = redis.NewClient(settings)
+
+	if err := pool.Retry(func() error {
+		return db.Ping().Err()
+	}); err != nil {
+		log.Fatalf("Could not connect to docker: %s", err)
+	}
+
+	code := m.Run()
+
+	if err := pool.Purge(resource); err != nil {
+		log.Fatalf("Could not purge resource: %s", err)
+	}
+
+	os.Exit(code)
+}
+
+func TestRedisDecisionStore(t *testing.T) {
+	s := NewRedisDecisionStore(db, "decisions", 4)
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	records := []audit.DecisionRecord{
+		{Subject: "carol", Resource: "articles:1", Action: "get", Decision: audit.DecisionRejected},
+		{Subject: "alice", Resource: "articles:1", Action: "get", Effect: "allow", PolicyID: "allow-get", Decision: audit.DecisionGranted},
+		{Subject: "bob", Resource: "articles:1", Action: "get", Effect: "deny", PolicyID: "deny-bob", Decision: audit.DecisionRejected},
+		{Subject: "alice", Resource: "articles:2", Action: "get", Decision: audit.DecisionRejected},
+		{Subject: "alice", Resource: "articles:1", Action: "get", Effect: "allow", PolicyID: "allow-get", Decision: audit.DecisionGranted},
+	}
+	for i := range records {
+		records[i].Timestamp = start.Add(time.Duration(i) * time.Hour)
+		if err := s.StoreDecision(&records[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for k, c := range []struct {
+		filter   *audit.DecisionFilter
+		expected []audit.DecisionRecord
+	}{
+		{filter: &audit.DecisionFilter{Subject: "alice"}, expected: []audit.DecisionRecord{records[4], records[3], records[1]}},
+		{filter: &audit.DecisionFilter{Resource: "articles:1", Limit: 2}, expected: []audit.DecisionRecord{records[4], records[2]}},
+		{filter: &audit.DecisionFilter{Resource: "articles:1", Until: start.Add(2 * time.Hour)}, expected: []audit.DecisionRecord{records[2], records[1]}},
+		{filter: &audit.DecisionFilter{Subject: "carol"}, expected: []audit.DecisionRecord{}},
+	} {
+		actual, err := s.QueryDecisions(c.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(c.expected, actual) {
+			t.Errorf("Case %d: unexpected records\n%s", k, cmp.Diff(c.expected, actual))
+		}
+	}
+
+	if n, err := db.LLen(s.key).Result(); err != nil {
+		t.Fatal(err)
+	} else if n != 4 {
+		t.Fatalf("Expected the list to be capped at 4 records, got %d", n)
+	}
+
+	t.Run("Keep all records without a capacity", func(t *testing.T) {
+		for _, capacity := range []int64{0, -1} {
+			s := NewRedisDecisionStore(db, fmt.Sprintf("uncapped%d", capacity), capacity)
+			for i := 0; i < 3; i++ {
+				if err := s.StoreDecision(&audit.DecisionRecord{Subject: "alice"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if n, err := db.LLen(s.key).Result(); err != nil {
+				t.Fatal(err)
+			} else if n != 3 {
+				t.Fatalf("Expected capacity %d to keep all 3 records, got %d", capacity, n)
+			}
+		}
+	})
+}
@@ -0,0 +1,59 @@
+// Package condition contains additional conditions for ladon policies. Importing this package registers
+// all of them in ladon.ConditionFactories, so policies using them can be unmarshalled.
+package condition
+
+import (
+	"strings"
+
+	. "github.com/ory/ladon"
+)
+
+var factories = []func() Condition{
+	func() Condition { return new(ActionMatchCondition) },
+	func() Condition { return new(ResourceContextCondition) },
+	func() Condition { return new(TokenValidityCondition) },
+	func() Condition { return new(AllowlistCondition) },
+	func() Condition { return new(AllOfCondition) },
+	func() Condition { return new(EqualsSubjectPathCondition) },
+	func() Condition { return new(NotEqualsSubjectPathCondition) },
+	func() Condition { return new(ResourceTemplateCondition) },
+	func() Condition { return new(DeadlineCondition) },
+	func() Condition { return new(RateLimitCondition) },
+	func() Condition { return new(GroupPrefixCondition) },
+	func() Condition { return new(JSONPathCondition) },
+	func() Condition { return new(AttributeCondition) },
+	func() Condition { return new(HTTPCondition) },
+	func() Condition { return new(MatchedSubjectCondition) },
+	func() Condition { return new(HMACVerifiedCondition) },
+	func() Condition { return new(GeoCondition) },
+	func() Condition { return new(NumericFieldsCondition) },
+}
+
+func init() {
+	for _, f := range factories {
+		ConditionFactories[f().GetName()] = f
+	}
+}
+
+// lookup returns the value at a dotted path like `resource.owner.id` in the request context, descending
+// into nested maps. It returns false if any segment of the path is missing.
+func lookup(ctx Context, path string) (interface{}, bool) {
+	var value interface{} = map[string]interface{}(ctx)
+	for _, segment := range strings.Split(path, ".") {
+		var (
+			v  interface{}
+			ok bool
+		)
+		switch m := value.(type) {
+		case map[string]interface{}:
+			v, ok = m[segment]
+		case Context:
+			v, ok = m[segment]
+		}
+		if !ok {
+			return nil, false
+		}
+		value = v
+	}
+	return value, true
+}
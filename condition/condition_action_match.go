@@ -0,0 +1,22 @@
+package condition
+
+import (
+	. "github.com/ory/ladon"
+)
+
+// ActionMatchCondition is fulfilled if the request's action matches one of the given actions. Actions
+// use the same syntax as a policy's actions, so `<.*>` style regular expressions are supported.
+type ActionMatchCondition struct {
+	Actions []string `json:"actions"`
+}
+
+// Fulfills returns true if the request's action matches one of ActionMatchCondition.Actions.
+func (c *ActionMatchCondition) Fulfills(_ interface{}, r *Request) bool {
+	matches, err := DefaultMatcher.Matches(&DefaultPolicy{}, c.Actions, r.Action)
+	return err == nil && matches
+}
+
+// GetName returns the condition's name.
+func (c *ActionMatchCondition) GetName() string {
+	return "ActionMatchCondition"
+}
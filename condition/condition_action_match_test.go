@@ -0,0 +1,29 @@
+package condition
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+)
+
+func TestActionMatchCondition(t *testing.T) {
+	for k, c := range []struct {
+		actions []string
+		action  string
+		pass    bool
+	}{
+		{actions: []string{"get"}, action: "get", pass: true},
+		{actions: []string{"get"}, action: "delete", pass: false},
+		{actions: []string{"get", "update"}, action: "update", pass: true},
+		{actions: []string{"<.*>"}, action: "delete", pass: true},
+		{actions: []string{"<get|list>"}, action: "list", pass: true},
+		{actions: []string{"<get|list>"}, action: "delete", pass: false},
+		{actions: []string{"<(>"}, action: "delete", pass: false},
+		{actions: []string{}, action: "get", pass: false},
+	} {
+		condition := &ActionMatchCondition{Actions: c.actions}
+		if pass := condition.Fulfills(nil, &Request{Action: c.action}); pass != c.pass {
+			t.Errorf("Case %d: expected %v matching %s to be %t", k, c.actions, c.action, c.pass)
+		}
+	}
+}
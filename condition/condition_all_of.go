@@ -0,0 +1,100 @@
+package condition
+
+import (
+	"encoding/json"
+
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// KeyedCondition is a condition together with the context key whose value it is evaluated against.
+type KeyedCondition struct {
+	Key       string
+	Condition Condition
+}
+
+type jsonKeyedCondition struct {
+	Key     string          `json:"key"`
+	Type    string          `json:"type"`
+	Options json.RawMessage `json:"options"`
+}
+
+// MarshalJSON marshals the condition like ladon.Conditions does, adding the context key.
+func (c KeyedCondition) MarshalJSON() ([]byte, error) {
+	if c.Condition == nil {
+		return nil, errors.Errorf("condition for key %s is nil", c.Key)
+	}
+
+	raw, err := json.Marshal(c.Condition)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return json.Marshal(&jsonKeyedCondition{
+		Key:     c.Key,
+		Type:    c.Condition.GetName(),
+		Options: raw,
+	})
+}
+
+// UnmarshalJSON unmarshals the condition using the factories registered in ladon.ConditionFactories.
+func (c *KeyedCondition) UnmarshalJSON(data []byte) error {
+	var jc jsonKeyedCondition
+	if err := json.Unmarshal(data, &jc); err != nil {
+		return errors.WithStack(err)
+	}
+
+	factory, ok := ConditionFactories[jc.Type]
+	if !ok {
+		return errors.Errorf("Could not find condition type %s", jc.Type)
+	}
+
+	condition := factory()
+	if len(jc.Options) > 0 {
+		if err := json.Unmarshal(jc.Options, condition); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	c.Key = jc.Key
+	c.Condition = condition
+	return nil
+}
+
+// AllOfCondition is fulfilled if all of its conditions are fulfilled. Unlike the conditions of a policy,
+// which are evaluated in random order, they are evaluated in the order they are declared in and
+// evaluation stops at the first condition which isn't fulfilled. Put cheap conditions first to avoid
+// evaluating expensive ones, e.g. remote lookups, for requests which are going to be denied anyway.
+//
+// Each condition is evaluated against the context value of its own key, the value passed to the
+// AllOfCondition itself is ignored.
+type AllOfCondition struct {
+	Conditions []KeyedCondition `json:"conditions"`
+}
+
+// Fulfills returns true if all conditions are fulfilled.
+func (c *AllOfCondition) Fulfills(_ interface{}, r *Request) bool {
+	for _, kc := range c.Conditions {
+		if !kc.Condition.Fulfills(r.Context[kc.Key], r) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetName returns the condition's name.
+func (c *AllOfCondition) GetName() string {
+	return "AllOfCondition"
+}
+
+// ContextKeys returns the keys of its conditions and the context keys they read themselves.
+func (c *AllOfCondition) ContextKeys() []string {
+	var keys []string
+	for _, kc := range c.Conditions {
+		keys = append(keys, kc.Key)
+		if ck, ok := kc.Condition.(ContextKeyer); ok {
+			keys = append(keys, ck.ContextKeys()...)
+		}
+	}
+	return keys
+}
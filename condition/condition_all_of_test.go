@@ -0,0 +1,96 @@
+package condition
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+)
+
+// recordingCondition records whether it was evaluated.
+type recordingCondition struct {
+	pass      bool
+	evaluated bool
+}
+
+func (c *recordingCondition) Fulfills(_ interface{}, _ *Request) bool {
+	c.evaluated = true
+	return c.pass
+}
+
+func (c *recordingCondition) GetName() string {
+	return "recordingCondition"
+}
+
+func TestAllOfCondition(t *testing.T) {
+	t.Run("Skip conditions after the first failing one", func(t *testing.T) {
+		cheap := &recordingCondition{pass: false}
+		expensive := &recordingCondition{pass: true}
+		condition := &AllOfCondition{Conditions: []KeyedCondition{
+			{Key: "cheap", Condition: cheap},
+			{Key: "expensive", Condition: expensive},
+		}}
+
+		if condition.Fulfills(nil, &Request{}) {
+			t.Fatal("Expected condition not to be fulfilled")
+		}
+		if !cheap.evaluated {
+			t.Fatal("Expected the first condition to be evaluated")
+		}
+		if expensive.evaluated {
+			t.Fatal("Expected the second condition to be skipped")
+		}
+	})
+
+	t.Run("Evaluate conditions against their own context keys", func(t *testing.T) {
+		condition := &AllOfCondition{Conditions: []KeyedCondition{
+			{Key: "ip", Condition: &CIDRCondition{CIDR: "10.0.0.0/8"}},
+			{Key: "owner", Condition: &EqualsSubjectCondition{}},
+		}}
+
+		for k, c := range []struct {
+			context Context
+			pass    bool
+		}{
+			{context: Context{"ip": "10.1.2.3", "owner": "alice"}, pass: true},
+			{context: Context{"ip": "192.168.1.1", "owner": "alice"}, pass: false},
+			{context: Context{"ip": "10.1.2.3", "owner": "bob"}, pass: false},
+			{context: Context{"ip": "10.1.2.3"}, pass: false},
+		} {
+			if pass := condition.Fulfills(nil, &Request{Subject: "alice", Context: c.context}); pass != c.pass {
+				t.Errorf("Case %d: expected %t, got %t", k, c.pass, pass)
+			}
+		}
+	})
+
+	t.Run("Round trip through JSON preserving the order", func(t *testing.T) {
+		in := Conditions{"ordered": &AllOfCondition{Conditions: []KeyedCondition{
+			{Key: "owner", Condition: &EqualsSubjectCondition{}},
+			{Key: "ip", Condition: &CIDRCondition{CIDR: "10.0.0.0/8"}},
+			{Key: "nested", Condition: &AllOfCondition{Conditions: []KeyedCondition{
+				{Key: "region", Condition: &StringEqualCondition{Equals: "eu"}},
+			}}},
+		}}}
+
+		out, err := json.Marshal(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := Conditions{}
+		if err := json.Unmarshal(out, &got); err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(in, got) {
+			t.Fatalf("Unexpected conditions after round trip\n%s", cmp.Diff(in, got))
+		}
+	})
+
+	t.Run("Reject unknown condition types", func(t *testing.T) {
+		var c AllOfCondition
+		if err := json.Unmarshal([]byte(`{"conditions":[{"key":"a","type":"DoesNotExist"}]}`), &c); err == nil {
+			t.Fatal("Expected an error for an unknown condition type")
+		}
+	})
+}
@@ -0,0 +1,68 @@
+package condition
+
+import (
+	"context"
+	"sync"
+
+	. "github.com/ory/ladon"
+)
+
+// AllowlistProvider decides whether a value is on an allowlist managed outside of the policy, e.g. a
+// set of approved IP ranges stored centrally. Providers are free to cache the allowlist.
+type AllowlistProvider interface {
+	// Allowed returns true if value is on the allowlist.
+	Allowed(ctx context.Context, value interface{}, r *Request) (bool, error)
+}
+
+// AllowlistProviderFunc is an adapter to use an ordinary function as AllowlistProvider.
+type AllowlistProviderFunc func(ctx context.Context, value interface{}, r *Request) (bool, error)
+
+// Allowed calls f(ctx, value, r).
+func (f AllowlistProviderFunc) Allowed(ctx context.Context, value interface{}, r *Request) (bool, error) {
+	return f(ctx, value, r)
+}
+
+var (
+	allowlistProviders   = map[string]AllowlistProvider{}
+	allowlistProvidersMu sync.RWMutex
+)
+
+// RegisterAllowlistProvider makes provider available to AllowlistConditions under name. Registering a
+// provider under an existing name replaces it.
+func RegisterAllowlistProvider(name string, provider AllowlistProvider) {
+	allowlistProvidersMu.Lock()
+	defer allowlistProvidersMu.Unlock()
+	allowlistProviders[name] = provider
+}
+
+// AllowlistCondition is fulfilled if the registered AllowlistProvider named Provider allows the value.
+// Only the provider's name is stored in the policy, the provider itself is registered at runtime with
+// RegisterAllowlistProvider.
+//
+// If the provider fails, the condition is fulfilled only if FailOpen is set. A provider which isn't
+// registered never fulfills the condition.
+type AllowlistCondition struct {
+	Provider string `json:"provider"`
+	FailOpen bool   `json:"failOpen"`
+}
+
+// Fulfills returns true if the provider allows the value.
+func (c *AllowlistCondition) Fulfills(value interface{}, r *Request) bool {
+	allowlistProvidersMu.RLock()
+	provider, ok := allowlistProviders[c.Provider]
+	allowlistProvidersMu.RUnlock()
+	if !ok {
+		return false
+	}
+
+	allowed, err := provider.Allowed(context.Background(), value, r)
+	if err != nil {
+		return c.FailOpen
+	}
+	return allowed
+}
+
+// GetName returns the condition's name.
+func (c *AllowlistCondition) GetName() string {
+	return "AllowlistCondition"
+}
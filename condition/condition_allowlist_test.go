@@ -0,0 +1,38 @@
+package condition
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/ory/ladon"
+)
+
+func TestAllowlistCondition(t *testing.T) {
+	RegisterAllowlistProvider("test-ips", AllowlistProviderFunc(func(ctx context.Context, value interface{}, r *Request) (bool, error) {
+		switch value {
+		case "10.0.0.1":
+			return true, nil
+		case "10.0.0.2":
+			return false, nil
+		}
+		return false, errors.New("allowlist unavailable")
+	}))
+
+	for k, c := range []struct {
+		condition *AllowlistCondition
+		value     interface{}
+		pass      bool
+	}{
+		{condition: &AllowlistCondition{Provider: "test-ips"}, value: "10.0.0.1", pass: true},
+		{condition: &AllowlistCondition{Provider: "test-ips"}, value: "10.0.0.2", pass: false},
+		{condition: &AllowlistCondition{Provider: "test-ips"}, value: "10.0.0.3", pass: false},
+		{condition: &AllowlistCondition{Provider: "test-ips", FailOpen: true}, value: "10.0.0.3", pass: true},
+		{condition: &AllowlistCondition{Provider: "test-ips", FailOpen: true}, value: "10.0.0.2", pass: false},
+		{condition: &AllowlistCondition{Provider: "unknown", FailOpen: true}, value: "10.0.0.1", pass: false},
+	} {
+		if pass := c.condition.Fulfills(c.value, new(Request)); pass != c.pass {
+			t.Errorf("Case %d: expected %t, got %t", k, c.pass, pass)
+		}
+	}
+}
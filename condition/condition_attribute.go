@@ -0,0 +1,146 @@
+package condition
+
+import (
+	"strings"
+
+	. "github.com/ory/ladon"
+)
+
+// The operators of an AttributeCondition.
+const (
+	OperatorEq         = "eq"
+	OperatorNeq        = "neq"
+	OperatorIn         = "in"
+	OperatorContains   = "contains"
+	OperatorGt         = "gt"
+	OperatorLt         = "lt"
+	OperatorStartsWith = "startsWith"
+)
+
+// AttributeCondition compares a request context attribute to Operand using Operator. Attribute is a
+// dotted path like `user.department` resolved from the root of the context; if it is empty, the value of
+// the key the condition is stored under is used instead. The operators are
+//
+//	eq, neq     the attribute equals or doesn't equal the operand
+//	in          the attribute equals one of the values of the operand, which must be a list
+//	contains    the attribute is a string containing the operand or a list with a value equal to it
+//	gt, lt      the attribute is greater or less than the operand
+//	startsWith  the attribute is a string starting with the operand
+//
+// Numbers are compared by value regardless of their Go type, so an int context value equals the
+// float64 operand decoded from JSON. gt and lt compare numbers, or times if both sides are accepted by
+// ToTime, e.g. RFC3339 strings; anything else is never greater or less. All other values are equal only
+// if they are deeply equal, and lists may be []string or []interface{}.
+//
+// A missing attribute never fulfills the condition, regardless of the operator, and neither does an
+// unknown operator.
+type AttributeCondition struct {
+	Attribute string      `json:"attribute"`
+	Operator  string      `json:"operator"`
+	Operand   interface{} `json:"operand"`
+}
+
+// Fulfills returns true if the attribute compares to the operand as configured.
+func (c *AttributeCondition) Fulfills(value interface{}, r *Request) bool {
+	if c.Attribute != "" {
+		var ok bool
+		if value, ok = lookup(r.Context, c.Attribute); !ok {
+			return false
+		}
+	}
+	if value == nil {
+		return false
+	}
+
+	switch c.Operator {
+	case OperatorEq:
+		return attributeEqual(value, c.Operand)
+	case OperatorNeq:
+		return !attributeEqual(value, c.Operand)
+	case OperatorIn:
+		list, ok := toJSONTree(c.Operand).([]interface{})
+		return ok && containsEqual(list, value)
+	case OperatorContains:
+		if s, ok := value.(string); ok {
+			sub, ok := c.Operand.(string)
+			return ok && strings.Contains(s, sub)
+		}
+		list, ok := toJSONTree(value).([]interface{})
+		return ok && containsEqual(list, c.Operand)
+	case OperatorGt:
+		cmp, ok := compareAttribute(value, c.Operand)
+		return ok && cmp > 0
+	case OperatorLt:
+		cmp, ok := compareAttribute(value, c.Operand)
+		return ok && cmp < 0
+	case OperatorStartsWith:
+		s, ok := value.(string)
+		prefix, isString := c.Operand.(string)
+		return ok && isString && strings.HasPrefix(s, prefix)
+	}
+	return false
+}
+
+// GetName returns the condition's name.
+func (c *AttributeCondition) GetName() string {
+	return "AttributeCondition"
+}
+
+// ContextKeys returns Attribute, or nothing if the condition reads the value of its own key.
+func (c *AttributeCondition) ContextKeys() []string {
+	if c.Attribute == "" {
+		return nil
+	}
+	return []string{c.Attribute}
+}
+
+// attributeEqual is jsonEqual which also treats []string and []interface{} lists alike.
+func attributeEqual(a, b interface{}) bool {
+	return jsonEqual(toJSONTree(a), toJSONTree(b))
+}
+
+func containsEqual(list []interface{}, value interface{}) bool {
+	for _, v := range list {
+		if attributeEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareAttribute returns -1, 0 or 1 if a is less than, equal to or greater than b, comparing numbers
+// or times. It returns false if a and b are neither.
+func compareAttribute(a, b interface{}) (int, bool) {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	x, err := ToTime(a)
+	if err != nil {
+		return 0, false
+	}
+	if _, isNumber := toFloat(b); isNumber {
+		return 0, false
+	}
+	y, err := ToTime(b)
+	if err != nil {
+		return 0, false
+	}
+	switch {
+	case x.Before(y):
+		return -1, true
+	case x.After(y):
+		return 1, true
+	}
+	return 0, true
+}
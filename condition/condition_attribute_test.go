@@ -0,0 +1,104 @@
+package condition
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/ory/ladon"
+)
+
+func TestAttributeCondition(t *testing.T) {
+	ctx := Context{
+		"user": map[string]interface{}{
+			"department": "engineering",
+			"level":      3,
+			"groups":     []string{"admins", "oncall"},
+			"joined":     "2018-01-01T12:00:00Z",
+		},
+		"clearance": float64(2),
+		"tags":      []interface{}{"public", float64(7)},
+		"hired":     time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	for k, c := range []struct {
+		attribute string
+		operator  string
+		operand   interface{}
+		value     interface{}
+		pass      bool
+	}{
+		{attribute: "user.department", operator: OperatorEq, operand: "engineering", pass: true},
+		{attribute: "user.department", operator: OperatorEq, operand: "sales", pass: false},
+		{attribute: "user.level", operator: OperatorEq, operand: float64(3), pass: true},
+		{attribute: "user.level", operator: OperatorEq, operand: "3", pass: false},
+		{attribute: "user.groups", operator: OperatorEq, operand: []interface{}{"admins", "oncall"}, pass: true},
+		{attribute: "user.department", operator: OperatorNeq, operand: "sales", pass: true},
+		{attribute: "user.department", operator: OperatorNeq, operand: "engineering", pass: false},
+		{attribute: "user.department", operator: OperatorIn, operand: []interface{}{"sales", "engineering"}, pass: true},
+		{attribute: "user.department", operator: OperatorIn, operand: []string{"sales", "support"}, pass: false},
+		{attribute: "clearance", operator: OperatorIn, operand: []interface{}{1, 2}, pass: true},
+		{attribute: "user.department", operator: OperatorIn, operand: "engineering", pass: false},
+		{attribute: "user.department", operator: OperatorContains, operand: "gineer", pass: true},
+		{attribute: "user.department", operator: OperatorContains, operand: "sales", pass: false},
+		{attribute: "user.groups", operator: OperatorContains, operand: "oncall", pass: true},
+		{attribute: "user.groups", operator: OperatorContains, operand: "guests", pass: false},
+		{attribute: "tags", operator: OperatorContains, operand: 7, pass: true},
+		{attribute: "user.level", operator: OperatorContains, operand: 3, pass: false},
+		{attribute: "user.level", operator: OperatorGt, operand: float64(2), pass: true},
+		{attribute: "user.level", operator: OperatorGt, operand: float64(3), pass: false},
+		{attribute: "user.level", operator: OperatorGt, operand: "2", pass: false},
+		{attribute: "user.joined", operator: OperatorGt, operand: "2017-06-01T00:00:00Z", pass: true},
+		{attribute: "hired", operator: OperatorGt, operand: "2019-01-01T00:00:00Z", pass: false},
+		{attribute: "user.department", operator: OperatorGt, operand: "a", pass: false},
+		{attribute: "clearance", operator: OperatorLt, operand: 3, pass: true},
+		{attribute: "clearance", operator: OperatorLt, operand: 2, pass: false},
+		{attribute: "hired", operator: OperatorLt, operand: "2019-01-01T00:00:00Z", pass: true},
+		{attribute: "user.joined", operator: OperatorLt, operand: float64(1e10), pass: false},
+		{attribute: "user.department", operator: OperatorStartsWith, operand: "eng", pass: true},
+		{attribute: "user.department", operator: OperatorStartsWith, operand: "sales", pass: false},
+		{attribute: "user.level", operator: OperatorStartsWith, operand: "3", pass: false},
+		{operator: OperatorEq, operand: "engineering", value: "engineering", pass: true},
+		{attribute: "user.department", operator: "matches", operand: "engineering", pass: false},
+	} {
+		condition := &AttributeCondition{Attribute: c.attribute, Operator: c.operator, Operand: c.operand}
+		if pass := condition.Fulfills(c.value, &Request{Context: ctx}); pass != c.pass {
+			t.Errorf("Case %d: expected %t for %s %s %v, got %t", k, c.pass, c.attribute, c.operator, c.operand, pass)
+		}
+	}
+}
+
+func TestAttributeConditionMissing(t *testing.T) {
+	for k, operator := range []string{OperatorEq, OperatorNeq, OperatorIn, OperatorContains, OperatorGt, OperatorLt, OperatorStartsWith} {
+		condition := &AttributeCondition{Attribute: "user.department", Operator: operator, Operand: []interface{}{}}
+		for _, ctx := range []Context{{}, {"user": map[string]interface{}{}}, {"user": "alice"}} {
+			if condition.Fulfills(nil, &Request{Context: ctx}) {
+				t.Errorf("Case %d: expected %s to fail for a missing attribute in %v", k, operator, ctx)
+			}
+		}
+		condition.Attribute = ""
+		if condition.Fulfills(nil, &Request{Context: Context{}}) {
+			t.Errorf("Case %d: expected %s to fail for a missing value", k, operator)
+		}
+	}
+}
+
+func TestAttributeConditionFromJSON(t *testing.T) {
+	var p DefaultPolicy
+	if err := json.Unmarshal([]byte(`{
+		"id": "engineering",
+		"conditions": {
+			"department": {
+				"type": "AttributeCondition",
+				"options": {"attribute": "user.department", "operator": "in", "operand": ["engineering", "security"]}
+			}
+		}
+	}`), &p); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Request{Context: Context{"user": map[string]interface{}{"department": "security"}}}
+	if !p.Conditions["department"].Fulfills(nil, r) {
+		t.Fatal("Expected the decoded condition to be fulfilled")
+	}
+}
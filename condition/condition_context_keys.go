@@ -0,0 +1,39 @@
+package condition
+
+import (
+	"sort"
+
+	. "github.com/ory/ladon"
+)
+
+// ContextKeyer is implemented by conditions which read context keys other than the one they are stored
+// under, or nested paths like `resource.owner`.
+type ContextKeyer interface {
+	// ContextKeys returns the context keys or dotted paths the condition reads.
+	ContextKeys() []string
+}
+
+// RequiredContextKeys returns the context keys the conditions of p read, sorted and without duplicates,
+// e.g. to show policy authors what a request has to supply. ladon's DefaultPolicy is defined upstream, so
+// this is a function rather than a method.
+//
+// Only conditions implementing ContextKeyer contribute keys. The keys conditions are stored under aren't
+// included, as most conditions read the value of their key but some, like DeadlineCondition, ignore it.
+func RequiredContextKeys(p Policy) []string {
+	seen := map[string]bool{}
+	keys := []string{}
+	for _, condition := range p.GetConditions() {
+		ck, ok := condition.(ContextKeyer)
+		if !ok {
+			continue
+		}
+		for _, key := range ck.ContextKeys() {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,48 @@
+package condition
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+)
+
+func TestRequiredContextKeys(t *testing.T) {
+	for k, c := range []struct {
+		conditions Conditions
+		expected   []string
+	}{
+		{conditions: Conditions{}, expected: []string{}},
+		{conditions: Conditions{"ip": &CIDRCondition{CIDR: "10.0.0.0/8"}}, expected: []string{}},
+		{
+			conditions: Conditions{
+				"ip":       &CIDRCondition{CIDR: "10.0.0.0/8"},
+				"token":    &TokenValidityCondition{ExpiresAtKey: "tokenExp"},
+				"deadline": &DeadlineCondition{},
+				"owner":    &EqualsSubjectPathCondition{Path: "resource.owner"},
+				"self":     &NotEqualsSubjectPathCondition{},
+				"project":  &ResourceTemplateCondition{Template: "projects:{id}", Captures: map[string]string{"id": "claims.project"}},
+				"all": &AllOfCondition{Conditions: []KeyedCondition{
+					{Key: "groups", Condition: &GroupPrefixCondition{Group: "/org/eng"}},
+					{Key: "share", Condition: &DeadlineCondition{DeadlineKey: "shareExpiresAt"}},
+				}},
+			},
+			expected: []string{
+				"claims.project",
+				"expiresAt",
+				"groups",
+				"nbf",
+				"requestTime",
+				"resource.owner",
+				"share",
+				"shareExpiresAt",
+				"tokenExp",
+			},
+		},
+	} {
+		keys := RequiredContextKeys(&DefaultPolicy{Conditions: c.conditions})
+		if !cmp.Equal(c.expected, keys) {
+			t.Errorf("Case %d: unexpected keys\n%s", k, cmp.Diff(c.expected, keys))
+		}
+	}
+}
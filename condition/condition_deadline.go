@@ -0,0 +1,50 @@
+package condition
+
+import (
+	"time"
+
+	. "github.com/ory/ladon"
+)
+
+// DeadlineCondition is fulfilled until the deadline passed in the request context, e.g. the expiry of a
+// share link, has passed. The deadline and the request time may be given as any value accepted by
+// ToTime; the request time defaults to now.
+type DeadlineCondition struct {
+	// DeadlineKey is the context key of the deadline, defaults to "expiresAt".
+	DeadlineKey string `json:"deadlineKey"`
+
+	// RequestTimeKey is the context key of the request time, defaults to "requestTime".
+	RequestTimeKey string `json:"requestTimeKey"`
+
+	// AllowInvalid fulfills the condition if the deadline is missing or malformed. By default, such
+	// requests are denied.
+	AllowInvalid bool `json:"allowInvalid"`
+}
+
+// Fulfills returns true if the request time is not after the deadline.
+func (c *DeadlineCondition) Fulfills(_ interface{}, r *Request) bool {
+	now := time.Now()
+	if v, ok := r.Context[withDefault(c.RequestTimeKey, "requestTime")]; ok {
+		t, err := ToTime(v)
+		if err != nil {
+			return false
+		}
+		now = t
+	}
+
+	deadline, err := ToTime(r.Context[withDefault(c.DeadlineKey, "expiresAt")])
+	if err != nil {
+		return c.AllowInvalid
+	}
+	return !now.After(deadline)
+}
+
+// GetName returns the condition's name.
+func (c *DeadlineCondition) GetName() string {
+	return "DeadlineCondition"
+}
+
+// ContextKeys returns the context keys of the deadline and request time.
+func (c *DeadlineCondition) ContextKeys() []string {
+	return []string{withDefault(c.DeadlineKey, "expiresAt"), withDefault(c.RequestTimeKey, "requestTime")}
+}
@@ -0,0 +1,47 @@
+package condition
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/ory/ladon"
+)
+
+func TestDeadlineCondition(t *testing.T) {
+	var (
+		now      = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+		before   = now.Add(-time.Hour)
+		after    = now.Add(time.Hour)
+		rfc3339  = func(t time.Time) string { return t.Format(time.RFC3339) }
+		epochSec = func(t time.Time) float64 { return float64(t.Unix()) }
+	)
+
+	for k, c := range []struct {
+		condition *DeadlineCondition
+		context   Context
+		pass      bool
+	}{
+		{condition: &DeadlineCondition{}, context: Context{"expiresAt": rfc3339(after), "requestTime": rfc3339(now)}, pass: true},
+		{condition: &DeadlineCondition{}, context: Context{"expiresAt": rfc3339(before), "requestTime": rfc3339(now)}, pass: false},
+		{condition: &DeadlineCondition{}, context: Context{"expiresAt": epochSec(after), "requestTime": epochSec(now)}, pass: true},
+		{condition: &DeadlineCondition{}, context: Context{"expiresAt": epochSec(before), "requestTime": epochSec(now)}, pass: false},
+		{condition: &DeadlineCondition{}, context: Context{"expiresAt": rfc3339(now), "requestTime": epochSec(now)}, pass: true},
+		{condition: &DeadlineCondition{}, context: Context{"expiresAt": rfc3339(time.Now().Add(time.Hour))}, pass: true},
+		{condition: &DeadlineCondition{}, context: Context{"expiresAt": rfc3339(time.Now().Add(-time.Hour))}, pass: false},
+		{condition: &DeadlineCondition{}, context: Context{"expiresAt": "tomorrow", "requestTime": rfc3339(now)}, pass: false},
+		{condition: &DeadlineCondition{}, context: Context{"requestTime": rfc3339(now)}, pass: false},
+		{condition: &DeadlineCondition{AllowInvalid: true}, context: Context{"expiresAt": "tomorrow", "requestTime": rfc3339(now)}, pass: true},
+		{condition: &DeadlineCondition{AllowInvalid: true}, context: Context{}, pass: true},
+		{condition: &DeadlineCondition{AllowInvalid: true}, context: Context{"expiresAt": rfc3339(before), "requestTime": rfc3339(now)}, pass: false},
+		{condition: &DeadlineCondition{AllowInvalid: true}, context: Context{"requestTime": "noon"}, pass: false},
+		{
+			condition: &DeadlineCondition{DeadlineKey: "linkExpiry", RequestTimeKey: "at"},
+			context:   Context{"linkExpiry": rfc3339(after), "at": rfc3339(now), "expiresAt": rfc3339(before)},
+			pass:      true,
+		},
+	} {
+		if pass := c.condition.Fulfills(nil, &Request{Context: c.context}); pass != c.pass {
+			t.Errorf("Case %d: expected %t, got %t", k, c.pass, pass)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package condition
+
+import (
+	"encoding/json"
+
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// ErrMalformedPolicy is the cause of errors returned by UnmarshalPolicy and UnmarshalPolicyLenient if
+// decoding a policy panicked.
+var ErrMalformedPolicy = errors.New("Malformed policy")
+
+// UnmarshalPolicy unmarshals a JSON policy like json.Unmarshal, but never panics. Policies may be written
+// by semi-trusted operators, and a condition type's UnmarshalJSON, including those of third party
+// conditions registered in ladon.ConditionFactories, might panic on input it doesn't expect. Such a panic
+// is returned as an error wrapping ErrMalformedPolicy. p is undefined if an error is returned.
+func UnmarshalPolicy(data []byte, p *DefaultPolicy) (err error) {
+	defer recoverDecode(&err)
+	return json.Unmarshal(data, p)
+}
+
+// recoverDecode turns a panic of a decoder into an error wrapping ErrMalformedPolicy. It has to be
+// deferred directly.
+func recoverDecode(err *error) {
+	if r := recover(); r != nil {
+		*err = errors.Wrapf(ErrMalformedPolicy, "decoding panicked: %v", r)
+	}
+}
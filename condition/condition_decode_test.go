@@ -0,0 +1,73 @@
+package condition
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// panickingCondition panics when it is unmarshalled.
+type panickingCondition struct{}
+
+func (c *panickingCondition) Fulfills(interface{}, *Request) bool { return false }
+func (c *panickingCondition) GetName() string                     { return "panickingCondition" }
+func (c *panickingCondition) UnmarshalJSON([]byte) error          { panic("unexpected input") }
+
+func TestUnmarshalPolicy(t *testing.T) {
+	ConditionFactories["panickingCondition"] = func() Condition { return new(panickingCondition) }
+	defer delete(ConditionFactories, "panickingCondition")
+
+	for k, data := range []string{
+		`{"id": "p", "conditions": {"a": {"type": "panickingCondition", "options": {}}}}`,
+		`{"id": "p", "conditions": {"a": {"type": "AllOfCondition", "options": {"conditions": [{"key": "b", "type": "panickingCondition", "options": {}}]}}}}`,
+	} {
+		for name, unmarshal := range map[string]func([]byte, *DefaultPolicy) error{
+			"strict":  UnmarshalPolicy,
+			"lenient": UnmarshalPolicyLenient,
+		} {
+			if err := unmarshal([]byte(data), new(DefaultPolicy)); errors.Cause(err) != ErrMalformedPolicy {
+				t.Errorf("Case %d (%s): expected ErrMalformedPolicy, got %v", k, name, err)
+			}
+		}
+	}
+
+	var p DefaultPolicy
+	if err := UnmarshalPolicy([]byte(`{"id": "p", "conditions": {"ip": {"type": "CIDRCondition", "options": {"cidr": "10.0.0.0/8"}}}}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if c, ok := p.Conditions["ip"].(*CIDRCondition); !ok || c.CIDR != "10.0.0.0/8" {
+		t.Fatalf("Unexpected conditions %+v", p.Conditions)
+	}
+}
+
+// FuzzUnmarshalPolicy checks that decoding never panics. Inputs which made the decoder panic are kept in
+// testdata/fuzz/FuzzUnmarshalPolicy, which go test runs like the seeds.
+func FuzzUnmarshalPolicy(f *testing.F) {
+	for _, seed := range []string{
+		`{}`,
+		`null`,
+		`{"id": "p", "subjects": ["<.*>"], "effect": "allow", "conditions": null}`,
+		`{"id": "p", "conditions": {"ip": {"type": "CIDRCondition", "options": {"cidr": "10.0.0.0/8"}}}}`,
+		`{"id": "p", "conditions": {"a": {"type": "AllOfCondition", "options": {"conditions": [null, {"key": "b"}]}}}}`,
+		`{"id": "p", "conditions": {"a": {"type": "JSONPathCondition", "options": {"path": "$[?(@.a == ]"}}}}`,
+		`{"id": "p", "conditions": {"a": {"type": "MatchedSubjectCondition", "options": {"branches": [{"conditions": [{}]}]}}}}`,
+		`{"id": "p", "conditions": {"a": {"type": "DoesNotExist"}}, "meta": "e30="}`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, unmarshal := range []func([]byte, *DefaultPolicy) error{UnmarshalPolicy, UnmarshalPolicyLenient} {
+			var p DefaultPolicy
+			if err := unmarshal(data, &p); err != nil {
+				continue
+			}
+			// A policy which was decoded has to survive being stored again.
+			if _, err := json.Marshal(&p); err != nil {
+				t.Errorf("Decoded policy can't be marshalled: %s", err)
+			}
+		}
+	})
+}
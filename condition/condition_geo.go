@@ -0,0 +1,101 @@
+package condition
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+
+	. "github.com/ory/ladon"
+)
+
+// GeoResolver locates IP addresses for GeoConditions, e.g. using a GeoIP database. Keeping the lookup
+// behind this interface keeps the database out of this package.
+type GeoResolver interface {
+	// Country returns the ISO 3166-1 alpha-2 code of the country ip is located in, e.g. `DE`, or an empty
+	// string if ip can't be located. Errors are reserved for failures of the lookup itself.
+	Country(ctx context.Context, ip net.IP) (string, error)
+}
+
+// GeoResolverFunc is an adapter to use an ordinary function as GeoResolver.
+type GeoResolverFunc func(ctx context.Context, ip net.IP) (string, error)
+
+// Country calls f(ctx, ip).
+func (f GeoResolverFunc) Country(ctx context.Context, ip net.IP) (string, error) {
+	return f(ctx, ip)
+}
+
+var (
+	geoResolvers   = map[string]GeoResolver{}
+	geoResolversMu sync.RWMutex
+)
+
+// RegisterGeoResolver makes resolver available to GeoConditions under name. Registering a resolver under
+// an existing name replaces it.
+func RegisterGeoResolver(name string, resolver GeoResolver) {
+	geoResolversMu.Lock()
+	defer geoResolversMu.Unlock()
+	geoResolvers[name] = resolver
+}
+
+// GeoCondition is fulfilled if the value, an IP address like the `remoteIP` a CIDRCondition checks, is
+// located in an allowed country. The country is looked up with the registered GeoResolver named Resolver,
+// so only its name is stored in the policy.
+//
+// A country listed in Denied never fulfills the condition. If Allowed is empty, every other country does;
+// otherwise only those listed. Country codes are compared ignoring case.
+//
+// Addresses the resolver can't locate fulfill the condition only if AllowUnresolved is set. A value which
+// isn't an IP address, an unregistered resolver and a resolver returning an error never fulfill it.
+type GeoCondition struct {
+	Resolver        string   `json:"resolver"`
+	Allowed         []string `json:"allowed,omitempty"`
+	Denied          []string `json:"denied,omitempty"`
+	AllowUnresolved bool     `json:"allowUnresolved,omitempty"`
+}
+
+// Fulfills returns true if the value is located in an allowed country.
+func (c *GeoCondition) Fulfills(value interface{}, _ *Request) bool {
+	s, ok := value.(string)
+	if !ok {
+		return false
+	}
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return false
+	}
+
+	geoResolversMu.RLock()
+	resolver, ok := geoResolvers[c.Resolver]
+	geoResolversMu.RUnlock()
+	if !ok {
+		return false
+	}
+	country, err := resolver.Country(context.Background(), ip)
+	if err != nil {
+		return false
+	}
+	if country == "" {
+		return c.AllowUnresolved
+	}
+
+	if containsFold(c.Denied, country) {
+		return false
+	}
+	return len(c.Allowed) == 0 || containsFold(c.Allowed, country)
+}
+
+// GetName returns the condition's name.
+func (c *GeoCondition) GetName() string {
+	return "GeoCondition"
+}
+
+// containsFold returns true if values contains s, ignoring case.
+func containsFold(values []string, s string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package condition
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	. "github.com/ory/ladon"
+)
+
+func TestGeoCondition(t *testing.T) {
+	RegisterGeoResolver("test-geo", GeoResolverFunc(func(ctx context.Context, ip net.IP) (string, error) {
+		switch ip.String() {
+		case "1.1.1.1":
+			return "DE", nil
+		case "2.2.2.2":
+			return "us", nil
+		case "3.3.3.3":
+			return "KP", nil
+		case "4.4.4.4":
+			return "", errors.New("database unavailable")
+		}
+		return "", nil
+	}))
+
+	allowlist := &GeoCondition{Resolver: "test-geo", Allowed: []string{"de", "US"}}
+	denylist := &GeoCondition{Resolver: "test-geo", Denied: []string{"KP"}}
+	for k, c := range []struct {
+		condition *GeoCondition
+		value     interface{}
+		pass      bool
+	}{
+		{condition: allowlist, value: "1.1.1.1", pass: true},
+		{condition: allowlist, value: "2.2.2.2", pass: true},
+		{condition: allowlist, value: "3.3.3.3", pass: false},
+		{condition: denylist, value: "1.1.1.1", pass: true},
+		{condition: denylist, value: "3.3.3.3", pass: false},
+		{condition: &GeoCondition{Resolver: "test-geo", Allowed: []string{"KP"}, Denied: []string{"KP"}}, value: "3.3.3.3", pass: false},
+
+		// Unresolvable addresses
+		{condition: allowlist, value: "5.5.5.5", pass: false},
+		{condition: denylist, value: "5.5.5.5", pass: false},
+		{condition: &GeoCondition{Resolver: "test-geo", Denied: []string{"KP"}, AllowUnresolved: true}, value: "5.5.5.5", pass: true},
+		{condition: &GeoCondition{Resolver: "test-geo", AllowUnresolved: true}, value: "4.4.4.4", pass: false},
+
+		// Malformed values and configurations
+		{condition: denylist, value: nil, pass: false},
+		{condition: denylist, value: "not an ip", pass: false},
+		{condition: denylist, value: 1, pass: false},
+		{condition: &GeoCondition{Resolver: "unknown"}, value: "1.1.1.1", pass: false},
+	} {
+		if pass := c.condition.Fulfills(c.value, &Request{}); pass != c.pass {
+			t.Errorf("Case %d: expected %t, got %t", k, c.pass, pass)
+		}
+	}
+}
+
+func TestGeoConditionInPolicy(t *testing.T) {
+	RegisterGeoResolver("test-geo", GeoResolverFunc(func(ctx context.Context, ip net.IP) (string, error) {
+		return "DE", nil
+	}))
+
+	var p DefaultPolicy
+	if err := UnmarshalPolicy([]byte(`{
+		"id": "geo",
+		"conditions": {"remoteIP": {"type": "GeoCondition", "options": {"resolver": "test-geo", "allowed": ["DE"]}}}
+	}`), &p); err != nil {
+		t.Fatal(err)
+	}
+	if !p.Conditions["remoteIP"].Fulfills("1.1.1.1", &Request{}) {
+		t.Fatal("Expected the unmarshalled condition to be fulfilled")
+	}
+}
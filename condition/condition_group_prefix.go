@@ -0,0 +1,68 @@
+package condition
+
+import (
+	"strings"
+
+	. "github.com/ory/ladon"
+)
+
+// GroupPrefixCondition is fulfilled if the group path in the request context, e.g. `/org/eng/backend`, is
+// Group or one of its descendants, so a policy for `/org/eng` applies to all of engineering. Paths are
+// separated by `/` and trailing slashes are ignored, so `/org/eng/` equals `/org/eng`. A sibling like
+// `/org/engineering` doesn't match.
+//
+// The value may also be a list of group paths, in which case one of them has to match. An empty Group
+// never fulfills the condition.
+type GroupPrefixCondition struct {
+	Group string `json:"group"`
+}
+
+// Fulfills returns true if the value is Group or a descendant of it.
+func (c *GroupPrefixCondition) Fulfills(value interface{}, _ *Request) bool {
+	group := trimGroup(c.Group)
+	if group == "" {
+		return false
+	}
+
+	switch v := value.(type) {
+	case string:
+		return inGroup(group, v)
+	case []string:
+		for _, path := range v {
+			if inGroup(group, path) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, path := range v {
+			if s, ok := path.(string); ok && inGroup(group, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetName returns the condition's name.
+func (c *GroupPrefixCondition) GetName() string {
+	return "GroupPrefixCondition"
+}
+
+// trimGroup removes trailing slashes from a group path, keeping the root `/`.
+func trimGroup(path string) string {
+	if trimmed := strings.TrimRight(path, "/"); trimmed != "" || path == "" {
+		return trimmed
+	}
+	return "/"
+}
+
+func inGroup(group, path string) bool {
+	path = trimGroup(path)
+	if path == "" {
+		return false
+	}
+	if group == "/" {
+		return strings.HasPrefix(path, "/")
+	}
+	return path == group || strings.HasPrefix(path, group+"/")
+}
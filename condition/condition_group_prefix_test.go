@@ -0,0 +1,49 @@
+package condition
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/ory/ladon"
+)
+
+func TestGroupPrefixCondition(t *testing.T) {
+	for k, c := range []struct {
+		group string
+		value interface{}
+		pass  bool
+	}{
+		{group: "/org/eng", value: "/org/eng", pass: true},
+		{group: "/org/eng", value: "/org/eng/backend", pass: true},
+		{group: "/org/eng", value: "/org/eng/backend/payments", pass: true},
+		{group: "/org/eng/", value: "/org/eng", pass: true},
+		{group: "/org/eng", value: "/org/eng/", pass: true},
+		{group: "/org/eng", value: "/org/engineering", pass: false},
+		{group: "/org/eng", value: "/org/sales", pass: false},
+		{group: "/org/eng", value: "/org", pass: false},
+		{group: "/org/eng/backend", value: "/org/eng", pass: false},
+		{group: "/", value: "/org/eng", pass: true},
+		{group: "/", value: "org", pass: false},
+		{group: "", value: "/org/eng", pass: false},
+		{group: "/org/eng", value: "", pass: false},
+		{group: "/org/eng", value: nil, pass: false},
+		{group: "/org/eng", value: 1, pass: false},
+		{group: "/org/eng", value: []string{"/org/sales", "/org/eng/backend"}, pass: true},
+		{group: "/org/eng", value: []interface{}{"/org/sales", 1}, pass: false},
+	} {
+		condition := &GroupPrefixCondition{Group: c.group}
+		if pass := condition.Fulfills(c.value, new(Request)); pass != c.pass {
+			t.Errorf("Case %d: expected %t for group %q and value %v, got %t", k, c.pass, c.group, c.value, pass)
+		}
+	}
+
+	t.Run("Decode group lists from JSON", func(t *testing.T) {
+		var ctx Context
+		if err := json.Unmarshal([]byte(`{"groups": ["/org/sales", "/org/eng/backend"]}`), &ctx); err != nil {
+			t.Fatal(err)
+		}
+		if !(&GroupPrefixCondition{Group: "/org/eng"}).Fulfills(ctx["groups"], new(Request)) {
+			t.Fatal("Expected a group list decoded from JSON to match")
+		}
+	})
+}
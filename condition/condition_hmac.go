@@ -0,0 +1,114 @@
+package condition
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"sync"
+
+	. "github.com/ory/ladon"
+)
+
+// HMACKeyProvider returns the secrets HMACVerifiedConditions verify signatures with, e.g. from a secret
+// store. Providers are free to cache the secrets.
+type HMACKeyProvider interface {
+	// Key returns the secret with the ID keyID.
+	Key(ctx context.Context, keyID string) ([]byte, error)
+}
+
+// HMACKeyProviderFunc is an adapter to use an ordinary function as HMACKeyProvider.
+type HMACKeyProviderFunc func(ctx context.Context, keyID string) ([]byte, error)
+
+// Key calls f(ctx, keyID).
+func (f HMACKeyProviderFunc) Key(ctx context.Context, keyID string) ([]byte, error) {
+	return f(ctx, keyID)
+}
+
+var (
+	hmacKeyProviders   = map[string]HMACKeyProvider{}
+	hmacKeyProvidersMu sync.RWMutex
+)
+
+// RegisterHMACKeyProvider makes provider available to HMACVerifiedConditions under name. Registering a
+// provider under an existing name replaces it.
+func RegisterHMACKeyProvider(name string, provider HMACKeyProvider) {
+	hmacKeyProvidersMu.Lock()
+	defer hmacKeyProvidersMu.Unlock()
+	hmacKeyProviders[name] = provider
+}
+
+// HMACVerifiedCondition is fulfilled if the value, a HMAC-SHA256 signature, matches the payload in the
+// request context at PayloadKey, which may be a dotted path like `upstream.claims`. It lets a policy trust
+// context values set by an upstream service only if they weren't tampered with on the way.
+//
+// The signature is encoded as set by Encoding, `hex` (the default) or `base64`. The payload has to be a
+// string. The secret is read from the registered HMACKeyProvider named Provider using KeyID, so only the
+// IDs of the provider and key are stored in the policy.
+//
+// A missing or malformed signature or payload, an unregistered provider and a provider failing to return
+// the key never fulfill the condition.
+type HMACVerifiedCondition struct {
+	Provider   string `json:"provider"`
+	KeyID      string `json:"keyID"`
+	PayloadKey string `json:"payloadKey"`
+	Encoding   string `json:"encoding,omitempty"`
+}
+
+// Fulfills returns true if the value is a valid signature of the payload.
+func (c *HMACVerifiedCondition) Fulfills(value interface{}, r *Request) bool {
+	signature, ok := value.(string)
+	if !ok {
+		return false
+	}
+	var (
+		expected []byte
+		err      error
+	)
+	switch c.Encoding {
+	case "", "hex":
+		expected, err = hex.DecodeString(signature)
+	case "base64":
+		expected, err = base64.StdEncoding.DecodeString(signature)
+	default:
+		return false
+	}
+	if err != nil || len(expected) == 0 {
+		return false
+	}
+
+	v, ok := lookup(r.Context, c.PayloadKey)
+	if !ok {
+		return false
+	}
+	payload, ok := v.(string)
+	if !ok {
+		return false
+	}
+
+	hmacKeyProvidersMu.RLock()
+	provider, ok := hmacKeyProviders[c.Provider]
+	hmacKeyProvidersMu.RUnlock()
+	if !ok {
+		return false
+	}
+	key, err := provider.Key(context.Background(), c.KeyID)
+	if err != nil || len(key) == 0 {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return hmac.Equal(expected, mac.Sum(nil))
+}
+
+// GetName returns the condition's name.
+func (c *HMACVerifiedCondition) GetName() string {
+	return "HMACVerifiedCondition"
+}
+
+// ContextKeys returns PayloadKey.
+func (c *HMACVerifiedCondition) ContextKeys() []string {
+	return []string{c.PayloadKey}
+}
@@ -0,0 +1,68 @@
+package condition
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	. "github.com/ory/ladon"
+)
+
+func TestHMACVerifiedCondition(t *testing.T) {
+	RegisterHMACKeyProvider("test-keys", HMACKeyProviderFunc(func(ctx context.Context, keyID string) ([]byte, error) {
+		if keyID == "upstream" {
+			return []byte("secret"), nil
+		}
+		return nil, errors.New("unknown key")
+	}))
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("tenant=acme"))
+	signature := mac.Sum(nil)
+
+	condition := &HMACVerifiedCondition{Provider: "test-keys", KeyID: "upstream", PayloadKey: "upstream.payload"}
+	for k, c := range []struct {
+		condition *HMACVerifiedCondition
+		value     interface{}
+		payload   interface{}
+		pass      bool
+	}{
+		{condition: condition, value: hex.EncodeToString(signature), payload: "tenant=acme", pass: true},
+		{condition: condition, value: hex.EncodeToString(signature), payload: "tenant=evil", pass: false},
+		{condition: condition, value: nil, payload: "tenant=acme", pass: false},
+		{condition: condition, value: "", payload: "tenant=acme", pass: false},
+		{condition: condition, value: "not hex", payload: "tenant=acme", pass: false},
+		{condition: condition, value: hex.EncodeToString(signature), payload: nil, pass: false},
+		{condition: condition, value: base64.StdEncoding.EncodeToString(signature), payload: "tenant=acme", pass: false},
+		{
+			condition: &HMACVerifiedCondition{Provider: "test-keys", KeyID: "upstream", PayloadKey: "upstream.payload", Encoding: "base64"},
+			value:     base64.StdEncoding.EncodeToString(signature),
+			payload:   "tenant=acme",
+			pass:      true,
+		},
+		{
+			condition: &HMACVerifiedCondition{Provider: "test-keys", KeyID: "other", PayloadKey: "upstream.payload"},
+			value:     hex.EncodeToString(signature),
+			payload:   "tenant=acme",
+			pass:      false,
+		},
+		{
+			condition: &HMACVerifiedCondition{Provider: "unknown", KeyID: "upstream", PayloadKey: "upstream.payload"},
+			value:     hex.EncodeToString(signature),
+			payload:   "tenant=acme",
+			pass:      false,
+		},
+	} {
+		ctx := Context{}
+		if c.payload != nil {
+			ctx["upstream"] = map[string]interface{}{"payload": c.payload}
+		}
+		if pass := c.condition.Fulfills(c.value, &Request{Context: ctx}); pass != c.pass {
+			t.Errorf("Case %d: expected %t, got %t", k, c.pass, pass)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package condition
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// DefaultHTTPTimeout is the timeout of an HTTPCondition's requests if it doesn't set one.
+const DefaultHTTPTimeout = 5 * time.Second
+
+var (
+	// HTTPRetryBackoff is how long an HTTPCondition waits before its first retry. The wait doubles with
+	// every further retry.
+	HTTPRetryBackoff = 100 * time.Millisecond
+
+	// HTTPCacheSize is the maximum number of answers cached by all HTTPConditions together. Once it is
+	// reached, expired answers are dropped and then the least recently used ones.
+	HTTPCacheSize = 10000
+)
+
+// HTTPCondition asks an external authorization service, e.g. Open Policy Agent, whether the request is
+// allowed. It sends Body to URL using Method, which defaults to POST, and is fulfilled if the service
+// responds with a 2xx status and a JSON body of `true`, `{"allow": true}` or `{"result": true}`.
+//
+// Body is a text/template executed with the fields Subject, Resource, Action, Context and Value, the
+// value of the key the condition is stored under. The template function json marshals its argument,
+// e.g. `{"input": {"user": {{json .Subject}}, "roles": {{json .Context.roles}}}}`.
+//
+// Each attempt times out after Timeout, e.g. `"500ms"`, which defaults to DefaultHTTPTimeout. Failed
+// attempts, i.e. network errors and 5xx responses, are retried up to Retries times, waiting
+// HTTPRetryBackoff before the first retry and twice as long before each further one. If Deadline is set,
+// e.g. to `"1s"`, it bounds all attempts and waits together; otherwise the condition may take up to
+// Retries+1 times Timeout plus the waits. If the service still fails, the condition is fulfilled only if
+// FailOpen is set. A malformed template, timeout or deadline never fulfills the condition.
+//
+// If CacheTTL is set, e.g. to `"30s"`, answers are cached for that long, keyed by method, URL and the
+// rendered body, so identical requests don't reach the service. Failures are not cached. The cache is
+// shared by all HTTPConditions and holds at most HTTPCacheSize answers.
+type HTTPCondition struct {
+	URL      string `json:"url"`
+	Method   string `json:"method,omitempty"`
+	Body     string `json:"body"`
+	Timeout  string `json:"timeout,omitempty"`
+	Retries  int    `json:"retries,omitempty"`
+	Deadline string `json:"deadline,omitempty"`
+	FailOpen bool   `json:"failOpen"`
+	CacheTTL string `json:"cacheTTL,omitempty"`
+}
+
+type httpCacheEntry struct {
+	key     string
+	allowed bool
+	expires time.Time
+}
+
+// httpCache is a least recently used cache of the answers of authorization services. Its list holds
+// *httpCacheEntry values, the most recently used first.
+var httpCache = struct {
+	sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}{entries: map[string]*list.Element{}, lru: list.New()}
+
+// cachedAnswer returns the cached answer for key, if there is one which hasn't expired.
+func cachedAnswer(key string) (allowed, ok bool) {
+	httpCache.Lock()
+	defer httpCache.Unlock()
+
+	e, ok := httpCache.entries[key]
+	if !ok {
+		return false, false
+	}
+	entry := e.Value.(*httpCacheEntry)
+	if time.Now().After(entry.expires) {
+		httpCache.lru.Remove(e)
+		delete(httpCache.entries, key)
+		return false, false
+	}
+	httpCache.lru.MoveToFront(e)
+	return entry.allowed, true
+}
+
+// cacheAnswer caches allowed for key until ttl has passed, making room if the cache is full.
+func cacheAnswer(key string, allowed bool, ttl time.Duration) {
+	httpCache.Lock()
+	defer httpCache.Unlock()
+
+	now := time.Now()
+	if e, ok := httpCache.entries[key]; ok {
+		entry := e.Value.(*httpCacheEntry)
+		entry.allowed, entry.expires = allowed, now.Add(ttl)
+		httpCache.lru.MoveToFront(e)
+		return
+	}
+
+	if len(httpCache.entries) >= HTTPCacheSize {
+		for e := httpCache.lru.Back(); e != nil; {
+			prev := e.Prev()
+			if entry := e.Value.(*httpCacheEntry); now.After(entry.expires) {
+				httpCache.lru.Remove(e)
+				delete(httpCache.entries, entry.key)
+			}
+			e = prev
+		}
+	}
+	for len(httpCache.entries) >= HTTPCacheSize && httpCache.lru.Len() > 0 {
+		e := httpCache.lru.Back()
+		httpCache.lru.Remove(e)
+		delete(httpCache.entries, e.Value.(*httpCacheEntry).key)
+	}
+	if HTTPCacheSize <= 0 {
+		return
+	}
+	httpCache.entries[key] = httpCache.lru.PushFront(&httpCacheEntry{key: key, allowed: allowed, expires: now.Add(ttl)})
+}
+
+var httpTemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// Fulfills returns true if the authorization service allows the request.
+func (c *HTTPCondition) Fulfills(value interface{}, r *Request) bool {
+	timeout := DefaultHTTPTimeout
+	if c.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(c.Timeout); err != nil || timeout <= 0 {
+			return false
+		}
+	}
+	var deadline time.Duration
+	if c.Deadline != "" {
+		var err error
+		if deadline, err = time.ParseDuration(c.Deadline); err != nil || deadline <= 0 {
+			return false
+		}
+	}
+	var ttl time.Duration
+	if c.CacheTTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(c.CacheTTL); err != nil {
+			return false
+		}
+	}
+
+	body, err := c.render(value, r)
+	if err != nil {
+		return false
+	}
+
+	method := c.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	key := method + " " + c.URL + "\x00" + body
+	if ttl > 0 {
+		if allowed, ok := cachedAnswer(key); ok {
+			return allowed
+		}
+	}
+
+	ctx := context.Background()
+	if deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, deadline)
+		defer cancel()
+	}
+
+	client := &http.Client{Timeout: timeout}
+	var allowed bool
+	for attempt := 0; attempt <= c.Retries; attempt++ {
+		if attempt > 0 {
+			wait := time.NewTimer(HTTPRetryBackoff << uint(attempt-1))
+			select {
+			case <-wait.C:
+			case <-ctx.Done():
+				wait.Stop()
+				return c.FailOpen
+			}
+		}
+
+		var retry bool
+		if allowed, retry, err = c.ask(ctx, client, method, body); !retry {
+			break
+		}
+	}
+	if err != nil {
+		return c.FailOpen
+	}
+
+	if ttl > 0 {
+		cacheAnswer(key, allowed, ttl)
+	}
+	return allowed
+}
+
+// GetName returns the condition's name.
+func (c *HTTPCondition) GetName() string {
+	return "HTTPCondition"
+}
+
+func (c *HTTPCondition) render(value interface{}, r *Request) (string, error) {
+	t, err := template.New("body").Funcs(httpTemplateFuncs).Option("missingkey=zero").Parse(c.Body)
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, map[string]interface{}{
+		"Subject":  r.Subject,
+		"Resource": r.Resource,
+		"Action":   r.Action,
+		"Context":  map[string]interface{}(r.Context),
+		"Value":    value,
+	}); err != nil {
+		return "", errors.WithStack(err)
+	}
+	return b.String(), nil
+}
+
+// ask sends a single request to the service. retry is true if the attempt failed in a way which might
+// succeed when repeated.
+func (c *HTTPCondition) ask(ctx context.Context, client *http.Client, method, body string) (allowed, retry bool, err error) {
+	req, err := http.NewRequest(method, c.URL, bytes.NewBufferString(body))
+	if err != nil {
+		return false, false, errors.WithStack(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := client.Do(req)
+	if err != nil {
+		return false, true, errors.WithStack(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 500 {
+		io.Copy(ioutil.Discard, res.Body)
+		return false, true, errors.Errorf("authorization service responded with %s", res.Status)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return false, false, errors.Errorf("authorization service responded with %s", res.Status)
+	}
+
+	var decision interface{}
+	if err := json.NewDecoder(res.Body).Decode(&decision); err != nil {
+		return false, false, errors.WithStack(err)
+	}
+	switch d := decision.(type) {
+	case bool:
+		return d, false, nil
+	case map[string]interface{}:
+		for _, field := range []string{"allow", "result"} {
+			if b, ok := d[field].(bool); ok {
+				return b, false, nil
+			}
+		}
+	}
+	return false, false, errors.Errorf("unexpected authorization service response %v", decision)
+}
@@ -0,0 +1,152 @@
+package condition
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/ory/ladon"
+)
+
+func TestHTTPCondition(t *testing.T) {
+	defer func(backoff time.Duration) { HTTPRetryBackoff = backoff }(HTTPRetryBackoff)
+	HTTPRetryBackoff = time.Millisecond
+
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+
+		var input struct {
+			User   string   `json:"user"`
+			Action string   `json:"action"`
+			Roles  []string `json:"roles"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		switch input.User {
+		case "alice":
+			fmt.Fprint(w, `{"result": true}`)
+		case "bob":
+			fmt.Fprint(w, `{"allow": false}`)
+		case "carol":
+			fmt.Fprint(w, `true`)
+		case "slow":
+			time.Sleep(200 * time.Millisecond)
+			fmt.Fprint(w, `true`)
+		case "flaky":
+			if atomic.LoadInt32(&hits)%2 == 1 {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, `true`)
+		case "garbage":
+			fmt.Fprint(w, `{"decision": "maybe"}`)
+		default:
+			http.Error(w, "failure", http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	body := `{"user": {{json .Subject}}, "action": {{json .Action}}, "roles": {{json .Context.roles}}}`
+	for k, c := range []struct {
+		condition HTTPCondition
+		subject   string
+		pass      bool
+		hits      int32
+	}{
+		{condition: HTTPCondition{URL: server.URL, Body: body}, subject: "alice", pass: true, hits: 1},
+		{condition: HTTPCondition{URL: server.URL, Body: body}, subject: "bob", pass: false, hits: 1},
+		{condition: HTTPCondition{URL: server.URL, Method: http.MethodPut, Body: body}, subject: "carol", pass: true, hits: 1},
+		{condition: HTTPCondition{URL: server.URL, Body: body}, subject: "garbage", pass: false, hits: 1},
+		{condition: HTTPCondition{URL: server.URL, Body: body, FailOpen: true}, subject: "garbage", pass: true, hits: 1},
+		{condition: HTTPCondition{URL: server.URL, Body: body, Retries: 2}, subject: "error", pass: false, hits: 3},
+		{condition: HTTPCondition{URL: server.URL, Body: body, Retries: 2, FailOpen: true}, subject: "error", pass: true, hits: 3},
+		{condition: HTTPCondition{URL: server.URL, Body: body, Retries: 1}, subject: "flaky", pass: true, hits: 2},
+		{condition: HTTPCondition{URL: server.URL, Body: body, Timeout: "50ms"}, subject: "slow", pass: false, hits: 1},
+		{condition: HTTPCondition{URL: server.URL, Body: body, Timeout: "50ms", FailOpen: true}, subject: "slow", pass: true, hits: 1},
+		{condition: HTTPCondition{URL: server.URL, Body: body, Timeout: "1s", Retries: 2, Deadline: "50ms"}, subject: "slow", pass: false, hits: 1},
+		{condition: HTTPCondition{URL: server.URL, Body: body, Timeout: "1s", Retries: 2, Deadline: "50ms", FailOpen: true}, subject: "slow", pass: true, hits: 1},
+		{condition: HTTPCondition{URL: server.URL, Body: body, Timeout: "soon", FailOpen: true}, subject: "alice", pass: false, hits: 0},
+		{condition: HTTPCondition{URL: server.URL, Body: body, Deadline: "-1s", FailOpen: true}, subject: "alice", pass: false, hits: 0},
+		{condition: HTTPCondition{URL: server.URL, Body: `{{json .Subject`, FailOpen: true}, subject: "alice", pass: false, hits: 0},
+	} {
+		atomic.StoreInt32(&hits, 0)
+		r := &Request{Subject: c.subject, Action: "get", Context: Context{"roles": []string{"editor"}}}
+		if pass := c.condition.Fulfills(nil, r); pass != c.pass {
+			t.Errorf("Case %d: expected %t for %s, got %t", k, c.pass, c.subject, pass)
+		}
+		if n := atomic.LoadInt32(&hits); n != c.hits {
+			t.Errorf("Case %d: expected %d requests, got %d", k, c.hits, n)
+		}
+	}
+
+	t.Run("Cache answers by rendered body", func(t *testing.T) {
+		atomic.StoreInt32(&hits, 0)
+		c := &HTTPCondition{URL: server.URL, Body: body, CacheTTL: "1m"}
+
+		for i := 0; i < 3; i++ {
+			for _, subject := range []string{"alice", "bob"} {
+				r := &Request{Subject: subject, Action: "cache", Context: Context{"roles": []string{"editor"}}}
+				if pass := c.Fulfills(nil, r); pass != (subject == "alice") {
+					t.Fatalf("Unexpected decision %t for %s", pass, subject)
+				}
+			}
+		}
+		if n := atomic.LoadInt32(&hits); n != 2 {
+			t.Fatalf("Expected one request per distinct body, got %d", n)
+		}
+
+		// A different context renders a different body.
+		if !c.Fulfills(nil, &Request{Subject: "alice", Action: "cache", Context: Context{"roles": []string{"admin"}}}) {
+			t.Fatal("Expected alice to be allowed")
+		}
+		if n := atomic.LoadInt32(&hits); n != 3 {
+			t.Fatalf("Expected a cache miss for a different body, got %d requests", n)
+		}
+	})
+
+	t.Run("Don't cache failures", func(t *testing.T) {
+		atomic.StoreInt32(&hits, 0)
+		c := &HTTPCondition{URL: server.URL, Body: body, CacheTTL: "1m", FailOpen: true}
+		r := &Request{Subject: "error", Action: "cache", Context: Context{}}
+		c.Fulfills(nil, r)
+		c.Fulfills(nil, r)
+		if n := atomic.LoadInt32(&hits); n != 2 {
+			t.Fatalf("Expected failures to reach the service every time, got %d requests", n)
+		}
+	})
+	t.Run("Evict the least recently used answers", func(t *testing.T) {
+		defer func(size int) { HTTPCacheSize = size }(HTTPCacheSize)
+		HTTPCacheSize = 2
+
+		atomic.StoreInt32(&hits, 0)
+		c := &HTTPCondition{URL: server.URL, Body: body, CacheTTL: "1m"}
+		ask := func(role string) {
+			if !c.Fulfills(nil, &Request{Subject: "alice", Action: "evict", Context: Context{"roles": []string{role}}}) {
+				t.Fatal("Expected alice to be allowed")
+			}
+		}
+
+		// Asking for a again makes b the least recently used answer, which c evicts.
+		for _, role := range []string{"a", "b", "a", "c", "a", "c"} {
+			ask(role)
+		}
+		if n := atomic.LoadInt32(&hits); n != 3 {
+			t.Fatalf("Expected a, b and c to reach the service once, got %d requests", n)
+		}
+		ask("b")
+		if n := atomic.LoadInt32(&hits); n != 4 {
+			t.Fatalf("Expected b to have been evicted, got %d requests", n)
+		}
+		if n := len(httpCache.entries); n != 2 {
+			t.Fatalf("Expected the cache to hold 2 answers, got %d", n)
+		}
+	})
+}
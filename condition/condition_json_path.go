@@ -0,0 +1,205 @@
+package condition
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/PaesslerAG/gval"
+	"github.com/PaesslerAG/jsonpath"
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// The operators of a JSONPathCondition.
+const (
+	JSONPathEquals      = "equals"
+	JSONPathNotEquals   = "notEquals"
+	JSONPathGreaterThan = "greaterThan"
+	JSONPathLessThan    = "lessThan"
+	JSONPathExists      = "exists"
+)
+
+// jsonPathLanguage is JSONPath with gval's full set of operators, so filters like
+// `$.claims.groups[?(@.role == "admin")]` can compare values.
+var jsonPathLanguage = gval.Full(jsonpath.Language())
+
+// JSONPathCondition evaluates the JSONPath expression Path, like `$.claims.groups[?(@.role == "admin")].name`,
+// against the whole request context and compares the result to Value using Operator, which defaults to
+// equals. Expressions containing wildcards, filters, unions or slices select a list, in which case equals,
+// greaterThan and lessThan are fulfilled if any selected value satisfies them and notEquals if none is
+// equal. exists is fulfilled if the expression selects anything at all and ignores Value.
+//
+// An expression which selects nothing never fulfills the condition, not even notEquals. Numbers are
+// compared by value regardless of their Go type. Invalid expressions and unknown operators are rejected
+// when the policy is loaded, a condition without a path never fulfills. The value of the key the condition is stored under is ignored.
+type JSONPathCondition struct {
+	Path     string      `json:"path"`
+	Operator string      `json:"operator,omitempty"`
+	Value    interface{} `json:"value"`
+
+	eval gval.Evaluable
+}
+
+// UnmarshalJSON decodes the condition and compiles its expression.
+func (c *JSONPathCondition) UnmarshalJSON(data []byte) error {
+	type plain JSONPathCondition
+	var decoded plain
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return errors.WithStack(err)
+	}
+	*c = JSONPathCondition(decoded)
+	if c.Path == "" {
+		return nil
+	}
+
+	eval, err := c.compile()
+	if err != nil {
+		return err
+	}
+	c.eval = eval
+	return nil
+}
+
+func (c *JSONPathCondition) compile() (eval gval.Evaluable, err error) {
+	// gval's parser is fed expressions from policies and isn't meant for untrusted input.
+	defer func() {
+		if r := recover(); r != nil {
+			eval, err = nil, errors.Errorf("invalid JSONPath expression %q: %v", c.Path, r)
+		}
+	}()
+
+	switch c.Operator {
+	case "", JSONPathEquals, JSONPathNotEquals, JSONPathGreaterThan, JSONPathLessThan, JSONPathExists:
+	default:
+		return nil, errors.Errorf("unknown JSONPath operator %q", c.Operator)
+	}
+
+	eval, err = jsonPathLanguage.NewEvaluable(c.Path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid JSONPath expression %q", c.Path)
+	}
+	return eval, nil
+}
+
+// Fulfills returns true if the result of the expression compares to the value as configured.
+func (c *JSONPathCondition) Fulfills(_ interface{}, r *Request) bool {
+	if c.Path == "" {
+		return false
+	}
+
+	eval := c.eval
+	if eval == nil {
+		// The condition was constructed in code rather than unmarshalled.
+		var err error
+		if eval, err = c.compile(); err != nil {
+			return false
+		}
+	}
+
+	result, err := eval(context.Background(), toJSONTree(map[string]interface{}(r.Context)))
+	if err != nil {
+		// Missing keys and type mismatches are no match.
+		return false
+	}
+
+	values := []interface{}{result}
+	if list, ok := result.([]interface{}); ok {
+		values = list
+	}
+	if len(values) == 0 {
+		return false
+	}
+
+	switch c.Operator {
+	case JSONPathExists:
+		return true
+	case JSONPathNotEquals:
+		for _, v := range values {
+			if jsonEqual(v, c.Value) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, v := range values {
+		switch c.Operator {
+		case JSONPathGreaterThan, JSONPathLessThan:
+			a, ok := toFloat(v)
+			if !ok {
+				continue
+			}
+			b, ok := toFloat(c.Value)
+			if !ok {
+				return false
+			}
+			if (c.Operator == JSONPathGreaterThan && a > b) || (c.Operator == JSONPathLessThan && a < b) {
+				return true
+			}
+		default:
+			if jsonEqual(v, c.Value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetName returns the condition's name.
+func (c *JSONPathCondition) GetName() string {
+	return "JSONPathCondition"
+}
+
+// toJSONTree converts nested Contexts to plain maps, which is what the JSONPath library descends into.
+func toJSONTree(v interface{}) interface{} {
+	switch t := v.(type) {
+	case Context:
+		return toJSONTree(map[string]interface{}(t))
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[k] = toJSONTree(v)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, v := range t {
+			l[i] = toJSONTree(v)
+		}
+		return l
+	case []string:
+		l := make([]interface{}, len(t))
+		for i, v := range t {
+			l[i] = v
+		}
+		return l
+	}
+	return v
+}
+
+// jsonEqual compares two values, treating numbers of different types as equal if their values are.
+func jsonEqual(a, b interface{}) bool {
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case float32:
+		return float64(t), true
+	case int:
+		return float64(t), true
+	case int64:
+		return float64(t), true
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,90 @@
+package condition
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/ory/ladon"
+)
+
+func TestJSONPathCondition(t *testing.T) {
+	var claims map[string]interface{}
+	if err := json.Unmarshal([]byte(`{
+		"iss": "https://auth.example.com",
+		"level": 3,
+		"email_verified": true,
+		"groups": [
+			{"name": "engineering", "role": "member"},
+			{"name": "payments", "role": "admin"}
+		],
+		"scopes": ["articles:read", "articles:write"]
+	}`), &claims); err != nil {
+		t.Fatal(err)
+	}
+	ctx := Context{"claims": claims, "tenant": Context{"id": "acme"}}
+
+	for k, c := range []struct {
+		condition JSONPathCondition
+		pass      bool
+	}{
+		{condition: JSONPathCondition{Path: "$.claims.iss", Value: "https://auth.example.com"}, pass: true},
+		{condition: JSONPathCondition{Path: "$.claims.iss", Value: "https://evil.example.com"}, pass: false},
+		{condition: JSONPathCondition{Path: "$.tenant.id", Operator: JSONPathEquals, Value: "acme"}, pass: true},
+		{condition: JSONPathCondition{Path: "$.claims.email_verified", Value: true}, pass: true},
+		{condition: JSONPathCondition{Path: "$.claims.level", Value: 3}, pass: true},
+		{condition: JSONPathCondition{Path: "$.claims.level", Operator: JSONPathGreaterThan, Value: 2}, pass: true},
+		{condition: JSONPathCondition{Path: "$.claims.level", Operator: JSONPathLessThan, Value: 2}, pass: false},
+		{condition: JSONPathCondition{Path: "$.claims.level", Operator: JSONPathGreaterThan, Value: "2"}, pass: false},
+		{condition: JSONPathCondition{Path: `$.claims.groups[?(@.role == "admin")].name`, Value: "payments"}, pass: true},
+		{condition: JSONPathCondition{Path: `$.claims.groups[?(@.role == "admin")].name`, Value: "engineering"}, pass: false},
+		{condition: JSONPathCondition{Path: `$.claims.groups[?(@.role == "owner")].name`, Operator: JSONPathExists}, pass: false},
+		{condition: JSONPathCondition{Path: `$.claims.groups[?(@.role == "owner")].name`, Operator: JSONPathNotEquals, Value: "payments"}, pass: false},
+		{condition: JSONPathCondition{Path: `$.claims.groups[*].name`, Operator: JSONPathNotEquals, Value: "sales"}, pass: true},
+		{condition: JSONPathCondition{Path: `$.claims.groups[*].name`, Operator: JSONPathNotEquals, Value: "payments"}, pass: false},
+		{condition: JSONPathCondition{Path: "$.claims.scopes[*]", Value: "articles:write"}, pass: true},
+		{condition: JSONPathCondition{Path: "$.claims.scopes[0]", Value: "articles:write"}, pass: false},
+		{condition: JSONPathCondition{Path: "$.claims.scopes", Operator: JSONPathExists}, pass: true},
+		{condition: JSONPathCondition{Path: "$.claims.missing", Operator: JSONPathExists}, pass: false},
+		{condition: JSONPathCondition{Path: "$.claims.missing", Operator: JSONPathNotEquals, Value: "x"}, pass: false},
+		{condition: JSONPathCondition{Path: "$.claims[", Operator: JSONPathExists}, pass: false},
+		{condition: JSONPathCondition{Operator: JSONPathExists}, pass: false},
+	} {
+		if pass := c.condition.Fulfills(nil, &Request{Context: ctx}); pass != c.pass {
+			t.Errorf("Case %d: expected %t for %s %s %v, got %t", k, c.pass, c.condition.Path, c.condition.Operator, c.condition.Value, pass)
+		}
+	}
+}
+
+func TestJSONPathConditionUnmarshal(t *testing.T) {
+	t.Run("Compile the expression when loading", func(t *testing.T) {
+		var p DefaultPolicy
+		if err := json.Unmarshal([]byte(`{
+			"id": "admins",
+			"conditions": {
+				"jwt": {
+					"type": "JSONPathCondition",
+					"options": {"path": "$.claims.groups[?(@.role == \"admin\")].name", "value": "payments"}
+				}
+			}
+		}`), &p); err != nil {
+			t.Fatal(err)
+		}
+
+		r := &Request{Context: Context{"claims": map[string]interface{}{
+			"groups": []interface{}{map[string]interface{}{"name": "payments", "role": "admin"}},
+		}}}
+		if !p.Conditions["jwt"].Fulfills(nil, r) {
+			t.Fatal("Expected the loaded condition to be fulfilled")
+		}
+	})
+
+	for k, options := range []string{
+		`{"path": "$.claims["}`,
+		`{"path": "$.claims.level", "operator": "between"}`,
+	} {
+		var p DefaultPolicy
+		if err := json.Unmarshal([]byte(`{"id": "broken", "conditions": {"jwt": {"type": "JSONPathCondition", "options": `+options+`}}}`), &p); err == nil {
+			t.Errorf("Case %d: expected %s to be rejected", k, options)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package condition
+
+import (
+	. "github.com/ory/ladon"
+)
+
+// MatchedSubjectKey is the reserved context key under which warden.ResolvingWarden exposes the effective
+// subject a request is evaluated for, e.g. the group `group:admins` of the requesting user. The warden
+// overwrites any value passed by the caller.
+const MatchedSubjectKey = "ladon:matched_subject"
+
+// SubjectBranch holds the conditions MatchedSubjectCondition evaluates if Subject was matched.
+type SubjectBranch struct {
+	Subject    string           `json:"subject"`
+	Conditions []KeyedCondition `json:"conditions"`
+}
+
+// MatchedSubjectCondition lets a policy listing several subjects require different conditions depending on
+// which subject matched, e.g. "members of group:a from the corporate network, or members of group:b". The
+// first branch whose Subject equals the matched subject is evaluated like an AllOfCondition, so a branch
+// without conditions is always fulfilled. If no branch matches, the condition isn't fulfilled.
+//
+// The matched subject is read from MatchedSubjectKey and defaults to the request's subject, the value
+// passed to the MatchedSubjectCondition itself is ignored. Subjects are compared as is.
+type MatchedSubjectCondition struct {
+	Branches []SubjectBranch `json:"branches"`
+}
+
+// Fulfills returns true if the branch of the matched subject is fulfilled.
+func (c *MatchedSubjectCondition) Fulfills(_ interface{}, r *Request) bool {
+	subject := r.Subject
+	if s, ok := r.Context[MatchedSubjectKey].(string); ok {
+		subject = s
+	}
+
+	for _, b := range c.Branches {
+		if b.Subject == subject {
+			return (&AllOfCondition{Conditions: b.Conditions}).Fulfills(nil, r)
+		}
+	}
+	return false
+}
+
+// GetName returns the condition's name.
+func (c *MatchedSubjectCondition) GetName() string {
+	return "MatchedSubjectCondition"
+}
+
+// ContextKeys returns the keys of the conditions of all branches and the context keys they read
+// themselves. MatchedSubjectKey isn't included as it is set by the warden.
+func (c *MatchedSubjectCondition) ContextKeys() []string {
+	var keys []string
+	for _, b := range c.Branches {
+		keys = append(keys, (&AllOfCondition{Conditions: b.Conditions}).ContextKeys()...)
+	}
+	return keys
+}
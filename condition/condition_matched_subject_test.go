@@ -0,0 +1,49 @@
+package condition
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+)
+
+func TestMatchedSubjectCondition(t *testing.T) {
+	condition := &MatchedSubjectCondition{Branches: []SubjectBranch{
+		{Subject: "group:a", Conditions: []KeyedCondition{{Key: "ip", Condition: &CIDRCondition{CIDR: "10.0.0.0/8"}}}},
+		{Subject: "group:b"},
+	}}
+
+	for k, c := range []struct {
+		request *Request
+		pass    bool
+	}{
+		{request: &Request{Subject: "alice", Context: Context{MatchedSubjectKey: "group:a", "ip": "10.1.2.3"}}, pass: true},
+		{request: &Request{Subject: "alice", Context: Context{MatchedSubjectKey: "group:a", "ip": "192.168.1.1"}}, pass: false},
+		{request: &Request{Subject: "alice", Context: Context{MatchedSubjectKey: "group:b", "ip": "192.168.1.1"}}, pass: true},
+		{request: &Request{Subject: "alice", Context: Context{MatchedSubjectKey: "group:c"}}, pass: false},
+		{request: &Request{Subject: "group:b"}, pass: true},
+		{request: &Request{Subject: "group:a", Context: Context{"ip": "192.168.1.1"}}, pass: false},
+	} {
+		if pass := condition.Fulfills(nil, c.request); pass != c.pass {
+			t.Errorf("Case %d: expected %t, got %t", k, c.pass, pass)
+		}
+	}
+
+	if keys := condition.ContextKeys(); !cmp.Equal([]string{"ip"}, keys) {
+		t.Errorf("Unexpected context keys %v", keys)
+	}
+
+	in := Conditions{"branch": condition}
+	out, err := json.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := Conditions{}
+	if err := json.Unmarshal(out, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(in, got) {
+		t.Fatalf("Unexpected conditions after round trip\n%s", cmp.Diff(in, got))
+	}
+}
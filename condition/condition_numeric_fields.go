@@ -0,0 +1,76 @@
+package condition
+
+import (
+	. "github.com/ory/ladon"
+)
+
+// The operators of a NumericFieldsCondition besides OperatorEq, OperatorNeq, OperatorGt and OperatorLt.
+const (
+	OperatorGte = "gte"
+	OperatorLte = "lte"
+)
+
+// NumericFieldsCondition compares two numbers of the request context, e.g. to allow a payment only if
+//
+//	{"left": "requestedAmount", "operator": "lte", "right": "availableBalance"}
+//
+// Left and Right are dotted paths like `account.balance` resolved from the root of the context. The
+// operators are eq, neq, gt, gte, lt and lte, comparing Left to Right. Numbers are compared by value
+// regardless of their Go type, so JSON numbers decoded as float64 or json.Number compare to ints.
+//
+// A missing field or one which isn't a number never fulfills the condition, regardless of the operator,
+// and neither does an unknown operator.
+type NumericFieldsCondition struct {
+	Left     string `json:"left"`
+	Operator string `json:"operator"`
+	Right    string `json:"right"`
+}
+
+// Fulfills returns true if the fields compare as configured. The value of the key the condition is stored
+// under is ignored.
+func (c *NumericFieldsCondition) Fulfills(_ interface{}, r *Request) bool {
+	left, ok := numericField(r, c.Left)
+	if !ok {
+		return false
+	}
+	right, ok := numericField(r, c.Right)
+	if !ok {
+		return false
+	}
+
+	switch c.Operator {
+	case OperatorEq:
+		return left == right
+	case OperatorNeq:
+		return left != right
+	case OperatorGt:
+		return left > right
+	case OperatorGte:
+		return left >= right
+	case OperatorLt:
+		return left < right
+	case OperatorLte:
+		return left <= right
+	}
+	return false
+}
+
+// numericField returns the number at path in the request context, or false if it is missing or isn't a
+// number.
+func numericField(r *Request, path string) (float64, bool) {
+	v, ok := lookup(r.Context, path)
+	if !ok {
+		return 0, false
+	}
+	return toFloat(v)
+}
+
+// GetName returns the condition's name.
+func (c *NumericFieldsCondition) GetName() string {
+	return "NumericFieldsCondition"
+}
+
+// ContextKeys returns Left and Right.
+func (c *NumericFieldsCondition) ContextKeys() []string {
+	return []string{c.Left, c.Right}
+}
@@ -0,0 +1,50 @@
+package condition
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/ory/ladon"
+)
+
+func TestNumericFieldsCondition(t *testing.T) {
+	for k, c := range []struct {
+		operator string
+		left     interface{}
+		right    interface{}
+		pass     bool
+	}{
+		{operator: OperatorEq, left: 10, right: 10.0, pass: true},
+		{operator: OperatorEq, left: 10, right: 11, pass: false},
+		{operator: OperatorNeq, left: 10, right: 11, pass: true},
+		{operator: OperatorNeq, left: json.Number("10"), right: 10, pass: false},
+		{operator: OperatorGt, left: 11, right: 10, pass: true},
+		{operator: OperatorGt, left: 10, right: 10, pass: false},
+		{operator: OperatorGte, left: 10, right: 10, pass: true},
+		{operator: OperatorGte, left: 9.5, right: 10, pass: false},
+		{operator: OperatorLt, left: int64(9), right: 10, pass: true},
+		{operator: OperatorLt, left: 10, right: 10, pass: false},
+		{operator: OperatorLte, left: json.Number("100.5"), right: 100.5, pass: true},
+		{operator: OperatorLte, left: 101, right: json.Number("100.5"), pass: false},
+
+		// Missing fields, values which aren't numbers and unknown operators
+		{operator: OperatorLte, left: nil, right: 10, pass: false},
+		{operator: OperatorLte, left: 10, right: nil, pass: false},
+		{operator: OperatorNeq, left: nil, right: nil, pass: false},
+		{operator: OperatorEq, left: "10", right: 10, pass: false},
+		{operator: "between", left: 10, right: 10, pass: false},
+	} {
+		ctx := Context{"account": map[string]interface{}{}}
+		if c.left != nil {
+			ctx["requestedAmount"] = c.left
+		}
+		if c.right != nil {
+			ctx["account"] = map[string]interface{}{"balance": c.right}
+		}
+
+		condition := &NumericFieldsCondition{Left: "requestedAmount", Operator: c.operator, Right: "account.balance"}
+		if pass := condition.Fulfills(nil, &Request{Context: ctx}); pass != c.pass {
+			t.Errorf("Case %d: expected %t, got %t", k, c.pass, pass)
+		}
+	}
+}
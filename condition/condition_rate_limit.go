@@ -0,0 +1,78 @@
+package condition
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/ory/ladon"
+)
+
+// CounterStore counts events per key within a sliding time window, e.g. backed by Redis.
+type CounterStore interface {
+	// Incr records an event for key and returns the number of events within the last window, including it.
+	Incr(ctx context.Context, key string, window time.Duration) (int64, error)
+}
+
+var (
+	counterStores   = map[string]CounterStore{}
+	counterStoresMu sync.RWMutex
+)
+
+// RegisterCounterStore makes store available to RateLimitConditions under name. Registering a store
+// under an existing name replaces it.
+func RegisterCounterStore(name string, store CounterStore) {
+	counterStoresMu.Lock()
+	defer counterStoresMu.Unlock()
+	counterStores[name] = store
+}
+
+// RateLimitCondition is fulfilled as long as the request's subject hasn't performed the request's action
+// more than Limit times within Window, e.g. `"1m"`. Events are counted by the registered CounterStore
+// named Store; like AllowlistCondition, only the store's name is stored in the policy.
+//
+// Events are counted per Key, subject and action. Conditions with the same Key share their counters, so
+// give every condition its own Key, e.g. the ID of its policy, unless they are meant to share a budget.
+// All conditions without a Key share the same counters, and each of them counts every evaluation of the
+// others as well.
+//
+// Every evaluation of the condition counts as an event, even if the policy ends up not applying because
+// another condition isn't fulfilled. If the store fails, the condition is fulfilled only if FailOpen is
+// set. A store which isn't registered or a malformed window never fulfills the condition.
+type RateLimitCondition struct {
+	Store    string `json:"store"`
+	Key      string `json:"key,omitempty"`
+	Limit    int64  `json:"limit"`
+	Window   string `json:"window"`
+	FailOpen bool   `json:"failOpen"`
+}
+
+// Fulfills returns true if the subject stays within the limit.
+func (c *RateLimitCondition) Fulfills(_ interface{}, r *Request) bool {
+	window, err := time.ParseDuration(c.Window)
+	if err != nil || window <= 0 {
+		return false
+	}
+
+	counterStoresMu.RLock()
+	store, ok := counterStores[c.Store]
+	counterStoresMu.RUnlock()
+	if !ok {
+		return false
+	}
+
+	key := r.Subject + "\x00" + r.Action
+	if c.Key != "" {
+		key = c.Key + "\x00" + key
+	}
+	n, err := store.Incr(context.Background(), key, window)
+	if err != nil {
+		return c.FailOpen
+	}
+	return n <= c.Limit
+}
+
+// GetName returns the condition's name.
+func (c *RateLimitCondition) GetName() string {
+	return "RateLimitCondition"
+}
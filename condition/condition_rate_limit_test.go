@@ -0,0 +1,105 @@
+package condition
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/ory/ladon"
+)
+
+// memoryCounterStore is a sliding window CounterStore with a controllable clock.
+type memoryCounterStore struct {
+	sync.Mutex
+	now    time.Time
+	events map[string][]time.Time
+}
+
+func (s *memoryCounterStore) Incr(ctx context.Context, key string, window time.Duration) (int64, error) {
+	s.Lock()
+	s.events[key] = append(s.events[key], s.now)
+	s.Unlock()
+	return s.Count(ctx, key, window)
+}
+
+func (s *memoryCounterStore) Count(_ context.Context, key string, window time.Duration) (int64, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	var n int64
+	for _, t := range s.events[key] {
+		if s.now.Sub(t) < window {
+			n++
+		}
+	}
+	return n, nil
+}
+
+type failingCounterStore struct{}
+
+func (failingCounterStore) Incr(context.Context, string, time.Duration) (int64, error) {
+	return 0, errors.New("unavailable")
+}
+
+func TestRateLimitCondition(t *testing.T) {
+	store := &memoryCounterStore{now: time.Now(), events: map[string][]time.Time{}}
+	RegisterCounterStore("memory", store)
+	RegisterCounterStore("failing", failingCounterStore{})
+
+	c := &RateLimitCondition{Store: "memory", Limit: 3, Window: "1m"}
+	alice := &Request{Subject: "alice", Action: "publish"}
+
+	t.Run("Stay under and cross the limit", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			if !c.Fulfills(nil, alice) {
+				t.Fatalf("Expected request %d to be under the limit", i+1)
+			}
+		}
+		if c.Fulfills(nil, alice) {
+			t.Fatal("Expected the fourth request to exceed the limit")
+		}
+
+		if !c.Fulfills(nil, &Request{Subject: "alice", Action: "read"}) {
+			t.Fatal("Expected other actions to be counted separately")
+		}
+		if !c.Fulfills(nil, &Request{Subject: "bob", Action: "publish"}) {
+			t.Fatal("Expected other subjects to be counted separately")
+		}
+
+		store.Lock()
+		store.now = store.now.Add(time.Minute)
+		store.Unlock()
+		if !c.Fulfills(nil, alice) {
+			t.Fatal("Expected the limit to reset after the window")
+		}
+	})
+
+	t.Run("Count conditions with different keys separately", func(t *testing.T) {
+		read := &RateLimitCondition{Store: "memory", Key: "read-policy", Limit: 1, Window: "1m"}
+		write := &RateLimitCondition{Store: "memory", Key: "write-policy", Limit: 1, Window: "1m"}
+		dave := &Request{Subject: "dave", Action: "publish"}
+
+		if !read.Fulfills(nil, dave) || !write.Fulfills(nil, dave) {
+			t.Fatal("Expected each condition to have its own budget")
+		}
+		if read.Fulfills(nil, dave) {
+			t.Fatal("Expected the second request to exceed the limit")
+		}
+	})
+
+	for k, tc := range []struct {
+		condition *RateLimitCondition
+		pass      bool
+	}{
+		{condition: &RateLimitCondition{Store: "unknown", Limit: 3, Window: "1m"}, pass: false},
+		{condition: &RateLimitCondition{Store: "memory", Limit: 3, Window: "a minute"}, pass: false},
+		{condition: &RateLimitCondition{Store: "failing", Limit: 3, Window: "1m"}, pass: false},
+		{condition: &RateLimitCondition{Store: "failing", Limit: 3, Window: "1m", FailOpen: true}, pass: true},
+	} {
+		if pass := tc.condition.Fulfills(nil, &Request{Subject: "carol", Action: "publish"}); pass != tc.pass {
+			t.Errorf("Case %d: expected %t, got %t", k, tc.pass, pass)
+		}
+	}
+}
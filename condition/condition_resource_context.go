@@ -0,0 +1,48 @@
+package condition
+
+import (
+	"strings"
+
+	. "github.com/ory/ladon"
+)
+
+const (
+	// ModeEquals requires the request's resource to equal the context value.
+	ModeEquals = "equals"
+
+	// ModePrefix requires the request's resource to start with the context value.
+	ModePrefix = "prefix"
+
+	// ModeContains requires the request's resource to contain the context value.
+	ModeContains = "contains"
+)
+
+// ResourceContextCondition is fulfilled if the request's resource equals, starts with or contains the
+// context value the condition is keyed by, e.g. an organization segment taken from the user's token.
+// Mode defaults to ModeEquals. A missing or empty context value never fulfills the condition.
+type ResourceContextCondition struct {
+	Mode string `json:"mode"`
+}
+
+// Fulfills returns true if the request's resource relates to the given value string as configured by Mode.
+func (c *ResourceContextCondition) Fulfills(value interface{}, r *Request) bool {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return false
+	}
+
+	switch c.Mode {
+	case "", ModeEquals:
+		return r.Resource == s
+	case ModePrefix:
+		return strings.HasPrefix(r.Resource, s)
+	case ModeContains:
+		return strings.Contains(r.Resource, s)
+	}
+	return false
+}
+
+// GetName returns the condition's name.
+func (c *ResourceContextCondition) GetName() string {
+	return "ResourceContextCondition"
+}
@@ -0,0 +1,59 @@
+package condition
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+)
+
+func TestResourceContextCondition(t *testing.T) {
+	for k, c := range []struct {
+		mode     string
+		value    interface{}
+		resource string
+		pass     bool
+	}{
+		{mode: ModePrefix, value: "orgs:acme:", resource: "orgs:acme:projects:1", pass: true},
+		{mode: ModePrefix, value: "orgs:acme:", resource: "orgs:evil:projects:1", pass: false},
+		{mode: ModePrefix, value: "orgs:acme:", resource: "projects:orgs:acme:", pass: false},
+		{mode: ModeContains, value: ":acme:", resource: "orgs:acme:projects:1", pass: true},
+		{mode: ModeContains, value: ":acme:", resource: "orgs:evil:projects:1", pass: false},
+		{mode: ModeEquals, value: "orgs:acme", resource: "orgs:acme", pass: true},
+		{mode: ModeEquals, value: "orgs:acme", resource: "orgs:acme:projects:1", pass: false},
+		{mode: "", value: "orgs:acme", resource: "orgs:acme", pass: true},
+		{mode: "unknown", value: "orgs:acme", resource: "orgs:acme", pass: false},
+		{mode: ModePrefix, value: nil, resource: "orgs:acme:projects:1", pass: false},
+		{mode: ModePrefix, value: "", resource: "orgs:acme:projects:1", pass: false},
+		{mode: ModePrefix, value: 1234, resource: "1234", pass: false},
+	} {
+		condition := &ResourceContextCondition{Mode: c.mode}
+		if pass := condition.Fulfills(c.value, &Request{Resource: c.resource}); pass != c.pass {
+			t.Errorf("Case %d: expected %s with %v on %s to be %t", k, c.mode, c.value, c.resource, c.pass)
+		}
+	}
+
+	t.Run("Warden denies requests without the context value", func(t *testing.T) {
+		w := &Ladon{Manager: memory.NewMemoryManager()}
+		if err := w.Manager.Create(&DefaultPolicy{
+			ID:         "org-scoped",
+			Subjects:   []string{"alice"},
+			Resources:  []string{"<orgs:.*>"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{"org": &ResourceContextCondition{Mode: ModePrefix}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		r := &Request{Subject: "alice", Resource: "orgs:acme:projects:1", Action: "get", Context: Context{"org": "orgs:acme:"}}
+		if err := w.IsAllowed(r); err != nil {
+			t.Fatalf("Expected request to be allowed: %s", err)
+		}
+
+		r.Context = Context{}
+		if err := w.IsAllowed(r); err == nil {
+			t.Fatal("Expected request without org context value to be denied")
+		}
+	})
+}
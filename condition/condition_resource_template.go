@@ -0,0 +1,59 @@
+package condition
+
+import (
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/matcher"
+)
+
+// ResourceTemplateCondition matches the request's resource against a template like
+// `projects:{projectID}:issues:{issueID}` and compares the captured values to the request context.
+// Captures maps capture names to context paths, e.g. `{"projectID": "claims.project"}`, and the
+// condition is fulfilled if the resource matches the template and every listed capture equals the
+// string at its context path. A capture matches a non-empty run of characters other than `:`, and a
+// template using the same capture name twice never matches.
+//
+// The template is matched by the condition itself, so the policy's resources can stay as broad as
+// `projects:<.*>` and it works with ladon.Ladon. To make the captures of the policy's resources available
+// to any condition instead, use matcher.TemplateMatcher with a warden of the warden package, which passes
+// them under matcher.CapturesKey. The value of the key the condition is stored under is ignored.
+type ResourceTemplateCondition struct {
+	Template string            `json:"template"`
+	Captures map[string]string `json:"captures"`
+}
+
+// Fulfills returns true if the request's resource matches the template and the captures match the context.
+func (c *ResourceTemplateCondition) Fulfills(_ interface{}, r *Request) bool {
+	captures, ok := matcher.MatchTemplate(c.Template, r.Resource)
+	if !ok {
+		return false
+	}
+
+	for name, path := range c.Captures {
+		captured, ok := captures[name]
+		if !ok {
+			return false
+		}
+		v, ok := lookup(r.Context, path)
+		if !ok {
+			return false
+		}
+		if s, ok := v.(string); !ok || s != captured {
+			return false
+		}
+	}
+	return true
+}
+
+// GetName returns the condition's name.
+func (c *ResourceTemplateCondition) GetName() string {
+	return "ResourceTemplateCondition"
+}
+
+// ContextKeys returns the context paths of the captures.
+func (c *ResourceTemplateCondition) ContextKeys() []string {
+	keys := make([]string, 0, len(c.Captures))
+	for _, path := range c.Captures {
+		keys = append(keys, path)
+	}
+	return keys
+}
@@ -0,0 +1,80 @@
+package condition
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+)
+
+func TestResourceTemplateCondition(t *testing.T) {
+	c := &ResourceTemplateCondition{
+		Template: "projects:{projectID}:issues:{issueID}",
+		Captures: map[string]string{"projectID": "claims.project"},
+	}
+	ctx := Context{"claims": map[string]interface{}{"project": "p1"}}
+
+	for k, tc := range []struct {
+		condition *ResourceTemplateCondition
+		resource  string
+		context   Context
+		pass      bool
+	}{
+		{condition: c, resource: "projects:p1:issues:42", context: ctx, pass: true},
+		{condition: c, resource: "projects:p2:issues:42", context: ctx, pass: false},
+		{condition: c, resource: "projects:p1:issues:42:comments:1", context: ctx, pass: false},
+		{condition: c, resource: "projects:p1:issues:", context: ctx, pass: false},
+		{condition: c, resource: "projects:p1:issues:42", context: Context{}, pass: false},
+		{condition: c, resource: "projects:p1:issues:42", context: Context{"claims": map[string]interface{}{"project": 1}}, pass: false},
+		{
+			condition: &ResourceTemplateCondition{Template: "projects:{projectID}", Captures: map[string]string{"issueID": "issue"}},
+			resource:  "projects:p1",
+			context:   Context{"issue": "p1"},
+			pass:      false,
+		},
+		{
+			condition: &ResourceTemplateCondition{Template: "projects:{id}:issues:{id}"},
+			resource:  "projects:p1:issues:p1",
+			pass:      false,
+		},
+		{
+			condition: &ResourceTemplateCondition{Template: "files:{name}.txt"},
+			resource:  "files:notes.txt",
+			pass:      true,
+		},
+		{
+			condition: &ResourceTemplateCondition{Template: "files:{name}.txt"},
+			resource:  "files:notesXtxt",
+			pass:      false,
+		},
+	} {
+		if pass := tc.condition.Fulfills(nil, &Request{Resource: tc.resource, Context: tc.context}); pass != tc.pass {
+			t.Errorf("Case %d: expected %t, got %t", k, tc.pass, pass)
+		}
+	}
+}
+
+func TestResourceTemplateConditionWithWarden(t *testing.T) {
+	m := memory.NewMemoryManager()
+	if err := m.Create(&DefaultPolicy{
+		ID:        "project-members",
+		Subjects:  []string{"<.*>"},
+		Resources: []string{"projects:<.*>"},
+		Actions:   []string{"get"},
+		Effect:    AllowAccess,
+		Conditions: Conditions{"project": &ResourceTemplateCondition{
+			Template: "projects:{projectID}:issues:{issueID}",
+			Captures: map[string]string{"projectID": "project"},
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := &Ladon{Manager: m, Matcher: DefaultMatcher}
+	if err := w.IsAllowed(&Request{Subject: "alice", Resource: "projects:p1:issues:1", Action: "get", Context: Context{"project": "p1"}}); err != nil {
+		t.Fatalf("Expected request to be allowed: %s", err)
+	}
+	if err := w.IsAllowed(&Request{Subject: "alice", Resource: "projects:p2:issues:1", Action: "get", Context: Context{"project": "p1"}}); err == nil {
+		t.Fatal("Expected request to be denied")
+	}
+}
@@ -0,0 +1,70 @@
+package condition
+
+import (
+	. "github.com/ory/ladon"
+)
+
+// EqualsSubjectPathCondition is fulfilled if the request's subject equals the string at Path in the
+// request context, e.g. `resource.owner.id` for owner based access. Unlike ladon's
+// EqualsSubjectCondition, Path may point into nested maps and is resolved from the root of the context,
+// so the key the condition is stored under doesn't matter. If Path is empty, the value of that key is
+// used instead. A missing path never fulfills the condition.
+type EqualsSubjectPathCondition struct {
+	Path string `json:"path"`
+}
+
+// Fulfills returns true if the request's subject equals the value at Path.
+func (c *EqualsSubjectPathCondition) Fulfills(value interface{}, r *Request) bool {
+	s, ok := subjectPathValue(c.Path, value, r)
+	return ok && s == r.Subject
+}
+
+// GetName returns the condition's name.
+func (c *EqualsSubjectPathCondition) GetName() string {
+	return "EqualsSubjectPathCondition"
+}
+
+// ContextKeys returns Path, or nothing if the condition reads the value of its own key.
+func (c *EqualsSubjectPathCondition) ContextKeys() []string {
+	if c.Path == "" {
+		return nil
+	}
+	return []string{c.Path}
+}
+
+// NotEqualsSubjectPathCondition is the inverse of EqualsSubjectPathCondition: it is fulfilled if the
+// request's subject differs from the string at Path. A missing path never fulfills the condition either.
+type NotEqualsSubjectPathCondition struct {
+	Path string `json:"path"`
+}
+
+// Fulfills returns true if the request's subject differs from the value at Path.
+func (c *NotEqualsSubjectPathCondition) Fulfills(value interface{}, r *Request) bool {
+	s, ok := subjectPathValue(c.Path, value, r)
+	return ok && s != r.Subject
+}
+
+// GetName returns the condition's name.
+func (c *NotEqualsSubjectPathCondition) GetName() string {
+	return "NotEqualsSubjectPathCondition"
+}
+
+// ContextKeys returns Path, or nothing if the condition reads the value of its own key.
+func (c *NotEqualsSubjectPathCondition) ContextKeys() []string {
+	if c.Path == "" {
+		return nil
+	}
+	return []string{c.Path}
+}
+
+func subjectPathValue(path string, value interface{}, r *Request) (string, bool) {
+	if path != "" {
+		var ok bool
+		if value, ok = lookup(r.Context, path); !ok {
+			return "", false
+		}
+	}
+
+	s, ok := value.(string)
+	return s, ok
+}
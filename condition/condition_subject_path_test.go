@@ -0,0 +1,44 @@
+package condition
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+)
+
+func TestSubjectPathConditions(t *testing.T) {
+	ctx := Context{
+		"owner": "alice",
+		"resource": map[string]interface{}{
+			"owner": map[string]interface{}{"id": "alice"},
+			"size":  12,
+		},
+	}
+
+	for k, c := range []struct {
+		path      string
+		value     interface{}
+		subject   string
+		equals    bool
+		notEquals bool
+	}{
+		{path: "resource.owner.id", subject: "alice", equals: true, notEquals: false},
+		{path: "resource.owner.id", subject: "bob", equals: false, notEquals: true},
+		{path: "resource.owner.name", subject: "alice", equals: false, notEquals: false},
+		{path: "resource.owner.id.deeper", subject: "alice", equals: false, notEquals: false},
+		{path: "missing.owner.id", subject: "alice", equals: false, notEquals: false},
+		{path: "resource.size", subject: "12", equals: false, notEquals: false},
+		{path: "owner", subject: "alice", equals: true, notEquals: false},
+		{value: "alice", subject: "alice", equals: true, notEquals: false},
+		{value: "alice", subject: "bob", equals: false, notEquals: true},
+		{subject: "alice", equals: false, notEquals: false},
+	} {
+		r := &Request{Subject: c.subject, Context: ctx}
+		if ok := (&EqualsSubjectPathCondition{Path: c.path}).Fulfills(c.value, r); ok != c.equals {
+			t.Errorf("Case %d: expected EqualsSubjectPathCondition to be %t", k, c.equals)
+		}
+		if ok := (&NotEqualsSubjectPathCondition{Path: c.path}).Fulfills(c.value, r); ok != c.notEquals {
+			t.Errorf("Case %d: expected NotEqualsSubjectPathCondition to be %t", k, c.notEquals)
+		}
+	}
+}
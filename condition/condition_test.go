@@ -0,0 +1,27 @@
+package condition
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	. "github.com/ory/ladon"
+)
+
+func TestConditionsAreRegistered(t *testing.T) {
+	for _, f := range factories {
+		c := f()
+		out, err := json.Marshal(Conditions{"key": c})
+		if err != nil {
+			t.Fatalf("Could not marshal %s: %s", c.GetName(), err)
+		}
+
+		in := Conditions{}
+		if err := json.Unmarshal(out, &in); err != nil {
+			t.Fatalf("Could not unmarshal %s: %s", c.GetName(), err)
+		}
+		if reflect.TypeOf(in["key"]) != reflect.TypeOf(c) {
+			t.Fatalf("Expected %T after unmarshalling %s, got %T", c, c.GetName(), in["key"])
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package condition
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ToTime converts a request context value to a time. It accepts a time.Time, an RFC3339 string like
+// `2018-01-01T12:00:00Z`, as unmarshalled from JSON, and epoch seconds as float64, json.Number, int or
+// int64. Fractions of epoch seconds are kept. Any other value or a malformed string returns an error
+// describing it.
+func ToTime(v interface{}) (time.Time, error) {
+	var seconds float64
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case string:
+		parsed, err := time.Parse(time.RFC3339, t)
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "could not convert %q to a time", t)
+		}
+		return parsed, nil
+	case float64:
+		seconds = t
+	case int:
+		seconds = float64(t)
+	case int64:
+		seconds = float64(t)
+	case json.Number:
+		f, err := t.Float64()
+		if err != nil {
+			return time.Time{}, errors.Wrapf(err, "could not convert %q to a time", t.String())
+		}
+		seconds = f
+	default:
+		return time.Time{}, errors.Errorf("could not convert %T to a time, expected a time.Time, an RFC3339 string or epoch seconds", v)
+	}
+
+	if math.IsNaN(seconds) || math.IsInf(seconds, 0) {
+		return time.Time{}, errors.Errorf("could not convert %v to a time", seconds)
+	}
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*1e9)), nil
+}
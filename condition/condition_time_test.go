@@ -0,0 +1,54 @@
+package condition
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToTime(t *testing.T) {
+	expected := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for k, c := range []struct {
+		value    interface{}
+		expected time.Time
+	}{
+		{value: expected, expected: expected},
+		{value: "2018-01-01T12:00:00Z", expected: expected},
+		{value: "2018-01-01T14:00:00+02:00", expected: expected},
+		{value: "2018-01-01T12:00:00.5Z", expected: expected.Add(500 * time.Millisecond)},
+		{value: float64(expected.Unix()), expected: expected},
+		{value: float64(expected.Unix()) + 0.25, expected: expected.Add(250 * time.Millisecond)},
+		{value: int(expected.Unix()), expected: expected},
+		{value: expected.Unix(), expected: expected},
+		{value: json.Number("1514808000"), expected: expected},
+	} {
+		actual, err := ToTime(c.value)
+		if err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+		if !actual.Equal(c.expected) {
+			t.Errorf("Case %d: expected %s, got %s", k, c.expected, actual)
+		}
+	}
+
+	for k, c := range []struct {
+		value   interface{}
+		message string
+	}{
+		{value: "tomorrow", message: `could not convert "tomorrow" to a time`},
+		{value: "2018-01-01", message: `could not convert "2018-01-01" to a time`},
+		{value: json.Number("soon"), message: `could not convert "soon" to a time`},
+		{value: true, message: "could not convert bool to a time"},
+		{value: nil, message: "could not convert <nil> to a time"},
+		{value: math.NaN(), message: "could not convert NaN to a time"},
+	} {
+		if _, err := ToTime(c.value); err == nil {
+			t.Errorf("Case %d: expected an error", k)
+		} else if !strings.Contains(err.Error(), c.message) {
+			t.Errorf("Case %d: expected %q to contain %q", k, err, c.message)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package condition
+
+import (
+	"time"
+
+	. "github.com/ory/ladon"
+)
+
+// TokenValidityCondition is fulfilled if the request time lies within the validity window of a token
+// whose `exp` and `nbf` claims are passed in the request context, usually as epoch seconds but any value
+// accepted by ToTime works. The time of the request is read from the context as well and defaults to now.
+//
+// A missing or malformed expiry never fulfills the condition, a missing not-before claim means that the
+// token is valid from the beginning of time.
+type TokenValidityCondition struct {
+	// ExpiresAtKey is the context key of the expiry, defaults to "exp".
+	ExpiresAtKey string `json:"expiresAtKey"`
+
+	// NotBeforeKey is the context key of the not-before time, defaults to "nbf".
+	NotBeforeKey string `json:"notBeforeKey"`
+
+	// RequestTimeKey is the context key of the request time, defaults to "requestTime".
+	RequestTimeKey string `json:"requestTimeKey"`
+}
+
+// Fulfills returns true if the request time lies within [nbf, exp].
+func (c *TokenValidityCondition) Fulfills(_ interface{}, r *Request) bool {
+	now := time.Now()
+	if v, ok := r.Context[withDefault(c.RequestTimeKey, "requestTime")]; ok {
+		t, err := ToTime(v)
+		if err != nil {
+			return false
+		}
+		now = t
+	}
+
+	exp, err := ToTime(r.Context[withDefault(c.ExpiresAtKey, "exp")])
+	if err != nil || now.After(exp) {
+		return false
+	}
+
+	if v, ok := r.Context[withDefault(c.NotBeforeKey, "nbf")]; ok {
+		nbf, err := ToTime(v)
+		if err != nil || now.Before(nbf) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetName returns the condition's name.
+func (c *TokenValidityCondition) GetName() string {
+	return "TokenValidityCondition"
+}
+
+// ContextKeys returns the context keys of the expiry, not-before time and request time.
+func (c *TokenValidityCondition) ContextKeys() []string {
+	return []string{withDefault(c.ExpiresAtKey, "exp"), withDefault(c.NotBeforeKey, "nbf"), withDefault(c.RequestTimeKey, "requestTime")}
+}
+
+func withDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
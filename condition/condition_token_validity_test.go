@@ -0,0 +1,78 @@
+package condition
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/ory/ladon"
+)
+
+func TestTokenValidityCondition(t *testing.T) {
+	now := float64(time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC).Unix())
+
+	for k, c := range []struct {
+		condition *TokenValidityCondition
+		context   Context
+		pass      bool
+	}{
+		{
+			condition: &TokenValidityCondition{},
+			context:   Context{"requestTime": now, "nbf": now - 60, "exp": now + 60},
+			pass:      true,
+		},
+		{
+			condition: &TokenValidityCondition{},
+			context:   Context{"requestTime": now, "nbf": now - 120, "exp": now - 60},
+			pass:      false,
+		},
+		{
+			condition: &TokenValidityCondition{},
+			context:   Context{"requestTime": now, "nbf": now + 60, "exp": now + 120},
+			pass:      false,
+		},
+		{
+			condition: &TokenValidityCondition{},
+			context:   Context{"requestTime": now, "exp": now + 60},
+			pass:      true,
+		},
+		{
+			condition: &TokenValidityCondition{},
+			context:   Context{"requestTime": now, "nbf": now - 60},
+			pass:      false,
+		},
+		{
+			condition: &TokenValidityCondition{},
+			context:   Context{"requestTime": now, "exp": "tomorrow"},
+			pass:      false,
+		},
+		{
+			condition: &TokenValidityCondition{},
+			context:   Context{"requestTime": "now", "exp": now + 60},
+			pass:      false,
+		},
+		{
+			condition: &TokenValidityCondition{},
+			context:   Context{"nbf": float64(time.Now().Add(-time.Minute).Unix()), "exp": float64(time.Now().Add(time.Minute).Unix())},
+			pass:      true,
+		},
+		{
+			condition: &TokenValidityCondition{},
+			context:   Context{"exp": now},
+			pass:      false,
+		},
+		{
+			condition: &TokenValidityCondition{ExpiresAtKey: "token.exp", NotBeforeKey: "token.nbf", RequestTimeKey: "at"},
+			context:   Context{"at": now, "token.nbf": now - 60, "token.exp": now + 60},
+			pass:      true,
+		},
+		{
+			condition: &TokenValidityCondition{ExpiresAtKey: "token.exp", NotBeforeKey: "token.nbf", RequestTimeKey: "at"},
+			context:   Context{"at": now, "exp": now + 60},
+			pass:      false,
+		},
+	} {
+		if pass := c.condition.Fulfills(nil, &Request{Context: c.context}); pass != c.pass {
+			t.Errorf("Case %d: expected %t, got %t", k, c.pass, pass)
+		}
+	}
+}
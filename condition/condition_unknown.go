@@ -0,0 +1,116 @@
+package condition
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// UnknownCondition stands in for a condition whose type isn't registered in ladon.ConditionFactories,
+// e.g. because the policy was written by a newer release. It fails safe: in an allowing policy it is
+// never fulfilled, so the policy never allows anything, and in a denying policy it is always fulfilled,
+// so the policy keeps denying every request it matches, regardless of the condition. It marshals back to
+// the original type and options, so the policy survives being stored again.
+type UnknownCondition struct {
+	// Type is the unknown condition type.
+	Type string
+
+	// Options are the condition's options as stored.
+	Options json.RawMessage
+
+	// Deny is set if the policy using the condition denies access, which makes the condition always
+	// fulfilled. UnmarshalPolicyLenient sets it from the policy's effect.
+	Deny bool
+}
+
+// Fulfills returns true if the policy using the condition denies access and false otherwise.
+func (c *UnknownCondition) Fulfills(interface{}, *Request) bool {
+	return c.Deny
+}
+
+// GetName returns the unknown condition type.
+func (c *UnknownCondition) GetName() string {
+	return c.Type
+}
+
+// MarshalJSON returns the original options.
+func (c *UnknownCondition) MarshalJSON() ([]byte, error) {
+	if len(c.Options) == 0 {
+		return []byte("{}"), nil
+	}
+	return c.Options, nil
+}
+
+// LogUnknownCondition is called by UnmarshalPolicyLenient for every condition of an unknown type, i.e.
+// whenever a policy with such a condition is decoded, which lenient managers do on every request. It may
+// be replaced, e.g. to count such conditions. By default it logs each combination of policy and condition
+// type only once with the standard logger.
+var LogUnknownCondition = logUnknownConditionOnce
+
+// loggedUnknownConditions holds the policy ID and condition type pairs logUnknownConditionOnce logged.
+var loggedUnknownConditions sync.Map
+
+func logUnknownConditionOnce(policyID, key, conditionType string) {
+	if _, logged := loggedUnknownConditions.LoadOrStore([2]string{policyID, conditionType}, true); logged {
+		return
+	}
+	log.Printf("ladon: condition %q of policy %s has the unknown type %q and fails safe", key, policyID, conditionType)
+}
+
+// UnmarshalPolicyLenient unmarshals a JSON policy like json.Unmarshal, except that conditions of types
+// which aren't registered in ladon.ConditionFactories are decoded as UnknownCondition and reported to
+// LogUnknownCondition instead of failing the whole policy. The conditions fail safe, see UnknownCondition,
+// so a denying policy still denies. UnmarshalPolicy remains the strict mode. Like
+// UnmarshalPolicy, it returns an error wrapping ErrMalformedPolicy rather than panicking.
+func UnmarshalPolicyLenient(data []byte, p *DefaultPolicy) (err error) {
+	defer recoverDecode(&err)
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return errors.WithStack(err)
+	}
+
+	var conditions map[string]struct {
+		Type    string          `json:"type"`
+		Options json.RawMessage `json:"options"`
+	}
+	if c, ok := raw["conditions"]; ok {
+		if err := json.Unmarshal(c, &conditions); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	unknown := Conditions{}
+	for key, c := range conditions {
+		if _, ok := ConditionFactories[c.Type]; ok {
+			continue
+		}
+		unknown[key] = &UnknownCondition{Type: c.Type, Options: c.Options}
+		delete(conditions, key)
+	}
+	if len(unknown) == 0 {
+		return json.Unmarshal(data, p)
+	}
+
+	known, err := json.Marshal(conditions)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	raw["conditions"] = known
+	if data, err = json.Marshal(raw); err != nil {
+		return errors.WithStack(err)
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return err
+	}
+
+	for key, c := range unknown {
+		c.(*UnknownCondition).Deny = !p.AllowAccess()
+		p.Conditions[key] = c
+		LogUnknownCondition(p.ID, key, c.GetName())
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package condition
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+const policyWithUnknownCondition = `{
+	"id": "rolled-back",
+	"subjects": ["alice"],
+	"resources": ["articles:1"],
+	"actions": ["get"],
+	"effect": "allow",
+	"conditions": {
+		"owner": {"type": "EqualsSubjectCondition", "options": {}},
+		"risk": {"type": "RiskScoreCondition", "options": {"max": 0.3}}
+	}
+}`
+
+func TestUnmarshalPolicyLenient(t *testing.T) {
+	t.Run("Fail in strict mode", func(t *testing.T) {
+		var p DefaultPolicy
+		if err := json.Unmarshal([]byte(policyWithUnknownCondition), &p); err == nil {
+			t.Fatal("Expected the unknown condition type to be rejected")
+		}
+	})
+
+	t.Run("Decode unknown conditions in lenient mode", func(t *testing.T) {
+		var logged []string
+		defer func(log func(string, string, string)) { LogUnknownCondition = log }(LogUnknownCondition)
+		LogUnknownCondition = func(policyID, key, conditionType string) {
+			logged = append(logged, policyID+"/"+key+"/"+conditionType)
+		}
+
+		var p DefaultPolicy
+		if err := UnmarshalPolicyLenient([]byte(policyWithUnknownCondition), &p); err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff([]string{"rolled-back/risk/RiskScoreCondition"}, logged); diff != "" {
+			t.Fatalf("Unexpected log (-want +got):\n%s", diff)
+		}
+		if _, ok := p.Conditions["owner"].(*EqualsSubjectCondition); !ok {
+			t.Fatalf("Expected the known condition to be decoded, got %T", p.Conditions["owner"])
+		}
+		unknown, ok := p.Conditions["risk"].(*UnknownCondition)
+		if !ok {
+			t.Fatalf("Expected an UnknownCondition, got %T", p.Conditions["risk"])
+		}
+		if unknown.Fulfills(0.1, &Request{}) {
+			t.Fatal("Expected the unknown condition to never be fulfilled")
+		}
+
+		w := &Ladon{Manager: memory.NewMemoryManager()}
+		if err := w.Manager.Create(&p); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.IsAllowed(&Request{Subject: "alice", Resource: "articles:1", Action: "get", Context: Context{"owner": "alice", "risk": 0.1}}); err == nil {
+			t.Fatal("Expected the policy with an unknown condition to not allow anything")
+		}
+
+		out, err := json.Marshal(&p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var roundTrip struct {
+			Conditions map[string]struct {
+				Type    string          `json:"type"`
+				Options json.RawMessage `json:"options"`
+			} `json:"conditions"`
+		}
+		if err := json.Unmarshal(out, &roundTrip); err != nil {
+			t.Fatal(err)
+		}
+		if c := roundTrip.Conditions["risk"]; c.Type != "RiskScoreCondition" || string(c.Options) != `{"max":0.3}` {
+			t.Fatalf("Expected the unknown condition to be marshalled as stored, got %s %s", c.Type, c.Options)
+		}
+	})
+
+	t.Run("Keep denying with unknown conditions", func(t *testing.T) {
+		defer func(log func(string, string, string)) { LogUnknownCondition = log }(LogUnknownCondition)
+		LogUnknownCondition = func(string, string, string) {}
+
+		var deny DefaultPolicy
+		if err := UnmarshalPolicyLenient([]byte(`{
+			"id": "deny-risky",
+			"subjects": ["<.*>"],
+			"resources": ["<.*>"],
+			"actions": ["<.*>"],
+			"effect": "deny",
+			"conditions": {"risk": {"type": "RiskScoreCondition", "options": {"min": 0.7}}}
+		}`), &deny); err != nil {
+			t.Fatal(err)
+		}
+
+		w := &Ladon{Manager: memory.NewMemoryManager()}
+		for _, p := range []Policy{&deny, &DefaultPolicy{
+			ID:        "allow-all",
+			Subjects:  []string{"<.*>"},
+			Resources: []string{"<.*>"},
+			Actions:   []string{"<.*>"},
+			Effect:    AllowAccess,
+		}} {
+			if err := w.Manager.Create(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.IsAllowed(&Request{Subject: "alice", Resource: "articles:1", Action: "get", Context: Context{"risk": 0.1}}); errors.Cause(err) != ErrRequestForcefullyDenied {
+			t.Fatalf("Expected the denying policy with an unknown condition to deny, got %v", err)
+		}
+	})
+
+	t.Run("Log each unknown condition type of a policy once", func(t *testing.T) {
+		loggedUnknownConditions.Range(func(key, _ interface{}) bool {
+			loggedUnknownConditions.Delete(key)
+			return true
+		})
+
+		var out bytes.Buffer
+		defer func(w io.Writer, flags int) { log.SetOutput(w); log.SetFlags(flags) }(log.Writer(), log.Flags())
+		log.SetOutput(&out)
+		log.SetFlags(0)
+
+		for k, data := range []string{
+			policyWithUnknownCondition,
+			policyWithUnknownCondition,
+			strings.Replace(policyWithUnknownCondition, `"rolled-back"`, `"rolled-back-too"`, 1),
+		} {
+			if err := UnmarshalPolicyLenient([]byte(data), new(DefaultPolicy)); err != nil {
+				t.Fatalf("Case %d: %s", k, err)
+			}
+		}
+
+		expected := "ladon: condition \"risk\" of policy rolled-back has the unknown type \"RiskScoreCondition\" and fails safe\n" +
+			"ladon: condition \"risk\" of policy rolled-back-too has the unknown type \"RiskScoreCondition\" and fails safe\n"
+		if diff := cmp.Diff(expected, out.String()); diff != "" {
+			t.Fatalf("Unexpected log (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("Behave like json.Unmarshal for known conditions", func(t *testing.T) {
+		for k, data := range []string{
+			`{"id": "plain", "conditions": {"owner": {"type": "EqualsSubjectCondition"}}}`,
+			`{"id": "no-conditions"}`,
+		} {
+			var strict, lenient DefaultPolicy
+			if err := json.Unmarshal([]byte(data), &strict); err != nil {
+				t.Fatalf("Case %d: %s", k, err)
+			}
+			if err := UnmarshalPolicyLenient([]byte(data), &lenient); err != nil {
+				t.Fatalf("Case %d: %s", k, err)
+			}
+			if !cmp.Equal(strict, lenient) {
+				t.Errorf("Case %d: unexpected policy\n%s", k, cmp.Diff(strict, lenient))
+			}
+		}
+		if err := UnmarshalPolicyLenient([]byte(`{"id": `), new(DefaultPolicy)); err == nil {
+			t.Fatal("Expected malformed JSON to be rejected")
+		}
+	})
+}
@@ -0,0 +1,172 @@
+// Package ladontest contains helpers for testing code which uses ladon, most notably an in-process mock
+// Manager.
+package ladontest
+
+import (
+	"sync"
+
+	. "github.com/ory/ladon"
+)
+
+// The method names recorded by MockManager.
+const (
+	MethodCreate                  = "Create"
+	MethodUpdate                  = "Update"
+	MethodGet                     = "Get"
+	MethodDelete                  = "Delete"
+	MethodGetAll                  = "GetAll"
+	MethodFindRequestCandidates   = "FindRequestCandidates"
+	MethodFindPoliciesForSubject  = "FindPoliciesForSubject"
+	MethodFindPoliciesForResource = "FindPoliciesForResource"
+)
+
+// Call is a single call to a MockManager.
+type Call struct {
+	// Method is the name of the called method, e.g. MethodGet.
+	Method string
+
+	// Args are the arguments of the call in order, e.g. the ID passed to Get or the limit and offset
+	// passed to GetAll.
+	Args []interface{}
+}
+
+// MockManager is a Manager whose return values are programmed by the test. Each method calls the
+// corresponding func field if it is set and returns zero values otherwise, so a zero MockManager accepts
+// every write and finds no policies. All calls are recorded and can be inspected with Calls.
+//
+// The func fields must be set before the manager is used concurrently. A MockManager must not be copied
+// after first use.
+type MockManager struct {
+	CreateFunc                  func(policy Policy) error
+	UpdateFunc                  func(policy Policy) error
+	GetFunc                     func(id string) (Policy, error)
+	DeleteFunc                  func(id string) error
+	GetAllFunc                  func(limit, offset int64) (Policies, error)
+	FindRequestCandidatesFunc   func(r *Request) (Policies, error)
+	FindPoliciesForSubjectFunc  func(subject string) (Policies, error)
+	FindPoliciesForResourceFunc func(resource string) (Policies, error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// NewMockManager returns a MockManager whose Find* methods and GetAll return policies and err, which is
+// the most common setup when testing code that asks a warden backed by the manager.
+func NewMockManager(policies Policies, err error) *MockManager {
+	find := func(string) (Policies, error) { return policies, err }
+	return &MockManager{
+		GetAllFunc:                  func(int64, int64) (Policies, error) { return policies, err },
+		FindRequestCandidatesFunc:   func(*Request) (Policies, error) { return policies, err },
+		FindPoliciesForSubjectFunc:  find,
+		FindPoliciesForResourceFunc: find,
+	}
+}
+
+func (m *MockManager) record(method string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, Call{Method: method, Args: args})
+}
+
+// Calls returns the recorded calls in the order they were made. If methods are given, only calls to
+// those methods are returned.
+func (m *MockManager) Calls(methods ...string) []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	calls := []Call{}
+	for _, c := range m.calls {
+		if len(methods) == 0 || contains(methods, c.Method) {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset forgets all recorded calls. The programmed return values are kept.
+func (m *MockManager) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = nil
+}
+
+// Create records the call and calls CreateFunc.
+func (m *MockManager) Create(policy Policy) error {
+	m.record(MethodCreate, policy)
+	if m.CreateFunc == nil {
+		return nil
+	}
+	return m.CreateFunc(policy)
+}
+
+// Update records the call and calls UpdateFunc.
+func (m *MockManager) Update(policy Policy) error {
+	m.record(MethodUpdate, policy)
+	if m.UpdateFunc == nil {
+		return nil
+	}
+	return m.UpdateFunc(policy)
+}
+
+// Get records the call and calls GetFunc. Without GetFunc it returns ErrNotFound.
+func (m *MockManager) Get(id string) (Policy, error) {
+	m.record(MethodGet, id)
+	if m.GetFunc == nil {
+		return nil, ErrNotFound
+	}
+	return m.GetFunc(id)
+}
+
+// Delete records the call and calls DeleteFunc.
+func (m *MockManager) Delete(id string) error {
+	m.record(MethodDelete, id)
+	if m.DeleteFunc == nil {
+		return nil
+	}
+	return m.DeleteFunc(id)
+}
+
+// GetAll records the call and calls GetAllFunc.
+func (m *MockManager) GetAll(limit, offset int64) (Policies, error) {
+	m.record(MethodGetAll, limit, offset)
+	if m.GetAllFunc == nil {
+		return Policies{}, nil
+	}
+	return m.GetAllFunc(limit, offset)
+}
+
+// FindRequestCandidates records the call and calls FindRequestCandidatesFunc.
+func (m *MockManager) FindRequestCandidates(r *Request) (Policies, error) {
+	m.record(MethodFindRequestCandidates, r)
+	if m.FindRequestCandidatesFunc == nil {
+		return Policies{}, nil
+	}
+	return m.FindRequestCandidatesFunc(r)
+}
+
+// FindPoliciesForSubject records the call and calls FindPoliciesForSubjectFunc.
+func (m *MockManager) FindPoliciesForSubject(subject string) (Policies, error) {
+	m.record(MethodFindPoliciesForSubject, subject)
+	if m.FindPoliciesForSubjectFunc == nil {
+		return Policies{}, nil
+	}
+	return m.FindPoliciesForSubjectFunc(subject)
+}
+
+// FindPoliciesForResource records the call and calls FindPoliciesForResourceFunc.
+func (m *MockManager) FindPoliciesForResource(resource string) (Policies, error) {
+	m.record(MethodFindPoliciesForResource, resource)
+	if m.FindPoliciesForResourceFunc == nil {
+		return Policies{}, nil
+	}
+	return m.FindPoliciesForResourceFunc(resource)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package ladontest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+)
+
+var _ Manager = new(MockManager)
+
+func TestMockManagerDefaults(t *testing.T) {
+	m := new(MockManager)
+
+	if err := m.Create(&DefaultPolicy{ID: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Get("1"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	policies, err := m.FindRequestCandidates(&Request{Subject: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(policies) != 0 {
+		t.Fatalf("expected no candidates, got %d", len(policies))
+	}
+}
+
+func TestMockManagerProgrammed(t *testing.T) {
+	errBackend := errors.New("backend unavailable")
+	policies := Policies{&DefaultPolicy{ID: "1", Effect: AllowAccess}}
+
+	m := NewMockManager(policies, nil)
+	m.GetFunc = func(id string) (Policy, error) {
+		if id == "1" {
+			return policies[0], nil
+		}
+		return nil, errBackend
+	}
+	m.DeleteFunc = func(string) error { return errBackend }
+
+	for k, c := range []struct {
+		call     func() (interface{}, error)
+		expected interface{}
+		err      error
+	}{
+		{call: func() (interface{}, error) { return m.FindRequestCandidates(&Request{Subject: "alice"}) }, expected: policies},
+		{call: func() (interface{}, error) { return m.FindPoliciesForSubject("alice") }, expected: policies},
+		{call: func() (interface{}, error) { return m.FindPoliciesForResource("articles:1") }, expected: policies},
+		{call: func() (interface{}, error) { return m.GetAll(10, 0) }, expected: policies},
+		{call: func() (interface{}, error) { return m.Get("1") }, expected: policies[0]},
+		{call: func() (interface{}, error) { return m.Get("2") }, expected: Policy(nil), err: errBackend},
+		{call: func() (interface{}, error) { return nil, m.Delete("1") }, err: errBackend},
+	} {
+		actual, err := c.call()
+		if err != c.err {
+			t.Fatalf("Case %d: expected error %v, got %v", k, c.err, err)
+		}
+		if diff := cmp.Diff(c.expected, actual); diff != "" {
+			t.Errorf("Case %d: unexpected result (-want +got):\n%s", k, diff)
+		}
+	}
+
+	failing := NewMockManager(nil, errBackend)
+	if _, err := failing.FindRequestCandidates(&Request{}); err != errBackend {
+		t.Fatalf("expected %v, got %v", errBackend, err)
+	}
+}
+
+func TestMockManagerCalls(t *testing.T) {
+	m := new(MockManager)
+	policy := &DefaultPolicy{ID: "1"}
+	r := &Request{Subject: "alice", Resource: "articles:1", Action: "get"}
+
+	m.Create(policy)
+	m.FindRequestCandidates(r)
+	m.GetAll(10, 20)
+	m.Get("1")
+	m.FindRequestCandidates(r)
+
+	expected := []Call{
+		{Method: MethodCreate, Args: []interface{}{policy}},
+		{Method: MethodFindRequestCandidates, Args: []interface{}{r}},
+		{Method: MethodGetAll, Args: []interface{}{int64(10), int64(20)}},
+		{Method: MethodGet, Args: []interface{}{"1"}},
+		{Method: MethodFindRequestCandidates, Args: []interface{}{r}},
+	}
+	if diff := cmp.Diff(expected, m.Calls()); diff != "" {
+		t.Fatalf("unexpected calls (-want +got):\n%s", diff)
+	}
+
+	if diff := cmp.Diff([]Call{expected[0], expected[3]}, m.Calls(MethodCreate, MethodGet)); diff != "" {
+		t.Fatalf("unexpected filtered calls (-want +got):\n%s", diff)
+	}
+
+	m.Reset()
+	if calls := m.Calls(); len(calls) != 0 {
+		t.Fatalf("expected no calls after Reset, got %d", len(calls))
+	}
+}
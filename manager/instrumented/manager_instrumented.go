@@ -0,0 +1,116 @@
+// Package instrumented contains a manager decorator that reports latency and errors of another manager.
+package instrumented
+
+import (
+	"time"
+
+	. "github.com/ory/ladon"
+)
+
+// The method names reported to the Collector.
+const (
+	MethodCreate                  = "Create"
+	MethodUpdate                  = "Update"
+	MethodGet                     = "Get"
+	MethodDelete                  = "Delete"
+	MethodGetAll                  = "GetAll"
+	MethodFindRequestCandidates   = "FindRequestCandidates"
+	MethodFindPoliciesForSubject  = "FindPoliciesForSubject"
+	MethodFindPoliciesForResource = "FindPoliciesForResource"
+)
+
+// Collector receives the measurements of an InstrumentedManager, e.g. to feed them into Prometheus
+// histograms and counters. Implementations must be safe for concurrent use.
+type Collector interface {
+	// ObserveLatency records how long a call to method took.
+	ObserveLatency(method string, duration time.Duration)
+
+	// IncError records that a call to method returned an error.
+	IncError(method string)
+}
+
+// InstrumentedManager wraps a Manager and reports the latency and errors of every call to a Collector.
+// Return values and errors of the wrapped manager are passed through unchanged.
+type InstrumentedManager struct {
+	manager   Manager
+	collector Collector
+}
+
+// NewInstrumentedManager initializes a new InstrumentedManager reporting calls to manager to collector.
+func NewInstrumentedManager(manager Manager, collector Collector) *InstrumentedManager {
+	return &InstrumentedManager{
+		manager:   manager,
+		collector: collector,
+	}
+}
+
+func (m *InstrumentedManager) observe(method string, start time.Time, err error) {
+	m.collector.ObserveLatency(method, time.Since(start))
+	if err != nil {
+		m.collector.IncError(method)
+	}
+}
+
+// Create persists the policy.
+func (m *InstrumentedManager) Create(policy Policy) error {
+	start := time.Now()
+	err := m.manager.Create(policy)
+	m.observe(MethodCreate, start, err)
+	return err
+}
+
+// Update updates an existing policy.
+func (m *InstrumentedManager) Update(policy Policy) error {
+	start := time.Now()
+	err := m.manager.Update(policy)
+	m.observe(MethodUpdate, start, err)
+	return err
+}
+
+// Get retrieves a policy.
+func (m *InstrumentedManager) Get(id string) (Policy, error) {
+	start := time.Now()
+	policy, err := m.manager.Get(id)
+	m.observe(MethodGet, start, err)
+	return policy, err
+}
+
+// Delete removes a policy.
+func (m *InstrumentedManager) Delete(id string) error {
+	start := time.Now()
+	err := m.manager.Delete(id)
+	m.observe(MethodDelete, start, err)
+	return err
+}
+
+// GetAll retrieves all policies.
+func (m *InstrumentedManager) GetAll(limit, offset int64) (Policies, error) {
+	start := time.Now()
+	policies, err := m.manager.GetAll(limit, offset)
+	m.observe(MethodGetAll, start, err)
+	return policies, err
+}
+
+// FindRequestCandidates returns candidates that could match the request object.
+func (m *InstrumentedManager) FindRequestCandidates(r *Request) (Policies, error) {
+	start := time.Now()
+	policies, err := m.manager.FindRequestCandidates(r)
+	m.observe(MethodFindRequestCandidates, start, err)
+	return policies, err
+}
+
+// FindPoliciesForSubject returns policies that could match the subject.
+func (m *InstrumentedManager) FindPoliciesForSubject(subject string) (Policies, error) {
+	start := time.Now()
+	policies, err := m.manager.FindPoliciesForSubject(subject)
+	m.observe(MethodFindPoliciesForSubject, start, err)
+	return policies, err
+}
+
+// FindPoliciesForResource returns policies that could match the resource.
+func (m *InstrumentedManager) FindPoliciesForResource(resource string) (Policies, error) {
+	start := time.Now()
+	policies, err := m.manager.FindPoliciesForResource(resource)
+	m.observe(MethodFindPoliciesForResource, start, err)
+	return policies, err
+}
@@ -0,0 +1,110 @@
+package instrumented
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+)
+
+type fakeCollector struct {
+	sync.Mutex
+	latencies map[string][]time.Duration
+	errors    map[string]int
+}
+
+func newFakeCollector() *fakeCollector {
+	return &fakeCollector{
+		latencies: map[string][]time.Duration{},
+		errors:    map[string]int{},
+	}
+}
+
+func (c *fakeCollector) ObserveLatency(method string, duration time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	c.latencies[method] = append(c.latencies[method], duration)
+}
+
+func (c *fakeCollector) IncError(method string) {
+	c.Lock()
+	defer c.Unlock()
+	c.errors[method]++
+}
+
+var errBackend = errors.New("backend unavailable")
+
+// slowManager delays every lookup and fails FindRequestCandidates.
+type slowManager struct {
+	*memory.MemoryManager
+	delay time.Duration
+}
+
+func (m *slowManager) Get(id string) (Policy, error) {
+	time.Sleep(m.delay)
+	return m.MemoryManager.Get(id)
+}
+
+func (m *slowManager) FindRequestCandidates(r *Request) (Policies, error) {
+	time.Sleep(m.delay)
+	return nil, errBackend
+}
+
+func TestInstrumentedManager(t *testing.T) {
+	c := newFakeCollector()
+	m := NewInstrumentedManager(&slowManager{MemoryManager: memory.NewMemoryManager(), delay: 20 * time.Millisecond}, c)
+
+	policy := &DefaultPolicy{ID: "test-policy-1"}
+	if err := m.Create(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Slow calls are measured", func(t *testing.T) {
+		p, err := m.Get(policy.GetID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p != policy {
+			t.Fatalf("Expected the inner manager's policy to be returned, got %+v", p)
+		}
+
+		if l := c.latencies[MethodGet]; len(l) != 1 || l[0] < 20*time.Millisecond {
+			t.Fatalf("Expected one latency of at least 20ms for Get, got %v", l)
+		}
+		if c.errors[MethodGet] != 0 {
+			t.Fatalf("Expected no errors for Get, got %d", c.errors[MethodGet])
+		}
+	})
+
+	t.Run("Errors are counted and passed through", func(t *testing.T) {
+		for i := 0; i < 2; i++ {
+			if _, err := m.FindRequestCandidates(&Request{}); err != errBackend {
+				t.Fatalf("Expected the inner manager's error, got %v", err)
+			}
+		}
+
+		if c.errors[MethodFindRequestCandidates] != 2 {
+			t.Fatalf("Expected 2 errors for FindRequestCandidates, got %d", c.errors[MethodFindRequestCandidates])
+		}
+		if l := c.latencies[MethodFindRequestCandidates]; len(l) != 2 {
+			t.Fatalf("Expected 2 latencies for FindRequestCandidates, got %v", l)
+		}
+	})
+
+	t.Run("Every method reports to the collector", func(t *testing.T) {
+		m.Update(policy)
+		m.GetAll(10, 0)
+		m.FindPoliciesForSubject("")
+		m.FindPoliciesForResource("")
+		m.Delete(policy.GetID())
+
+		for _, method := range []string{MethodCreate, MethodUpdate, MethodGetAll, MethodFindPoliciesForSubject, MethodFindPoliciesForResource, MethodDelete} {
+			if len(c.latencies[method]) != 1 {
+				t.Errorf("Expected one latency for %s, got %v", method, c.latencies[method])
+			}
+		}
+	})
+}
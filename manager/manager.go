@@ -0,0 +1,121 @@
+// Package manager contains helpers operating on any ladon.Manager.
+package manager
+
+import (
+	"context"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+// Iterator is implemented by managers which can stream their policies, e.g. redis.RedisManager.
+type Iterator interface {
+	// Iterate calls fn for every policy and stops early if fn returns an error or ctx is done.
+	Iterate(ctx context.Context, fn func(Policy) error) error
+}
+
+// Iterate calls fn for every policy of m. It uses m's Iterate method if m is an Iterator and pages
+// through GetAll in batches of batchSize otherwise.
+func Iterate(ctx context.Context, m Manager, batchSize int, fn func(Policy) error) error {
+	if it, ok := m.(Iterator); ok {
+		return it.Iterate(ctx, fn)
+	}
+
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	for offset := int64(0); ; offset += int64(batchSize) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		policies, err := m.GetAll(int64(batchSize), offset)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range policies {
+			if err := fn(p); err != nil {
+				return err
+			}
+		}
+
+		if len(policies) < batchSize {
+			return nil
+		}
+	}
+}
+
+// Replacer is implemented by managers which can swap their entire policy set at once, e.g.
+// redis.RedisManager.
+type Replacer interface {
+	// ReplaceAll replaces all policies with the given ones, so readers see either the old or the new
+	// policy set.
+	ReplaceAll(policies Policies) error
+}
+
+// replaceAll replaces all policies of m with policies. It uses m's ReplaceAll method if m is a Replacer
+// and swaps the policies of a memory.MemoryManager under its lock. Other managers have all their policies
+// deleted before the new ones are created, which isn't atomic: readers may observe a partial policy set,
+// and an error leaves m with one.
+func replaceAll(m Manager, policies []*DefaultPolicy) error {
+	switch r := m.(type) {
+	case Replacer:
+		ps := make(Policies, len(policies))
+		for i, p := range policies {
+			ps[i] = p
+		}
+		return r.ReplaceAll(ps)
+	case *memory.MemoryManager:
+		ps := make(map[string]Policy, len(policies))
+		for _, p := range policies {
+			ps[p.ID] = p
+		}
+		r.Lock()
+		r.Policies = ps
+		r.Unlock()
+		return nil
+	}
+
+	if err := deleteAll(m); err != nil {
+		return err
+	}
+	for _, p := range policies {
+		if err := m.Create(p); err != nil {
+			return errors.Wrapf(err, "could not create policy %s", p.ID)
+		}
+	}
+	return nil
+}
+
+// ExistenceChecker is implemented by managers which can tell whether a policy exists without loading
+// it, e.g. redis.RedisManager and postgres.PostgresManager.
+type ExistenceChecker interface {
+	// Exists returns true if a policy with the given ID exists. Errors of the backend are returned as
+	// such and never mean that the policy doesn't exist.
+	Exists(id string) (bool, error)
+}
+
+// Exists returns true if m stores a policy with the given ID. It uses m's Exists method if m is an
+// ExistenceChecker, looks the ID up directly for a memory.MemoryManager, whose Get doesn't return
+// ladon.ErrNotFound, and calls Get otherwise, treating ladon.ErrNotFound as a missing policy.
+func Exists(m Manager, id string) (bool, error) {
+	switch c := m.(type) {
+	case ExistenceChecker:
+		return c.Exists(id)
+	case *memory.MemoryManager:
+		c.RLock()
+		defer c.RUnlock()
+		_, ok := c.Policies[id]
+		return ok, nil
+	}
+
+	if _, err := m.Get(id); errors.Cause(err) == ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,83 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// CanonicalJSON returns a stable representation of p, so two policies which only differ in the order of
+// their subjects, resources, actions or conditions, or in the formatting of their meta data, serialize to
+// the same bytes. Subjects, resources and actions are sorted and duplicates are removed like Normalize
+// does, object keys are sorted, including those of condition options and of meta data which is valid
+// JSON, and the output is indented by two spaces and terminated by a newline to keep line based diffs
+// readable. The result unmarshals into a ladon.DefaultPolicy.
+func CanonicalJSON(p Policy) ([]byte, error) {
+	p = Normalize(p)
+	meta, err := canonicalMeta(p.GetMeta())
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(&DefaultPolicy{
+		ID:          p.GetID(),
+		Description: p.GetDescription(),
+		Subjects:    sorted(p.GetSubjects()),
+		Effect:      p.GetEffect(),
+		Resources:   sorted(p.GetResources()),
+		Actions:     sorted(p.GetActions()),
+		Conditions:  p.GetConditions(),
+		Meta:        meta,
+	})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	// Decoding into generic values and encoding them again sorts the keys of all objects, including
+	// those written by the MarshalJSON methods of conditions.
+	return canonicalize(raw, true)
+}
+
+// canonicalMeta sorts the keys of meta if it is valid JSON and returns it unchanged otherwise.
+func canonicalMeta(meta []byte) ([]byte, error) {
+	if len(meta) == 0 || !json.Valid(meta) {
+		return meta, nil
+	}
+	c, err := canonicalize(meta, false)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(c, []byte("\n")), nil
+}
+
+func canonicalize(raw []byte, indent bool) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(raw))
+	// Keep numbers as written rather than converting them to float64.
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var b bytes.Buffer
+	e := json.NewEncoder(&b)
+	// Patterns like `<.*>` are common in policies and would be unreadable if escaped.
+	e.SetEscapeHTML(false)
+	if indent {
+		e.SetIndent("", "  ")
+	}
+	if err := e.Encode(v); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return b.Bytes(), nil
+}
+
+// sorted returns a sorted copy of values.
+func sorted(values []string) []string {
+	s := append([]string{}, values...)
+	sort.Strings(s)
+	return s
+}
@@ -0,0 +1,75 @@
+package manager
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+)
+
+func TestCanonicalJSON(t *testing.T) {
+	a := &DefaultPolicy{
+		ID:        "articles",
+		Subjects:  []string{"user:bob", "user:alice"},
+		Resources: []string{"articles:<.*>", "comments:<.*>"},
+		Actions:   []string{"update", "get"},
+		Effect:    AllowAccess,
+		Conditions: Conditions{
+			"ip":    &CIDRCondition{CIDR: "10.0.0.0/8"},
+			"owner": &EqualsSubjectCondition{},
+		},
+		Meta: []byte(`{"team": "ops", "labels": {"tier": 1, "env": "prod"}}`),
+	}
+	b := &DefaultPolicy{
+		ID:        "articles",
+		Subjects:  []string{"user:alice", "user:bob", "user:alice"},
+		Resources: []string{"comments:<.*>", "articles:<.*>"},
+		Actions:   []string{"get", "update"},
+		Effect:    AllowAccess,
+		Conditions: Conditions{
+			"owner": &EqualsSubjectCondition{},
+			"ip":    &CIDRCondition{CIDR: "10.0.0.0/8"},
+		},
+		Meta: []byte(`{"labels":{"env":"prod","tier":1},"team":"ops"}`),
+	}
+
+	ca, err := CanonicalJSON(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cb, err := CanonicalJSON(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(ca) != string(cb) {
+		t.Fatalf("Expected identical canonical JSON\n%s", cmp.Diff(string(ca), string(cb)))
+	}
+
+	var got DefaultPolicy
+	if err := json.Unmarshal(ca, &got); err != nil {
+		t.Fatal(err)
+	}
+	expected := &DefaultPolicy{
+		ID:         "articles",
+		Subjects:   []string{"user:alice", "user:bob"},
+		Resources:  []string{"articles:<.*>", "comments:<.*>"},
+		Actions:    []string{"get", "update"},
+		Effect:     AllowAccess,
+		Conditions: a.Conditions,
+		Meta:       []byte(`{"labels":{"env":"prod","tier":1},"team":"ops"}`),
+	}
+	if !cmp.Equal(expected, &got) {
+		t.Errorf("Unexpected policy after round trip\n%s", cmp.Diff(expected, &got))
+	}
+
+	c := *b
+	c.Effect = DenyAccess
+	cc, err := CanonicalJSON(&c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(ca) == string(cc) {
+		t.Errorf("Expected policies with different effects to differ")
+	}
+}
@@ -0,0 +1,134 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// ChangeType is the kind of a Change.
+type ChangeType string
+
+const (
+	// ChangeCreate means that the policy only exists in the desired set.
+	ChangeCreate ChangeType = "create"
+
+	// ChangeUpdate means that the policy exists in both sets but differs.
+	ChangeUpdate ChangeType = "update"
+
+	// ChangeDelete means that the policy only exists in the current set.
+	ChangeDelete ChangeType = "delete"
+)
+
+// Change describes how a single policy differs between two policy sets.
+type Change struct {
+	Type ChangeType `json:"type"`
+	ID   string     `json:"id"`
+
+	// Fields lists the changed fields of updated policies.
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// FieldChange is a changed field of an updated policy. Values are given as JSON.
+type FieldChange struct {
+	Field   string          `json:"field"`
+	Current json.RawMessage `json:"current"`
+	Desired json.RawMessage `json:"desired"`
+}
+
+// Diff returns the changes turning current into desired: policies which only exist in desired are
+// created, policies which only exist in current are deleted, and policies which differ are updated.
+// Changes are sorted by ID, and the fields of an update are listed in a fixed order. Diff returns an
+// error if a policy set contains the same ID more than once.
+func Diff(current, desired Policies) ([]Change, error) {
+	c, err := byID(current)
+	if err != nil {
+		return nil, err
+	}
+	d, err := byID(desired)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []Change{}
+	for id, dp := range d {
+		cp, ok := c[id]
+		if !ok {
+			changes = append(changes, Change{Type: ChangeCreate, ID: id})
+			continue
+		}
+
+		fields, err := diffFields(cp, dp)
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) > 0 {
+			changes = append(changes, Change{Type: ChangeUpdate, ID: id, Fields: fields})
+		}
+	}
+	for id := range c {
+		if _, ok := d[id]; !ok {
+			changes = append(changes, Change{Type: ChangeDelete, ID: id})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].ID < changes[j].ID
+	})
+	return changes, nil
+}
+
+func byID(policies Policies) (map[string]Policy, error) {
+	m := make(map[string]Policy, len(policies))
+	for _, p := range policies {
+		if _, ok := m[p.GetID()]; ok {
+			return nil, errors.Errorf("policy %s is contained more than once", p.GetID())
+		}
+		m[p.GetID()] = p
+	}
+	return m, nil
+}
+
+func diffFields(current, desired Policy) ([]FieldChange, error) {
+	var fields []FieldChange
+	for _, f := range []struct {
+		name             string
+		current, desired interface{}
+	}{
+		{name: "description", current: current.GetDescription(), desired: desired.GetDescription()},
+		{name: "subjects", current: current.GetSubjects(), desired: desired.GetSubjects()},
+		{name: "resources", current: current.GetResources(), desired: desired.GetResources()},
+		{name: "actions", current: current.GetActions(), desired: desired.GetActions()},
+		{name: "effect", current: current.GetEffect(), desired: desired.GetEffect()},
+		{name: "conditions", current: current.GetConditions(), desired: desired.GetConditions()},
+		{name: "meta", current: rawMeta(current.GetMeta()), desired: rawMeta(desired.GetMeta())},
+	} {
+		c, err := json.Marshal(f.current)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		d, err := json.Marshal(f.desired)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if !bytes.Equal(c, d) {
+			fields = append(fields, FieldChange{Field: f.name, Current: c, Desired: d})
+		}
+	}
+	return fields, nil
+}
+
+// rawMeta returns meta as raw JSON if it is valid JSON, so it shows up readably in a FieldChange.
+func rawMeta(meta []byte) interface{} {
+	if len(meta) > 0 && json.Valid(meta) {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, meta); err == nil {
+			return json.RawMessage(compact.Bytes())
+		}
+	}
+	return meta
+}
@@ -0,0 +1,79 @@
+package manager
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+)
+
+func TestDiff(t *testing.T) {
+	base := func(id string) *DefaultPolicy {
+		return &DefaultPolicy{
+			ID:         id,
+			Subjects:   []string{"user:alice"},
+			Resources:  []string{"articles:1"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{"ip": &CIDRCondition{CIDR: "10.0.0.0/8"}},
+			Meta:       []byte(`{"team": "ops"}`),
+		}
+	}
+
+	changed := base("changed")
+	changed.Subjects = []string{"user:bob"}
+	changed.Effect = DenyAccess
+	changed.Conditions = Conditions{"ip": &CIDRCondition{CIDR: "192.168.0.0/16"}}
+
+	metaOnly := base("meta-only")
+	metaOnly.Meta = []byte(`{"team": "dev"}`)
+
+	current := Policies{base("unchanged"), base("deleted"), base("changed"), base("meta-only")}
+	desired := Policies{base("unchanged"), base("created"), changed, metaOnly}
+
+	changes, err := Diff(current, desired)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Change{
+		{Type: ChangeUpdate, ID: "changed", Fields: []FieldChange{
+			{Field: "subjects", Current: json.RawMessage(`["user:alice"]`), Desired: json.RawMessage(`["user:bob"]`)},
+			{Field: "effect", Current: json.RawMessage(`"allow"`), Desired: json.RawMessage(`"deny"`)},
+			{
+				Field:   "conditions",
+				Current: json.RawMessage(`{"ip":{"type":"CIDRCondition","options":{"cidr":"10.0.0.0/8"}}}`),
+				Desired: json.RawMessage(`{"ip":{"type":"CIDRCondition","options":{"cidr":"192.168.0.0/16"}}}`),
+			},
+		}},
+		{Type: ChangeCreate, ID: "created"},
+		{Type: ChangeDelete, ID: "deleted"},
+		{Type: ChangeUpdate, ID: "meta-only", Fields: []FieldChange{
+			{Field: "meta", Current: json.RawMessage(`{"team":"ops"}`), Desired: json.RawMessage(`{"team":"dev"}`)},
+		}},
+	}
+
+	// Compare the JSON representation, as raw messages are byte slices.
+	got, _ := json.Marshal(changes)
+	want, _ := json.Marshal(expected)
+	if string(got) != string(want) {
+		t.Fatalf("Unexpected changes\n%s", cmp.Diff(string(want), string(got)))
+	}
+
+	t.Run("Diff identical sets", func(t *testing.T) {
+		changes, err := Diff(current, Policies{base("meta-only"), base("changed"), base("deleted"), base("unchanged")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(changes) != 0 {
+			t.Fatalf("Expected no changes, got %+v", changes)
+		}
+	})
+
+	t.Run("Reject duplicate IDs", func(t *testing.T) {
+		if _, err := Diff(Policies{base("a"), base("a")}, nil); err == nil {
+			t.Fatal("Expected an error")
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// LoadDir creates the policies stored in the `*.json` files of dir in m and returns how many were
+// created. Each file contains a single policy or a list of policies. If replace is true, the loaded
+// policies replace all policies of m; this is atomic only if m is a Replacer or a memory.MemoryManager.
+//
+// All files are read and validated before m is modified, so a malformed file leaves m untouched. Errors
+// name the offending file. Policies need an ID unique across all files and an effect of either allow or
+// deny.
+func LoadDir(m Manager, dir string, replace bool) (loaded int, err error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	sort.Strings(files)
+
+	var (
+		policies []*DefaultPolicy
+		seen     = map[string]string{}
+	)
+	for _, file := range files {
+		ps, err := readPolicyFile(file)
+		if err != nil {
+			return 0, errors.Wrapf(err, "could not load %s", file)
+		}
+
+		for _, p := range ps {
+			if err := validatePolicy(p); err != nil {
+				return 0, errors.Wrapf(err, "could not load %s", file)
+			}
+			if other, ok := seen[p.ID]; ok {
+				return 0, errors.Errorf("could not load %s: policy %s is already defined in %s", file, p.ID, other)
+			}
+			seen[p.ID] = file
+		}
+		policies = append(policies, ps...)
+	}
+
+	if replace {
+		if err := replaceAll(m, policies); err != nil {
+			return 0, err
+		}
+		return len(policies), nil
+	}
+
+	for _, p := range policies {
+		if err := m.Create(p); err != nil {
+			return loaded, errors.Wrapf(err, "could not create policy %s from %s", p.ID, seen[p.ID])
+		}
+		loaded++
+	}
+	return loaded, nil
+}
+
+// readPolicyFile reads a single policy or a list of policies from the JSON file at path.
+func readPolicyFile(path string) ([]*DefaultPolicy, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '[' {
+		var policies []*DefaultPolicy
+		if err := json.Unmarshal(data, &policies); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return policies, nil
+	}
+
+	var p DefaultPolicy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return []*DefaultPolicy{&p}, nil
+}
+
+// validatePolicy checks that p has an ID and a valid effect.
+func validatePolicy(p *DefaultPolicy) error {
+	if p.ID == "" {
+		return errors.New("policy has no id")
+	}
+	if p.Effect != AllowAccess && p.Effect != DenyAccess {
+		return errors.Errorf("policy %s has invalid effect %q, expected %q or %q", p.ID, p.Effect, AllowAccess, DenyAccess)
+	}
+	return nil
+}
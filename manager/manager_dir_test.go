@@ -0,0 +1,112 @@
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ladon-policies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeFiles(t, dir, map[string]string{
+		"articles.json": `[
+			{"id": "articles-read", "subjects": ["<.*>"], "resources": ["articles:<.*>"], "actions": ["get"], "effect": "allow",
+			 "conditions": {"ip": {"type": "CIDRCondition", "options": {"cidr": "10.0.0.0/8"}}}},
+			{"id": "articles-deny-bob", "subjects": ["bob"], "resources": ["articles:<.*>"], "actions": ["<.*>"], "effect": "deny"}
+		]`,
+		"users.json": `{"id": "users-read", "subjects": ["admin"], "resources": ["users:<.*>"], "actions": ["get"], "effect": "allow"}`,
+		"README.md":  `Not a policy`,
+	})
+
+	m := memory.NewMemoryManager()
+	if err := m.Create(&DefaultPolicy{ID: "existing", Effect: AllowAccess}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Load all files", func(t *testing.T) {
+		n, err := LoadDir(m, dir, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 3 {
+			t.Fatalf("Expected 3 policies to be loaded, got %d", n)
+		}
+
+		p, err := m.Get("articles-read")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := &DefaultPolicy{
+			ID:         "articles-read",
+			Subjects:   []string{"<.*>"},
+			Resources:  []string{"articles:<.*>"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{"ip": &CIDRCondition{CIDR: "10.0.0.0/8"}},
+		}
+		if !cmp.Equal(expected, p) {
+			t.Fatalf("Unexpected policy\n%s", cmp.Diff(expected, p))
+		}
+
+		if _, err := m.Get("existing"); err == nil {
+			t.Fatal("Expected existing policies to be replaced")
+		}
+	})
+
+	for k, c := range []struct {
+		files   map[string]string
+		message string
+	}{
+		{files: map[string]string{"broken.json": `{"id": `}, message: "broken.json"},
+		{files: map[string]string{"no-id.json": `{"effect": "allow"}`}, message: "no-id.json: policy has no id"},
+		{files: map[string]string{"effect.json": `{"id": "x", "effect": "permit"}`}, message: `effect.json: policy x has invalid effect "permit"`},
+		{files: map[string]string{"z.json": `{"id": "users-read", "effect": "allow"}`}, message: "policy users-read is already defined in " + filepath.Join(dir, "users.json")},
+	} {
+		invalid, err := ioutil.TempDir("", "ladon-policies")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(invalid)
+
+		for _, name := range []string{"articles.json", "users.json"} {
+			data, err := ioutil.ReadFile(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			writeFiles(t, invalid, map[string]string{name: string(data)})
+		}
+		writeFiles(t, invalid, c.files)
+
+		before := len(m.Policies)
+		_, err = LoadDir(m, invalid, true)
+		if err == nil {
+			t.Fatalf("Case %d: expected an error", k)
+		}
+		message := strings.Replace(c.message, dir, invalid, 1)
+		if !strings.Contains(err.Error(), message) {
+			t.Errorf("Case %d: expected %q to contain %q", k, err, message)
+		}
+		if len(m.Policies) != before {
+			t.Errorf("Case %d: expected the manager to be untouched", k)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package manager
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// ExportVersion is the version of the document written by ExportJSON.
+const ExportVersion = 1
+
+// ExportDocument is the document written by ExportJSON and read by ImportJSON.
+type ExportDocument struct {
+	Version  int              `json:"version"`
+	Policies []*DefaultPolicy `json:"policies"`
+}
+
+// ExportJSON writes all policies of m to w as a single JSON document. Policies are sorted by ID, so
+// exporting the same policy set twice yields the same document, which keeps diffs in version control small.
+func ExportJSON(m Manager, w io.Writer) error {
+	var policies []Policy
+	if err := Iterate(context.Background(), m, 100, func(p Policy) error {
+		policies = append(policies, p)
+		return nil
+	}); err != nil {
+		return err
+	}
+	return writeDocument(w, policies)
+}
+
+// ImportJSON reads a document written by ExportJSON from r and creates its policies in m. If replace is
+// true, the policies of the document replace all policies of m; this is atomic only if m is a Replacer or
+// a memory.MemoryManager. The document is decoded and its IDs are checked for uniqueness before m is
+// modified, so a malformed document leaves m untouched.
+func ImportJSON(m Manager, r io.Reader, replace bool) error {
+	doc, err := readDocument(r)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(doc.Policies))
+	for _, p := range doc.Policies {
+		if seen[p.ID] {
+			return errors.Errorf("policy %s is contained more than once", p.ID)
+		}
+		seen[p.ID] = true
+	}
+
+	if replace {
+		return replaceAll(m, doc.Policies)
+	}
+
+	for _, p := range doc.Policies {
+		if err := m.Create(p); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// deleteAll deletes all policies of m.
+func deleteAll(m Manager) error {
+	var ids []string
+	if err := Iterate(context.Background(), m, 100, func(p Policy) error {
+		ids = append(ids, p.GetID())
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := m.Delete(id); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// writeDocument writes policies to w as an ExportDocument, sorted by ID.
+func writeDocument(w io.Writer, policies []Policy) error {
+	doc := ExportDocument{Version: ExportVersion, Policies: make([]*DefaultPolicy, len(policies))}
+	for i, p := range policies {
+		dp, err := toDefaultPolicy(p)
+		if err != nil {
+			return err
+		}
+		doc.Policies[i] = dp
+	}
+
+	sort.Slice(doc.Policies, func(i, j int) bool {
+		return doc.Policies[i].ID < doc.Policies[j].ID
+	})
+
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return errors.WithStack(e.Encode(&doc))
+}
+
+// readDocument reads an ExportDocument from r and checks its version.
+func readDocument(r io.Reader) (*ExportDocument, error) {
+	var doc ExportDocument
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if doc.Version != ExportVersion {
+		return nil, errors.Errorf("unsupported export version %d, expected %d", doc.Version, ExportVersion)
+	}
+	return &doc, nil
+}
+
+// toDefaultPolicy converts p into a *DefaultPolicy, going through JSON for other Policy implementations.
+func toDefaultPolicy(p Policy) (*DefaultPolicy, error) {
+	switch dp := p.(type) {
+	case *DefaultPolicy:
+		return dp, nil
+	case *TimestampedPolicy:
+		return dp.DefaultPolicy, nil
+	}
+
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	var dp DefaultPolicy
+	if err := json.Unmarshal(raw, &dp); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &dp, nil
+}
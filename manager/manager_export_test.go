@@ -0,0 +1,109 @@
+package manager
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+)
+
+func TestExportImportJSON(t *testing.T) {
+	src := memory.NewMemoryManager()
+	policies := seed(t, src, 5)
+
+	withConditions := &DefaultPolicy{
+		ID:        "with-conditions",
+		Subjects:  []string{"<.*>"},
+		Resources: []string{"articles:<.*>"},
+		Actions:   []string{"get", "update"},
+		Effect:    DenyAccess,
+		Conditions: Conditions{
+			"ip":    &CIDRCondition{CIDR: "10.0.0.0/8"},
+			"owner": &EqualsSubjectCondition{},
+		},
+		Meta: []byte(`{"team":"ops"}`),
+	}
+	if err := src.Create(withConditions); err != nil {
+		t.Fatal(err)
+	}
+	policies = append(policies, withConditions)
+
+	var exported bytes.Buffer
+	if err := ExportJSON(src, &exported); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Import into a fresh manager", func(t *testing.T) {
+		dst := memory.NewMemoryManager()
+		if err := ImportJSON(dst, bytes.NewReader(exported.Bytes()), false); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, p := range policies {
+			got, err := dst.Get(p.GetID())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(p, got) {
+				t.Fatalf("Unexpected policy after import\n%s", cmp.Diff(p, got))
+			}
+		}
+
+		var reexported bytes.Buffer
+		if err := ExportJSON(dst, &reexported); err != nil {
+			t.Fatal(err)
+		}
+		if exported.String() != reexported.String() {
+			t.Fatalf("Expected exports to be identical\n%s", cmp.Diff(exported.String(), reexported.String()))
+		}
+	})
+
+	t.Run("Replace existing policies", func(t *testing.T) {
+		dst := memory.NewMemoryManager()
+		if err := dst.Create(&DefaultPolicy{ID: "stale", Conditions: Conditions{}}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ImportJSON(dst, bytes.NewReader(exported.Bytes()), true); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := dst.Get("stale"); err == nil {
+			t.Fatal("Expected stale policy to be deleted")
+		}
+		if len(dst.Policies) != len(policies) {
+			t.Fatalf("Expected %d policies, got %d", len(policies), len(dst.Policies))
+		}
+	})
+
+	t.Run("Reject unknown versions", func(t *testing.T) {
+		dst := memory.NewMemoryManager()
+		if err := dst.Create(&DefaultPolicy{ID: "kept", Conditions: Conditions{}}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ImportJSON(dst, strings.NewReader(`{"version":2,"policies":[]}`), true); err == nil {
+			t.Fatal("Expected an error for an unknown version")
+		}
+		if _, err := dst.Get("kept"); err != nil {
+			t.Fatal("Expected existing policies to be left untouched")
+		}
+	})
+
+	t.Run("Reject duplicate IDs before replacing", func(t *testing.T) {
+		dst := memory.NewMemoryManager()
+		if err := dst.Create(&DefaultPolicy{ID: "kept", Conditions: Conditions{}}); err != nil {
+			t.Fatal(err)
+		}
+
+		doc := `{"version":1,"policies":[{"id":"1","effect":"allow"},{"id":"1","effect":"deny"}]}`
+		if err := ImportJSON(dst, strings.NewReader(doc), true); err == nil {
+			t.Fatal("Expected an error for duplicate IDs")
+		}
+		if _, err := dst.Get("kept"); err != nil {
+			t.Fatal("Expected existing policies to be left untouched")
+		}
+	})
+}
@@ -0,0 +1,28 @@
+package manager
+
+import (
+	"time"
+
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// ErrHistoryUnsupported is returned for managers which don't keep a history of their policies.
+var ErrHistoryUnsupported = errors.New("Manager does not keep a policy history")
+
+// HistoryReader is implemented by managers which keep a history of their policies, e.g.
+// redis.RedisManager with redis.WithHistory.
+type HistoryReader interface {
+	// GetAllAsOf returns the policies which were stored at the given time, sorted by ID.
+	GetAllAsOf(at time.Time) (Policies, error)
+}
+
+// GetAllAsOf returns the policies m stored at the given time. It returns ErrHistoryUnsupported if m isn't
+// a HistoryReader; a HistoryReader may return it too, e.g. if its history is switched off.
+func GetAllAsOf(m Manager, at time.Time) (Policies, error) {
+	h, ok := m.(HistoryReader)
+	if !ok {
+		return nil, errors.WithStack(ErrHistoryUnsupported)
+	}
+	return h.GetAllAsOf(at)
+}
@@ -0,0 +1,80 @@
+package manager
+
+import (
+	"context"
+
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// CollisionError is returned by Migrate if a policy already exists in the destination manager.
+type CollisionError struct {
+	ID string
+}
+
+func (e *CollisionError) Error() string {
+	return "policy " + e.ID + " already exists in the destination manager"
+}
+
+// MigrateOption configures Migrate.
+type MigrateOption func(*migrateOptions)
+
+type migrateOptions struct {
+	skip      bool
+	overwrite bool
+}
+
+// SkipCollisions makes Migrate leave policies which already exist in the destination manager untouched.
+func SkipCollisions() MigrateOption {
+	return func(o *migrateOptions) {
+		o.skip = true
+	}
+}
+
+// OverwriteCollisions makes Migrate update policies which already exist in the destination manager.
+func OverwriteCollisions() MigrateOption {
+	return func(o *migrateOptions) {
+		o.overwrite = true
+	}
+}
+
+// Migrate copies all policies from src to dst, reading them in batches of batchSize. By default, Migrate
+// stops with a *CollisionError if a policy already exists in dst; use SkipCollisions or
+// OverwriteCollisions to change that.
+//
+// Migrate returns the number of policies written to dst, even if it fails, so an aborted migration can
+// be resumed with SkipCollisions.
+func Migrate(ctx context.Context, src, dst Manager, batchSize int, opts ...MigrateOption) (migrated int, err error) {
+	o := new(migrateOptions)
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	err = Iterate(ctx, src, batchSize, func(p Policy) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if _, err := dst.Get(p.GetID()); err == nil {
+			switch {
+			case o.skip:
+				return nil
+			case o.overwrite:
+				if err := dst.Update(p); err != nil {
+					return errors.WithStack(err)
+				}
+				migrated++
+				return nil
+			default:
+				return errors.WithStack(&CollisionError{ID: p.GetID()})
+			}
+		}
+
+		if err := dst.Create(p); err != nil {
+			return errors.WithStack(err)
+		}
+		migrated++
+		return nil
+	})
+	return migrated, err
+}
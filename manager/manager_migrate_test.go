@@ -0,0 +1,127 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+func seed(t *testing.T, m Manager, n int) Policies {
+	policies := Policies{}
+	for i := 0; i < n; i++ {
+		p := &DefaultPolicy{
+			ID:         fmt.Sprintf("test-policy-%d", i),
+			Subjects:   []string{"ex1"},
+			Resources:  []string{fmt.Sprintf("exr%d", i)},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		}
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+		policies = append(policies, p)
+	}
+	return policies
+}
+
+func TestMigrate(t *testing.T) {
+	src := memory.NewMemoryManager()
+	policies := seed(t, src, 25)
+
+	t.Run("Migrate into a fresh manager", func(t *testing.T) {
+		dst := memory.NewMemoryManager()
+		migrated, err := Migrate(context.Background(), src, dst, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if migrated != len(policies) {
+			t.Fatalf("Expected %d migrated policies, got %d", len(policies), migrated)
+		}
+
+		for _, p := range policies {
+			got, err := dst.Get(p.GetID())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(p, got) {
+				t.Fatalf("Unexpected policy after migration\n%s", cmp.Diff(p, got))
+			}
+		}
+	})
+
+	t.Run("Report collisions", func(t *testing.T) {
+		dst := memory.NewMemoryManager()
+		if err := dst.Create(policies[0]); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := Migrate(context.Background(), src, dst, 10)
+		var collision *CollisionError
+		if !errors.As(err, &collision) || collision.ID != policies[0].GetID() {
+			t.Fatalf("Expected a collision for %s, got %v", policies[0].GetID(), err)
+		}
+	})
+
+	t.Run("Skip collisions", func(t *testing.T) {
+		dst := memory.NewMemoryManager()
+		if err := dst.Create(policies[0]); err != nil {
+			t.Fatal(err)
+		}
+
+		migrated, err := Migrate(context.Background(), src, dst, 10, SkipCollisions())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if migrated != len(policies)-1 {
+			t.Fatalf("Expected %d migrated policies, got %d", len(policies)-1, migrated)
+		}
+	})
+
+	t.Run("Overwrite collisions", func(t *testing.T) {
+		dst := memory.NewMemoryManager()
+		if err := dst.Create(&DefaultPolicy{ID: policies[0].GetID()}); err != nil {
+			t.Fatal(err)
+		}
+
+		migrated, err := Migrate(context.Background(), src, dst, 7, OverwriteCollisions())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if migrated != len(policies) {
+			t.Fatalf("Expected %d migrated policies, got %d", len(policies), migrated)
+		}
+		if got, _ := dst.Get(policies[0].GetID()); !cmp.Equal(policies[0], got) {
+			t.Fatalf("Expected policy to be overwritten\n%s", cmp.Diff(policies[0], got))
+		}
+	})
+
+	t.Run("Return the progress on failure", func(t *testing.T) {
+		dst := &failingManager{MemoryManager: memory.NewMemoryManager(), after: 5}
+		migrated, err := Migrate(context.Background(), src, dst, 10)
+		if err == nil {
+			t.Fatal("Expected an error")
+		}
+		if migrated != 5 {
+			t.Fatalf("Expected 5 migrated policies, got %d", migrated)
+		}
+	})
+}
+
+// failingManager fails to create any policy after the first few.
+type failingManager struct {
+	*memory.MemoryManager
+	after int
+}
+
+func (m *failingManager) Create(p Policy) error {
+	if len(m.Policies) >= m.after {
+		return errors.New("backend unavailable")
+	}
+	return m.MemoryManager.Create(p)
+}
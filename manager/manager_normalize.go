@@ -0,0 +1,44 @@
+package manager
+
+import (
+	. "github.com/ory/ladon"
+)
+
+// Normalize removes duplicate subjects, resources and actions from p, keeping the first occurrence of
+// each, so managers index a policy once per distinct value. Values are compared as is, so `a` and `A`
+// are distinct. p is returned unchanged if it has no duplicates and as a *DefaultPolicy copy otherwise.
+func Normalize(p Policy) Policy {
+	subjects, ds := dedupe(p.GetSubjects())
+	resources, dr := dedupe(p.GetResources())
+	actions, da := dedupe(p.GetActions())
+	if !ds && !dr && !da {
+		return p
+	}
+
+	return &DefaultPolicy{
+		ID:          p.GetID(),
+		Description: p.GetDescription(),
+		Subjects:    subjects,
+		Effect:      p.GetEffect(),
+		Resources:   resources,
+		Actions:     actions,
+		Conditions:  p.GetConditions(),
+		Meta:        p.GetMeta(),
+	}
+}
+
+// dedupe returns values without duplicates in their original order and whether any were removed.
+func dedupe(values []string) ([]string, bool) {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			unique = append(unique, v)
+		}
+	}
+	if len(unique) == len(values) {
+		return values, false
+	}
+	return unique, true
+}
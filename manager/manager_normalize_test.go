@@ -0,0 +1,54 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+)
+
+func TestNormalize(t *testing.T) {
+	unique := &DefaultPolicy{ID: "unique", Subjects: []string{"user:alice"}, Resources: []string{"articles:1"}, Actions: []string{"get"}}
+
+	for k, c := range []struct {
+		p        Policy
+		expected Policy
+	}{
+		{p: unique, expected: unique},
+		{p: &DefaultPolicy{ID: "empty"}, expected: &DefaultPolicy{ID: "empty"}},
+		{
+			p: &DefaultPolicy{
+				ID:          "duplicates",
+				Description: "has duplicates",
+				Subjects:    []string{"user:bob", "user:alice", "user:bob"},
+				Effect:      DenyAccess,
+				Resources:   []string{"articles:1", "articles:1"},
+				Actions:     []string{"get", "update", "get", "update"},
+				Conditions:  Conditions{"owner": &EqualsSubjectCondition{}},
+				Meta:        []byte(`{}`),
+			},
+			expected: &DefaultPolicy{
+				ID:          "duplicates",
+				Description: "has duplicates",
+				Subjects:    []string{"user:bob", "user:alice"},
+				Effect:      DenyAccess,
+				Resources:   []string{"articles:1"},
+				Actions:     []string{"get", "update"},
+				Conditions:  Conditions{"owner": &EqualsSubjectCondition{}},
+				Meta:        []byte(`{}`),
+			},
+		},
+		{
+			p:        &DefaultPolicy{ID: "case", Subjects: []string{"user:alice", "User:Alice"}},
+			expected: &DefaultPolicy{ID: "case", Subjects: []string{"user:alice", "User:Alice"}},
+		},
+	} {
+		if got := Normalize(c.p); !cmp.Equal(c.expected, got) {
+			t.Errorf("Case %d: unexpected policy\n%s", k, cmp.Diff(c.expected, got))
+		}
+	}
+
+	if Normalize(unique) != Policy(unique) {
+		t.Errorf("expected a policy without duplicates to be returned as is")
+	}
+}
@@ -0,0 +1,50 @@
+package manager
+
+import (
+	"bytes"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+// Snapshot serializes all policies of the memory manager m using the document format of ExportJSON.
+// Policies are sorted by ID, so snapshots of the same policy set are identical.
+func Snapshot(m *memory.MemoryManager) ([]byte, error) {
+	m.RLock()
+	policies := make([]Policy, 0, len(m.Policies))
+	for _, p := range m.Policies {
+		policies = append(policies, p)
+	}
+	m.RUnlock()
+
+	var b bytes.Buffer
+	if err := writeDocument(&b, policies); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Restore replaces all policies of the memory manager m with the policies of a snapshot taken by
+// Snapshot or ExportJSON. The snapshot is decoded completely before the policies are swapped in at
+// once, so concurrent readers see either the old or the new policy set, and a malformed snapshot
+// leaves m untouched.
+func Restore(m *memory.MemoryManager, data []byte) error {
+	doc, err := readDocument(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	policies := make(map[string]Policy, len(doc.Policies))
+	for _, p := range doc.Policies {
+		if _, ok := policies[p.ID]; ok {
+			return errors.Errorf("policy %s is contained more than once", p.ID)
+		}
+		policies[p.ID] = p
+	}
+
+	m.Lock()
+	m.Policies = policies
+	m.Unlock()
+	return nil
+}
@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	m := memory.NewMemoryManager()
+	policies := seed(t, m, 5)
+
+	snapshot, err := Snapshot(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, err := Snapshot(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(snapshot, again) {
+		t.Fatal("Expected snapshots of the same policy set to be identical")
+	}
+
+	// Mutate the policy set: delete, update and add policies.
+	if err := m.Delete(policies[0].GetID()); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Update(&DefaultPolicy{ID: policies[1].GetID(), Description: "changed", Conditions: Conditions{}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Create(&DefaultPolicy{ID: "added", Conditions: Conditions{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Restore(m, snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Policies) != len(policies) {
+		t.Fatalf("Expected %d policies after restore, got %d", len(policies), len(m.Policies))
+	}
+	for _, p := range policies {
+		got, err := m.Get(p.GetID())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(p, got) {
+			t.Fatalf("Unexpected policy after restore\n%s", cmp.Diff(p, got))
+		}
+	}
+
+	t.Run("Leave the manager untouched on malformed snapshots", func(t *testing.T) {
+		for k, data := range []string{
+			`not json`,
+			`{"version":2,"policies":[]}`,
+			`{"version":1,"policies":[{"id":"a"},{"id":"a"}]}`,
+		} {
+			if err := Restore(m, []byte(data)); err == nil {
+				t.Errorf("Case %d: expected an error", k)
+			}
+		}
+		if len(m.Policies) != len(policies) {
+			t.Fatalf("Expected %d policies, got %d", len(policies), len(m.Policies))
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package manager
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+)
+
+// checkingManager answers Exists itself and fails Get, so tests can tell which one was used.
+type checkingManager struct {
+	*memory.MemoryManager
+	err error
+}
+
+func (m *checkingManager) Exists(id string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	_, ok := m.Policies[id]
+	return ok, nil
+}
+
+func (m *checkingManager) Get(id string) (Policy, error) {
+	return nil, errors.New("Get must not be called")
+}
+
+// unreachableManager fails Get with err.
+type unreachableManager struct {
+	*memory.MemoryManager
+	err error
+}
+
+func (m *unreachableManager) Get(id string) (Policy, error) {
+	return nil, m.err
+}
+
+func TestExists(t *testing.T) {
+	mem := memory.NewMemoryManager()
+	if err := mem.Create(&DefaultPolicy{ID: "test-policy-1"}); err != nil {
+		t.Fatal(err)
+	}
+	unreachable := errors.New("connection refused")
+
+	for k, c := range []struct {
+		m      Manager
+		id     string
+		exists bool
+		err    error
+	}{
+		{m: mem, id: "test-policy-1", exists: true},
+		{m: mem, id: "missing", exists: false},
+		{m: &unreachableManager{MemoryManager: mem, err: unreachable}, id: "test-policy-1", err: unreachable},
+		{m: &checkingManager{MemoryManager: mem}, id: "test-policy-1", exists: true},
+		{m: &checkingManager{MemoryManager: mem}, id: "missing", exists: false},
+		{m: &checkingManager{MemoryManager: mem, err: unreachable}, id: "test-policy-1", err: unreachable},
+	} {
+		exists, err := Exists(c.m, c.id)
+		if err != c.err || exists != c.exists {
+			t.Errorf("Case %d: expected %t, %v, got %t, %v", k, c.exists, c.err, exists, err)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package manager
+
+import (
+	"time"
+
+	. "github.com/ory/ladon"
+)
+
+// Timestamps records when a policy was created and last changed.
+type Timestamps struct {
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TimestampedPolicy is a policy together with when it was created and last changed. Managers tracking
+// timestamps, like redis.RedisManager and the SQL managers, return it from Get and GetAll, so callers
+// read the timestamps with a type assertion:
+//
+//	if ts, ok := p.(*manager.TimestampedPolicy); ok {
+//		fmt.Println(ts.UpdatedAt)
+//	}
+//
+// It marshals to the policy's JSON with the fields `createdAt` and `updatedAt` added. Managers ignore
+// these fields when a TimestampedPolicy is passed to Create or Update and set the timestamps themselves.
+type TimestampedPolicy struct {
+	*DefaultPolicy
+	Timestamps
+}
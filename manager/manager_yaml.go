@@ -0,0 +1,105 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// UnmarshalYAML parses policies from YAML. The input may contain several documents separated by `---`,
+// each of which is either a single policy or a list of policies. Field names are the same as in
+// ladon's JSON representation, and conditions are decoded into their registered types through
+// ladon.ConditionFactories just like when unmarshalling JSON:
+//
+//	id: articles-owner
+//	subjects: ["<.*>"]
+//	resources: ["articles:<.*>"]
+//	actions: ["update"]
+//	effect: allow
+//	conditions:
+//	  owner:
+//	    type: EqualsSubjectCondition
+func UnmarshalYAML(data []byte) ([]*DefaultPolicy, error) {
+	var policies []*DefaultPolicy
+
+	d := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc interface{}
+		if err := d.Decode(&doc); err == io.EOF {
+			return policies, nil
+		} else if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		if doc == nil {
+			continue
+		}
+
+		// Go through JSON so conditions are decoded by ladon.Conditions' UnmarshalJSON.
+		raw, err := json.Marshal(toJSONValue(doc))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+
+		if _, ok := doc.([]interface{}); ok {
+			var ps []*DefaultPolicy
+			if err := json.Unmarshal(raw, &ps); err != nil {
+				return nil, errors.WithStack(err)
+			}
+			policies = append(policies, ps...)
+			continue
+		}
+
+		var p DefaultPolicy
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		policies = append(policies, &p)
+	}
+}
+
+// LoadYAML parses policies from r using UnmarshalYAML and creates them in m. All policies are parsed
+// before the first one is created, so a malformed document leaves m untouched.
+func LoadYAML(m Manager, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	policies, err := UnmarshalYAML(data)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range policies {
+		if err := m.Create(p); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// toJSONValue converts the map[interface{}]interface{} values produced by yaml.v2 into
+// map[string]interface{}, which encoding/json can marshal.
+func toJSONValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = toJSONValue(val)
+		}
+		return m
+	case []interface{}:
+		for i, val := range v {
+			v[i] = toJSONValue(val)
+		}
+		return v
+	default:
+		return v
+	}
+}
@@ -0,0 +1,104 @@
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/condition"
+	"github.com/ory/ladon/manager/memory"
+)
+
+func TestUnmarshalYAML(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/policies.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policies, err := UnmarshalYAML(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*DefaultPolicy{
+		{
+			ID:          "articles-read",
+			Description: "Read access from the internal network",
+			Subjects:    []string{"<.*>"},
+			Resources:   []string{"articles:<.*>"},
+			Actions:     []string{"get"},
+			Effect:      AllowAccess,
+			Conditions:  Conditions{"ip": &CIDRCondition{CIDR: "10.0.0.0/8"}},
+		},
+		{
+			ID:        "articles-owner",
+			Subjects:  []string{"<.*>"},
+			Resources: []string{"articles:<.*>"},
+			Actions:   []string{"update", "delete"},
+			Effect:    AllowAccess,
+			Conditions: Conditions{
+				"owner":  &EqualsSubjectCondition{},
+				"region": &StringEqualCondition{Equals: "eu"},
+			},
+		},
+		{
+			ID:        "articles-actions",
+			Subjects:  []string{"group:editors"},
+			Resources: []string{"articles:<.*>"},
+			Actions:   []string{"<.*>"},
+			Effect:    DenyAccess,
+			Conditions: Conditions{
+				"action": &condition.ActionMatchCondition{Actions: []string{"publish", "archive"}},
+			},
+		},
+	}
+	if !cmp.Equal(expected, policies) {
+		t.Fatalf("Unexpected policies\n%s", cmp.Diff(expected, policies))
+	}
+
+	t.Run("Reject unknown condition types", func(t *testing.T) {
+		_, err := UnmarshalYAML([]byte("id: a\nconditions:\n  x:\n    type: DoesNotExist\n"))
+		if err == nil {
+			t.Fatal("Expected an error for an unknown condition type")
+		}
+	})
+}
+
+func TestLoadYAML(t *testing.T) {
+	f, err := os.Open("testdata/policies.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	m := memory.NewMemoryManager()
+	if err := LoadYAML(m, f); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Policies) != 3 {
+		t.Fatalf("Expected 3 policies, got %d", len(m.Policies))
+	}
+
+	w := &Ladon{Manager: m}
+	if err := w.IsAllowed(&Request{
+		Subject:  "alice",
+		Resource: "articles:1",
+		Action:   "get",
+		Context:  Context{"ip": "10.1.2.3"},
+	}); err != nil {
+		t.Fatalf("Expected request to be allowed: %s", err)
+	}
+
+	t.Run("Leave the manager untouched on malformed input", func(t *testing.T) {
+		m := memory.NewMemoryManager()
+		if err := LoadYAML(m, strings.NewReader("id: a\n---\n- : [")); err == nil {
+			t.Fatal("Expected an error for malformed input")
+		}
+		if len(m.Policies) != 0 {
+			t.Fatalf("Expected no policies, got %d", len(m.Policies))
+		}
+	})
+}
@@ -0,0 +1,105 @@
+// Package postgres contains a ladon.Manager storing every policy as a single JSONB row in PostgreSQL.
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/ory/ladon-community/manager/sqlmanager"
+)
+
+var (
+	ErrPolicyExists  = sqlmanager.ErrPolicyExists
+	ErrBadConversion = sqlmanager.ErrBadConversion
+
+	// ErrConflict is returned by UpdateIfMatch if the policy was changed since its ETag was read.
+	ErrConflict = sqlmanager.ErrConflict
+)
+
+// Timestamps records when a policy was created and last changed.
+type Timestamps = sqlmanager.Timestamps
+
+// migrations are applied in order by CreateSchemas. Never change a migration which has been released,
+// append a new one instead.
+var migrations = []string{
+	`CREATE TABLE ladon_jsonb_policy (
+	id              varchar(255) NOT NULL PRIMARY KEY,
+	policy          jsonb NOT NULL,
+	subjects_any    boolean NOT NULL,
+	resources_any   boolean NOT NULL
+)`,
+	`CREATE INDEX ladon_jsonb_policy_subjects_idx ON ladon_jsonb_policy USING GIN ((policy->'subjects') jsonb_path_ops)`,
+	`CREATE INDEX ladon_jsonb_policy_resources_idx ON ladon_jsonb_policy USING GIN ((policy->'resources') jsonb_path_ops)`,
+	`ALTER TABLE ladon_jsonb_policy
+	ADD COLUMN created_at timestamptz NOT NULL DEFAULT now(),
+	ADD COLUMN updated_at timestamptz NOT NULL DEFAULT now()`,
+}
+
+// Dialect is the PostgreSQL dialect of sqlmanager.SQLManager. Policies are stored as JSONB and their
+// subjects and resources are looked up with the @> operator, which is backed by GIN indexes.
+type Dialect struct{}
+
+// Migrations returns the migrations of the PostgreSQL schema.
+func (Dialect) Migrations() []string {
+	return migrations
+}
+
+// Placeholder returns $n.
+func (Dialect) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+// InsertIgnore returns an INSERT ... ON CONFLICT DO NOTHING statement.
+func (d Dialect) InsertIgnore(table string, columns ...string) string {
+	return sqlmanager.InsertOnConflictDoNothing(d, table, columns...)
+}
+
+// JSONText returns policy->>'field'.
+func (Dialect) JSONText(field string) string {
+	return `policy->>'` + field + `'`
+}
+
+// JSONSet returns jsonb_set(policy, '{field}', to_jsonb(arg::text)).
+func (Dialect) JSONSet(field string, arg string) string {
+	return `jsonb_set(policy, '{` + field + `}', to_jsonb(` + arg + `::text))`
+}
+
+// ArrayContains returns policy->'field' @> jsonb_build_array(arg::text), which can use the GIN indexes.
+func (Dialect) ArrayContains(field string, arg string) string {
+	return `policy->'` + field + `' @> jsonb_build_array(` + arg + `::text)`
+}
+
+// ArrayElements returns jsonb_array_elements_text(policy->'field') AS e(value).
+func (Dialect) ArrayElements(field string) string {
+	return `jsonb_array_elements_text(policy->'` + field + `') AS e(value)`
+}
+
+// Now returns now().
+func (Dialect) Now() string {
+	return `now()`
+}
+
+// ForUpdate returns the FOR UPDATE clause.
+func (Dialect) ForUpdate() string {
+	return ` FOR UPDATE`
+}
+
+// PostgresManager is a PostgreSQL implementation of ladon.Manager. Each policy is stored as a JSONB
+// document, including its conditions, so policies can be queried with PostgreSQL's JSON operators, e.g.
+//
+//	SELECT id FROM ladon_jsonb_policy WHERE policy->'conditions' @> '{"ip":{"type":"CIDRCondition"}}'
+//
+// Subjects and resources are indexed with GIN indexes, which look up literal values only. Policies
+// whose subjects or resources are empty or contain a regular expression are flagged, so they are
+// returned as candidates for any value.
+//
+// It is a sqlmanager.SQLManager using Dialect.
+type PostgresManager struct {
+	*sqlmanager.SQLManager
+}
+
+// NewPostgresManager initializes a new PostgresManager. db must use a PostgreSQL driver like
+// github.com/lib/pq. Call CreateSchemas before using the manager.
+func NewPostgresManager(db *sql.DB, opts ...sqlmanager.Option) *PostgresManager {
+	return &PostgresManager{SQLManager: sqlmanager.NewSQLManager(db, Dialect{}, opts...)}
+}
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+)
+
+func TestSetEffect(t *testing.T) {
+	m := reset(t)
+	w := &Ladon{Manager: m, Matcher: DefaultMatcher}
+	policy := &DefaultPolicy{
+		ID:         "test-policy-1",
+		Subjects:   []string{"user:alice"},
+		Resources:  []string{"articles:1"},
+		Actions:    []string{"get"},
+		Effect:     AllowAccess,
+		Conditions: Conditions{},
+	}
+	if err := m.Create(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Request{Subject: "user:alice", Resource: "articles:1", Action: "get"}
+	if err := w.IsAllowed(r); err != nil {
+		t.Fatalf("Expected the request to be allowed, got %v", err)
+	}
+
+	before, err := m.GetTimestamps(policy.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetEffect(policy.ID, DenyAccess); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.IsAllowed(r); err == nil {
+		t.Fatal("Expected the request to be denied")
+	}
+
+	p, err := m.Get(policy.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.GetEffect() != DenyAccess || len(p.GetSubjects()) != 1 {
+		t.Fatalf("Expected only the effect to change, got %+v", p)
+	}
+	after, err := m.GetTimestamps(policy.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !after.UpdatedAt.After(before.UpdatedAt) {
+		t.Fatalf("Expected UpdatedAt to advance past %s, got %s", before.UpdatedAt, after.UpdatedAt)
+	}
+
+	if err := m.SetEffect("does-not-exist", DenyAccess); err != ErrNotFound {
+		t.Fatalf("Expected %s, got %v", ErrNotFound, err)
+	}
+	if err := m.SetEffect(policy.ID, "maybe"); err == nil {
+		t.Fatal("Expected an invalid effect to be rejected")
+	}
+}
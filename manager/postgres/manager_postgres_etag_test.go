@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+)
+
+func TestUpdateIfMatch(t *testing.T) {
+	m := reset(t)
+	policy := &DefaultPolicy{
+		ID:         "test-policy-1",
+		Subjects:   []string{"user:alice"},
+		Resources:  []string{"articles:1"},
+		Actions:    []string{"get"},
+		Effect:     AllowAccess,
+		Conditions: Conditions{},
+	}
+	if err := m.Create(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	_, tag, err := m.GetWithETag(policy.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated := *policy
+	updated.Description = "updated"
+	if err := m.UpdateIfMatch(&updated, tag); err != nil {
+		t.Fatal(err)
+	}
+
+	p, newTag, err := m.GetWithETag(policy.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(&updated, p) {
+		t.Fatalf("Unexpected policy after update\n%s", cmp.Diff(&updated, p))
+	}
+	if newTag == tag {
+		t.Fatal("Expected the ETag to change")
+	}
+
+	if err := m.UpdateIfMatch(policy, tag); err != ErrConflict {
+		t.Fatalf("Expected %v, got %v", ErrConflict, err)
+	}
+	if err := m.UpdateIfMatch(&DefaultPolicy{ID: "does-not-exist"}, tag); err != ErrNotFound {
+		t.Fatalf("Expected %v, got %v", ErrNotFound, err)
+	}
+}
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+)
+
+func TestTimestamps(t *testing.T) {
+	m := reset(t)
+	policy := &DefaultPolicy{
+		ID:         "test-policy-1",
+		Subjects:   []string{"user:alice"},
+		Resources:  []string{"articles:1"},
+		Actions:    []string{"get"},
+		Effect:     AllowAccess,
+		Conditions: Conditions{},
+	}
+	if err := m.Create(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := m.GetTimestamps(policy.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.CreatedAt.IsZero() || !created.UpdatedAt.Equal(created.CreatedAt) {
+		t.Fatalf("Expected both timestamps to be set on Create, got %+v", created)
+	}
+
+	if err := m.Update(policy); err != nil {
+		t.Fatal(err)
+	}
+	updated, err := m.GetTimestamps(policy.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated.CreatedAt.Equal(created.CreatedAt) {
+		t.Fatalf("Expected CreatedAt to stay %s, got %s", created.CreatedAt, updated.CreatedAt)
+	}
+	if !updated.UpdatedAt.After(created.UpdatedAt) {
+		t.Fatalf("Expected UpdatedAt to advance past %s, got %s", created.UpdatedAt, updated.UpdatedAt)
+	}
+
+	if _, err := m.GetTimestamps("does-not-exist"); err != ErrNotFound {
+		t.Fatalf("Expected %s, got %v", ErrNotFound, err)
+	}
+}
@@ -3,23 +3,65 @@
 package redis
 
 import (
-	"encoding/json"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-redis/redis"
 	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/condition"
+	"github.com/ory/ladon-community/manager"
 	"github.com/pkg/errors"
 )
 
 var (
-	ErrPolicyExists  = errors.New("Policy exists")
-	ErrBadConversion = errors.New("Could not convert policy from redis")
+	ErrPolicyExists   = errors.New("Policy exists")
+	ErrBadConversion  = errors.New("Could not convert policy from redis")
+	ErrIndexDisabled  = errors.New("Index is disabled")
+	ErrPolicyTooLarge = errors.New("Policy is too large")
 )
 
+// PolicyError is returned when an operation on a single policy fails. It carries the ID of the policy and
+// unwraps to the underlying error, so errors.Is(err, ErrBadConversion) keeps working through it.
+type PolicyError struct {
+	ID  string
+	Err error
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("policy %s: %s", e.ID, e.Err)
+}
+
+// Unwrap returns the underlying error for errors.Is and errors.As.
+func (e *PolicyError) Unwrap() error {
+	return e.Err
+}
+
+// Cause returns the underlying error for errors.Cause of github.com/pkg/errors.
+func (e *PolicyError) Cause() error {
+	return e.Err
+}
+
 const (
 	prefixPolicy   = "policy"
 	prefixResource = "resource"
 	prefixSubject  = "subject"
+	prefixReplace  = "replace"
+	prefixReindex  = "reindex"
+	prefixHistory  = "history"
+
+	// prefixSubjectPrefix is the prefix of the index of subjects ending in a wildcard, see
+	// WithSubjectPrefixIndex.
+	prefixSubjectPrefix = "subjectprefix"
+
+	// iteratePageSize is the SCAN count used by Iterate.
+	iteratePageSize = 100
 )
 
 // Just returns strings.Join(vals, "_") for creating redis keys
@@ -27,37 +69,115 @@ func prefixKey(vals ...string) string {
 	return strings.Join(vals, "_")
 }
 
+// key returns the Redis key for vals, prefixed by the manager's key prefix.
+func (m *RedisManager) key(vals ...string) string {
+	return prefixKey(append([]string{m.keyPrefix}, vals...)...)
+}
+
+// indexKey returns the key of the subject or resource index hashmap for value.
+func (m *RedisManager) indexKey(prefix string, value string) string {
+	if m.caseInsensitive {
+		value = strings.ToLower(value)
+	}
+	return m.key(prefix, value)
+}
+
+// namespaced returns a manager sharing the client and options of m whose keys are stored in the namespace
+// ns below the key prefix of m. Both managers' keys share the same hash tag.
+func (m *RedisManager) namespaced(ns string) *RedisManager {
+	return &RedisManager{
+		db:        m.db,
+		keyPrefix: m.key(ns),
+		options:   m.options,
+	}
+}
+
 // RedisManager is a redis implementation of Manager to store policies persistently.
 type RedisManager struct {
-	db        *redis.Client
+	db redis.UniversalClient
+
+	// keyPrefix is the prefix of all keys, e.g. "ladon", or "{ladon}" if it is used as a hash tag.
 	keyPrefix string
+
+	options
+
+	// owned is true if the manager created the client itself and is thus responsible for closing it.
+	owned     bool
+	closeOnce sync.Once
+	closeErr  error
 }
 
-// NewRedisManager initializes a new RedisManager with no policies
-func NewRedisManager(db *redis.Client, keyPrefix string) *RedisManager {
+// NewRedisManager initializes a new RedisManager with no policies. db may be a single node, sentinel
+// backed or cluster client, e.g. as returned by redis.NewUniversalClient.
+//
+// Keys are stored as keyPrefix_policy_<id> and so on. A Redis Cluster requires the WithHashTag option,
+// which stores them as {keyPrefix}_policy_<id> so all keys of a manager map to the same cluster slot.
+// Switching it on for existing data requires migrating the keys, see WithHashTag.
+func NewRedisManager(db redis.UniversalClient, keyPrefix string, opts ...Option) *RedisManager {
 	if keyPrefix == "" {
 		keyPrefix = "ladon"
 	}
 
-	return &RedisManager{
+	m := &RedisManager{
 		db:        db,
 		keyPrefix: keyPrefix,
+		options:   options{maxPolicySize: DefaultMaxPolicySize},
+	}
+	for _, opt := range opts {
+		opt(&m.options)
 	}
+	if m.hashTag {
+		m.keyPrefix = "{" + keyPrefix + "}"
+	}
+	return m
+}
+
+// NewRedisManagerFromURL initializes a new RedisManager connecting to the Redis server at url, e.g.
+// redis://localhost:6379/0. The manager owns the connection, which is released by Close.
+func NewRedisManagerFromURL(url string, keyPrefix string, opts ...Option) (*RedisManager, error) {
+	settings, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	m := NewRedisManager(redis.NewClient(settings), keyPrefix, opts...)
+	m.owned = true
+	return m, nil
+}
+
+// Close closes the Redis client if it was created by NewRedisManagerFromURL. Managers initialized with
+// NewRedisManager use a client passed in by the caller, who might share it, so Close is a no-op for them.
+// Calling Close more than once is safe.
+func (m *RedisManager) Close() error {
+	if !m.owned {
+		return nil
+	}
+
+	m.closeOnce.Do(func() {
+		m.closeErr = m.db.Close()
+	})
+	return m.closeErr
 }
 
 // Create a new policy in Redis. It will create a single key for the policy itself,
-// and for each subject and resource the policy will also exist in a hashmap.
+// and for each subject and resource the policy will also exist in a hashmap. Duplicate subjects,
+// resources and actions are removed with manager.Normalize before the policy is stored.
 func (m *RedisManager) Create(policy Policy) error {
+	policy = manager.Normalize(policy)
+
 	// Make sure that the key doesn't already exist
-	key := prefixKey(m.keyPrefix, prefixPolicy, policy.GetID())
+	key := m.key(prefixPolicy, policy.GetID())
 	if err := m.db.Get(key).Err(); err == nil {
 		return ErrPolicyExists
 	}
 
-	p, err := json.Marshal(policy)
+	p, err := m.encode(policy, time.Time{})
 	if err != nil {
 		return err
 	}
+	if err := m.checkSize(policy.GetID(), p); err != nil {
+		return err
+	}
 
 	// Set the policy key
 	cmd := m.db.Set(key, p, 0)
@@ -65,43 +185,45 @@ func (m *RedisManager) Create(policy Policy) error {
 	if err := cmd.Err(); err != nil {
 		return err
 	}
+	if err := m.recordVersion(m.db, policy.GetID(), p); err != nil {
+		return err
+	}
 
 	// Put this policy in the hashmap for each resource
-	for _, v := range policy.GetResources() {
-		hmkey := prefixKey(m.keyPrefix, prefixResource, v)
+	for _, v := range m.indexed(prefixResource, policy.GetResources()) {
+		hmkey := m.indexKey(prefixResource, v)
 		field := policy.GetID()
-		if err := m.db.HMSet(hmkey, map[string]interface{}{
-			field: p,
-		}).Err(); err != nil {
+		if err := m.indexSet(m.db, hmkey, field, m.indexValue(p)).Err(); err != nil {
 			return err
 		}
 	}
 
 	// Put this policy in the hashmap for each subject
-	for _, v := range policy.GetSubjects() {
-		hmkey := prefixKey(m.keyPrefix, prefixSubject, v)
+	for _, hmkey := range m.subjectKeys(policy) {
 		field := policy.GetID()
-		if err := m.db.HMSet(hmkey, map[string]interface{}{
-			field: p,
-		}).Err(); err != nil {
+		if err := m.indexSet(m.db, hmkey, field, m.indexValue(p)).Err(); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// GetAll retrieves all policies. (Equivelant of db.keys + db.Mget)
-func (m *RedisManager) GetAll(limit int64, offset int64) (Policies, error) {
-	key := prefixKey(m.keyPrefix, prefixPolicy, "*")
-	keyscmd := m.db.Keys(key)
-	if err := keyscmd.Err(); err != nil {
-		return nil, err
-	}
+// GetAll retrieves all policies as *manager.TimestampedPolicy, sorted by ID. (Equivelant of db.keys + db.Mget)
+func (m *RedisManager) GetAll(limit int64, offset int64) (policies Policies, err error) {
+	err = m.retry(func() error {
+		policies, err = m.getAll(limit, offset)
+		return err
+	})
+	return policies, err
+}
 
-	keys, err := keyscmd.Result()
+// getAll implements GetAll without retries.
+func (m *RedisManager) getAll(limit int64, offset int64) (Policies, error) {
+	keys, err := m.keysMatching(m.key(prefixPolicy, "*"))
 	if err != nil {
 		return nil, err
 	}
+	sort.Strings(keys)
 
 	mgetcmd := m.db.MGet(keys...)
 	if err := mgetcmd.Err(); err != nil {
@@ -110,17 +232,19 @@ func (m *RedisManager) GetAll(limit int64, offset int64) (Policies, error) {
 
 	values := mgetcmd.Val()
 
-	policies := make(Policies, len(values))
+	policies := make(Policies, 0, len(values))
 	for i, v := range values {
-		p := &DefaultPolicy{}
-		b := []byte(v.(string))
-		// if !ok {
-		// 	return nil, errors.Wrapf(ErrBadConversion, "value %+v is not a byte array", v)
-		// }
-		if err := json.Unmarshal(b, p); err != nil {
-			return nil, errors.Wrap(ErrBadConversion, err.Error())
+		s, ok := v.(string)
+		if !ok {
+			// The policy was deleted after its key was listed.
+			continue
 		}
-		policies[i] = p
+
+		p, err := m.decodeTimestampedPolicy(m.policyID(keys[i]), s)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
 	}
 
 	if offset+limit > int64(len(policies)) {
@@ -131,63 +255,226 @@ func (m *RedisManager) GetAll(limit int64, offset int64) (Policies, error) {
 	return policies[offset:limit], nil
 }
 
-// Get retrieves a policy.
+// GetAllPaginated retrieves a page of policies using the Redis SCAN cursor. Pass an empty cursor to start
+// at the beginning and the returned cursor to fetch the next page; an empty returned cursor means that
+// all policies have been retrieved. pageSize is a hint, so a page may contain slightly more or fewer
+// policies. Unlike GetAll, iteration stays stable when policies are created or deleted between pages:
+// every policy that exists for the whole iteration is returned, although SCAN may return it more than
+// once if Redis resizes its keyspace in the meantime. Like GetAll, it returns *manager.TimestampedPolicy.
+func (m *RedisManager) GetAllPaginated(cursor string, pageSize int) (policies Policies, next string, err error) {
+	err = m.retry(func() error {
+		policies, next, err = m.getAllPaginated(cursor, pageSize)
+		return err
+	})
+	return policies, next, err
+}
+
+// getAllPaginated implements GetAllPaginated without retries.
+func (m *RedisManager) getAllPaginated(cursor string, pageSize int) (Policies, string, error) {
+	var c uint64
+	if cursor != "" {
+		parsed, err := strconv.ParseUint(cursor, 10, 64)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "invalid cursor %s", cursor)
+		}
+		c = parsed
+	}
+
+	keys, next, err := m.scan(c, m.key(prefixPolicy, "*"), int64(pageSize))
+	if err != nil {
+		return nil, "", err
+	}
+
+	policies := Policies{}
+	if len(keys) > 0 {
+		values, err := m.db.MGet(keys...).Result()
+		if err != nil {
+			return nil, "", err
+		}
+
+		for i, v := range values {
+			s, ok := v.(string)
+			if !ok {
+				// The policy was deleted after it was scanned.
+				continue
+			}
+
+			p, err := m.decodeTimestampedPolicy(m.policyID(keys[i]), s)
+			if err != nil {
+				return nil, "", err
+			}
+			policies = append(policies, p)
+		}
+	}
+
+	if next == 0 {
+		return policies, "", nil
+	}
+	return policies, strconv.FormatUint(next, 10), nil
+}
+
+// Iterate calls fn for every policy without loading all of them into memory at once. Iteration stops
+// early and returns the error if fn returns an error or ctx is done. Like GetAllPaginated, it is backed
+// by SCAN and thus might call fn more than once for a policy if Redis resizes its keyspace meanwhile.
+func (m *RedisManager) Iterate(ctx context.Context, fn func(Policy) error) error {
+	var cursor string
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		policies, next, err := m.GetAllPaginated(cursor, iteratePageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range policies {
+			if err := fn(p); err != nil {
+				return err
+			}
+		}
+
+		if next == "" {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// FindPoliciesByDescription returns the policies whose description contains substring, ignoring case
+// and sorted by ID. An empty substring matches every policy. Descriptions aren't indexed, so this scans
+// all policies and is meant for administrative use rather than the request path.
+func (m *RedisManager) FindPoliciesByDescription(substring string) (Policies, error) {
+	substring = strings.ToLower(substring)
+	policies := Policies{}
+	// SCAN may return a key more than once, e.g. while Redis rehashes.
+	seen := map[string]bool{}
+	if err := m.Iterate(context.Background(), func(p Policy) error {
+		if seen[p.GetID()] {
+			return nil
+		}
+		seen[p.GetID()] = true
+		if strings.Contains(strings.ToLower(p.GetDescription()), substring) {
+			policies = append(policies, p)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	sortByID(policies)
+	return policies, nil
+}
+
+// ListSubjects returns the distinct, non-empty subjects of all policies in ascending order. Subjects are
+// read from the index, so they are lowercased if the manager uses WithCaseInsensitiveIndex.
+func (m *RedisManager) ListSubjects() ([]string, error) {
+	if m.noSubjectIndex {
+		return nil, ErrIndexDisabled
+	}
+	return m.listIndex(prefixSubject)
+}
+
+// ListResources returns the distinct, non-empty resources of all policies in ascending order. Resources
+// are read from the index, so they are lowercased if the manager uses WithCaseInsensitiveIndex.
+func (m *RedisManager) ListResources() ([]string, error) {
+	if m.noResourceIndex {
+		return nil, ErrIndexDisabled
+	}
+	return m.listIndex(prefixResource)
+}
+
+func (m *RedisManager) listIndex(prefix string) ([]string, error) {
+	keys, err := m.keysMatching(m.key(prefix, "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	values := []string{}
+	for _, key := range keys {
+		if v := strings.TrimPrefix(key, m.key(prefix, "")); v != "" {
+			values = append(values, v)
+		}
+	}
+	sort.Strings(values)
+	return values, nil
+}
+
+// Get retrieves a policy as a *manager.TimestampedPolicy. It returns ErrNotFound only if the policy
+// doesn't exist, errors talking to Redis are returned as they are.
 func (m *RedisManager) Get(id string) (Policy, error) {
 	var (
-		key    = prefixKey(m.keyPrefix, prefixPolicy, id)
-		cmd    = m.db.Get(key)
-		policy = &DefaultPolicy{}
+		key = m.key(prefixPolicy, id)
+		cmd *redis.StringCmd
 	)
 
-	if err := cmd.Err(); err != nil {
+	if err := m.retry(func() error {
+		cmd = m.db.Get(key)
+		return cmd.Err()
+	}); err == redis.Nil {
 		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
 	}
-	b, err := cmd.Bytes()
+	v, err := cmd.Result()
 	if err != nil {
 		return nil, err
 	}
-
-	if err := json.Unmarshal(b, policy); err != nil {
-		return nil, errors.Wrap(ErrBadConversion, err.Error())
+	policy, err := m.decodeTimestampedPolicy(id, v)
+	if err != nil {
+		return nil, err
 	}
 	return policy, nil
 }
 
+// Exists returns true if the policy exists. Unlike Get, it doesn't transfer or decode the policy.
+func (m *RedisManager) Exists(id string) (exists bool, err error) {
+	err = m.retry(func() error {
+		n, err := m.db.Exists(m.key(prefixPolicy, id)).Result()
+		exists = n > 0
+		return err
+	})
+	return exists, err
+}
+
 // Delete removes a policy.
 func (m *RedisManager) Delete(id string) error {
-	key := prefixKey(m.keyPrefix, prefixPolicy, id)
+	key := m.key(prefixPolicy, id)
 	getCmd := m.db.Get(key)
-	if err := getCmd.Err(); err != nil {
+	if err := getCmd.Err(); err == redis.Nil {
 		return ErrNotFound
+	} else if err != nil {
+		return err
 	}
-	policy := &DefaultPolicy{}
 	res, err := getCmd.Result()
 	if err != nil {
 		return err
 	}
-
-	if err := json.Unmarshal([]byte(res), policy); err != nil {
-		return errors.Wrap(ErrBadConversion, err.Error())
+	policy, err := m.decodePolicy(id, res)
+	if err != nil {
+		return err
 	}
 
 	if err := m.db.Del(key).Err(); err != nil {
 		return err
 	}
+	if err := m.recordVersion(m.db, id, nil); err != nil {
+		return err
+	}
 
 	// Put this policy in the hashmap for each resource
-	for _, v := range policy.GetResources() {
-		hmkey := prefixKey(m.keyPrefix, prefixResource, v)
+	for _, v := range m.indexed(prefixResource, policy.GetResources()) {
+		hmkey := m.indexKey(prefixResource, v)
 		field := policy.GetID()
-		if err := m.db.HDel(hmkey, field).Err(); err != nil {
+		if err := m.indexDel(m.db, hmkey, field).Err(); err != nil {
 			return err
 		}
 	}
 
 	// Put this policy in the hashmap for each subject
-	for _, v := range policy.GetSubjects() {
-		hmkey := prefixKey(m.keyPrefix, prefixSubject, v)
+	for _, hmkey := range m.subjectKeys(policy) {
 		field := policy.GetID()
-		if err := m.db.HDel(hmkey, field).Err(); err != nil {
+		if err := m.indexDel(m.db, hmkey, field).Err(); err != nil {
 			return err
 		}
 	}
@@ -195,133 +482,459 @@ func (m *RedisManager) Delete(id string) error {
 	return nil
 }
 
-// FindPoliciesForResource returns policies that could match the resource. It either returns
-// a set of policies that apply to the resource, or a superset of it.
+// FindPoliciesForResource returns policies that could match the resource, sorted by ID. It either
+// returns a set of policies that apply to the resource, or a superset of it.
 // If an error occurs, it returns nil and the error.
-func (m *RedisManager) FindPoliciesForResource(resource string) (Policies, error) {
-	policies := Policies{}
-
-	var (
-		rKey    = prefixKey(m.keyPrefix, prefixResource, resource)
-		rGetCmd = m.db.HGetAll(rKey)
-	)
-	if err := rGetCmd.Err(); err != nil {
-		return nil, err
+func (m *RedisManager) FindPoliciesForResource(resource string) (policies Policies, err error) {
+	if m.noResourceIndex {
+		return nil, ErrIndexDisabled
 	}
+	err = m.retry(func() error {
+		policies, err = m.findPoliciesForResource(resource)
+		return err
+	})
+	return policies, err
+}
 
-	rPolicies, err := rGetCmd.Result()
+// findPoliciesForResource implements FindPoliciesForResource without retries.
+func (m *RedisManager) findPoliciesForResource(resource string) (Policies, error) {
+	policies := Policies{}
+
+	_, resolved, err := m.snapshot(m.lookupKeys(prefixResource, resource))
 	if err != nil {
 		return nil, err
 	}
 
-	for _, v := range rPolicies {
-		p := &DefaultPolicy{}
-		b := []byte(v)
-		if err := json.Unmarshal(b, p); err != nil {
-			return nil, errors.Wrap(ErrBadConversion, err.Error())
-		}
+	for _, p := range resolved {
 		policies = append(policies, p)
 	}
 
+	sortByID(policies)
 	return policies, nil
 }
 
-// FindPoliciesForSubject returns policies that could match the subject. It either returns
+// FindPoliciesForSubject returns policies that could match the subject, sorted by ID. It either returns
 // a set of policies that applies to the subject, or a superset of it.
 // If an error occurs, it returns nil and the error.
-func (m *RedisManager) FindPoliciesForSubject(subject string) (Policies, error) {
-	policies := Policies{}
-
-	var (
-		sKey    = prefixKey(m.keyPrefix, prefixSubject, subject)
-		sGetCmd = m.db.HGetAll(sKey)
-	)
-	if err := sGetCmd.Err(); err != nil {
-		return nil, err
+func (m *RedisManager) FindPoliciesForSubject(subject string) (policies Policies, err error) {
+	if m.noSubjectIndex {
+		return nil, ErrIndexDisabled
 	}
+	err = m.retry(func() error {
+		policies, err = m.findPoliciesForSubject(subject)
+		return err
+	})
+	return policies, err
+}
 
-	sPolicies, err := sGetCmd.Result()
+// findPoliciesForSubject implements FindPoliciesForSubject without retries.
+func (m *RedisManager) findPoliciesForSubject(subject string) (Policies, error) {
+	policies := Policies{}
+
+	_, resolved, err := m.snapshot(m.lookupKeys(prefixSubject, subject))
 	if err != nil {
 		return nil, err
 	}
 
-	for _, v := range sPolicies {
-		p := &DefaultPolicy{}
-		b := []byte(v)
-		if err := json.Unmarshal(b, p); err != nil {
-			return nil, errors.Wrap(ErrBadConversion, err.Error())
-		}
+	for _, p := range resolved {
 		policies = append(policies, p)
 	}
 
+	sortByID(policies)
 	return policies, nil
 }
 
-// FindRequestCandidates returns candidates that could match the request object. It either returns
-// a set that exactly matches the request, or a superset of it. If an error occurs, it returns nil and
-// the error.
-func (m *RedisManager) FindRequestCandidates(r *Request) (Policies, error) {
-	policies := Policies{}
+// FindRequestCandidates returns candidates that could match the request object, sorted by ID. It either
+// returns a set that exactly matches the request, or a superset of it. If an error occurs, it returns nil
+// and the error.
+//
+// Policies which constrain both subjects and resources are only returned if they are indexed for the
+// request's subject and resource. Policies whose subjects or resources are empty or contain a regular
+// expression are returned if they are indexed for the other dimension, as are policies whose subjects or
+// resources contain a pattern character of WithPatternCharacters, like the `*` of a glob.
+//
+// The indexes and policies are read by a single script, so a concurrent ReplaceAll is observed either
+// completely or not at all.
+//
+// Actions are not indexed, so candidates are returned regardless of the request's action and a wildcard
+// action like `*` or `<.*>` is matched by the warden's matcher alone.
+//
+// Candidates need both indexes, so FindRequestCandidates returns ErrIndexDisabled if either of them is
+// disabled.
+func (m *RedisManager) FindRequestCandidates(r *Request) (policies Policies, err error) {
+	if m.noSubjectIndex || m.noResourceIndex {
+		return nil, ErrIndexDisabled
+	}
+	err = m.retry(func() error {
+		policies, err = m.findRequestCandidates(r)
+		return err
+	})
+	return policies, err
+}
+
+// findRequestCandidates implements FindRequestCandidates without retries.
+func (m *RedisManager) findRequestCandidates(r *Request) (Policies, error) {
 	var (
-		rKey    = prefixKey(m.keyPrefix, prefixResource, r.Resource)
-		sKey    = prefixKey(m.keyPrefix, prefixSubject, r.Subject)
-		rGetCmd = m.db.HGetAll(rKey)
-		sGetCmd = m.db.HGetAll(sKey)
+		sKeys = m.lookupKeys(prefixSubject, r.Subject)
+		rKeys = m.lookupKeys(prefixResource, r.Resource)
 	)
-	if err := rGetCmd.Err(); err != nil {
+	indexes, resolved, err := m.snapshot(append(sKeys, rKeys...))
+	if err != nil {
 		return nil, err
 	}
-	if err := sGetCmd.Err(); err != nil {
-		return nil, err
+	return m.candidates(resolved, mergedIndex(indexes, sKeys), mergedIndex(indexes, rKeys)), nil
+}
+
+// candidates returns the resolved policies which are candidates for a request whose subject and resource
+// hashmaps are sPolicies and rPolicies, sorted by ID.
+func (m *RedisManager) candidates(resolved map[string]*DefaultPolicy, sPolicies, rPolicies map[string]string) Policies {
+	policies := Policies{}
+	for id := range sPolicies {
+		if p, ok := resolved[id]; ok {
+			if _, inR := rPolicies[id]; inR || !m.constrains(p, p.GetResources()) {
+				policies = append(policies, p)
+			}
+		}
 	}
+	for id := range rPolicies {
+		if p, ok := resolved[id]; ok {
+			if _, inS := sPolicies[id]; !inS && !m.constrains(p, p.GetSubjects()) {
+				policies = append(policies, p)
+			}
+		}
+	}
+
+	sortByID(policies)
+	return policies
+}
 
-	rPolicies, err := rGetCmd.Result()
+// sortByID sorts policies by ID, so the order of returned policies doesn't depend on Redis' hashmap
+// iteration order.
+func sortByID(policies Policies) {
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].GetID() < policies[j].GetID()
+	})
+}
+
+// constrains returns false if values could match anything the index doesn't know about, which is the
+// case if they are empty or contain a regular expression or, with WithPatternCharacters, a pattern
+// character.
+func (m *RedisManager) constrains(p Policy, values []string) bool {
+	if len(values) == 0 {
+		return false
+	}
+	for _, v := range values {
+		if strings.IndexByte(v, p.GetStartDelimiter()) >= 0 {
+			return false
+		}
+		if m.patternCharacters != "" && strings.ContainsAny(v, m.patternCharacters) {
+			return false
+		}
+	}
+	return true
+}
+
+// indexed returns the values a policy is indexed under in the subject or resource hashmaps, or nothing
+// if that index is disabled. With WithEmptyMatchesAll, policies without values are indexed under the
+// empty value.
+func (m *RedisManager) indexed(prefix string, values []string) []string {
+	if (prefix == prefixSubject && m.noSubjectIndex) || (prefix == prefixResource && m.noResourceIndex) {
+		return nil
+	}
+	if m.emptyMatchesAll && len(values) == 0 {
+		return []string{""}
+	}
+	return values
+}
+
+// lookupKeys returns the keys of the subject or resource hashmaps to read for value.
+func (m *RedisManager) lookupKeys(prefix string, value string) []string {
+	keys := []string{m.indexKey(prefix, value)}
+	if m.emptyMatchesAll && value != "" {
+		keys = append(keys, m.indexKey(prefix, ""))
+	}
+	if prefix == prefixSubject && m.subjectPrefixIndex {
+		keys = append(keys, m.prefixKeys(value)...)
+	}
+	return keys
+}
+
+// snapshotScript reads the indexes at KEYS, SMEMBERS if ARGV[1] is 1 and HGETALL otherwise. If ARGV[2]
+// is set, it is the prefix of the policy keys, and the policies listed in the indexes are loaded as well:
+// their IDs and their values are appended to the reply. Scripts run atomically, so the reply never mixes
+// indexes and policies from before and after a concurrent write.
+var snapshotScript = redis.NewScript(`
+local reply = {}
+for i, key in ipairs(KEYS) do
+	if ARGV[1] == '1' then
+		reply[i] = redis.call('SMEMBERS', key)
+	else
+		reply[i] = redis.call('HGETALL', key)
+	end
+end
+if ARGV[2] == '' then
+	return reply
+end
+
+local step = 2
+if ARGV[1] == '1' then
+	step = 1
+end
+local ids, seen, values = {}, {}, {}
+for i = 1, #KEYS do
+	for j = 1, #reply[i], step do
+		local id = reply[i][j]
+		if not seen[id] then
+			seen[id] = true
+			ids[#ids + 1] = id
+		end
+	end
+end
+for i = 1, #ids, 1000 do
+	local keys = {}
+	for j = i, math.min(i + 999, #ids) do
+		keys[#keys + 1] = ARGV[2] .. ids[j]
+	end
+	for _, v in ipairs(redis.call('MGET', unpack(keys))) do
+		values[#values + 1] = v
+	end
+end
+reply[#KEYS + 1] = ids
+reply[#KEYS + 2] = values
+return reply
+`)
+
+// snapshot reads the indexes at keys and resolves the policies listed in them in a single script, so a
+// concurrent write, like the swap of ReplaceAll, is observed either completely or not at all. It returns
+// the indexes by key.
+func (m *RedisManager) snapshot(keys []string) (map[string]map[string]string, map[string]*DefaultPolicy, error) {
+	if len(keys) == 0 {
+		return map[string]map[string]string{}, map[string]*DefaultPolicy{}, nil
+	}
+
+	setIndex, policyPrefix := "0", ""
+	if m.setIndex {
+		setIndex = "1"
+	}
+	if m.idOnlyIndex {
+		policyPrefix = m.key(prefixPolicy, "")
+	}
+
+	res, err := snapshotScript.Run(m.db, keys, setIndex, policyPrefix).Result()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	reply, ok := res.([]interface{})
+	if !ok || len(reply) < len(keys) {
+		return nil, nil, errors.Errorf("unexpected reply %v", res)
+	}
+
+	indexes := make(map[string]map[string]string, len(keys))
+	all := make([]map[string]string, 0, len(keys))
+	for i, key := range keys {
+		values, err := toStrings(reply[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		index := map[string]string{}
+		if m.setIndex {
+			for _, id := range values {
+				index[id] = ""
+			}
+		} else {
+			for j := 0; j+1 < len(values); j += 2 {
+				index[values[j]] = values[j+1]
+			}
+		}
+		indexes[key] = index
+		all = append(all, index)
+	}
+
+	if !m.idOnlyIndex {
+		resolved, err := m.resolve(all...)
+		return indexes, resolved, err
+	}
+
+	if len(reply) != len(keys)+2 {
+		return nil, nil, errors.Errorf("unexpected reply %v", res)
 	}
-	sPolicies, err := sGetCmd.Result()
+	ids, err := toStrings(reply[len(keys)])
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	values, ok := reply[len(keys)+1].([]interface{})
+	if !ok || len(values) != len(ids) {
+		return nil, nil, errors.Errorf("unexpected reply %v", reply[len(keys)+1])
+	}
+	resolved, err := m.decodeAll(ids, values)
+	return indexes, resolved, err
+}
+
+// checkSize returns ErrPolicyTooLarge if the encoded policy p exceeds the manager's limit.
+func (m *RedisManager) checkSize(id string, p []byte) error {
+	if m.maxPolicySize > 0 && len(p) > m.maxPolicySize {
+		return &PolicyError{ID: id, Err: errors.Wrapf(ErrPolicyTooLarge, "%d bytes exceed the limit of %d bytes", len(p), m.maxPolicySize)}
+	}
+	return nil
+}
+
+// indexSet adds the policy with the given ID to the index at key, storing value unless the manager uses
+// WithSetIndex.
+func (m *RedisManager) indexSet(c redis.Cmdable, key, id string, value interface{}) redis.Cmder {
+	if m.setIndex {
+		return c.SAdd(key, id)
 	}
+	return c.HSet(key, id, value)
+}
 
-	for _, v := range rPolicies {
-		p := &DefaultPolicy{}
-		b := []byte(v)
-		// if !ok {
-		// 	return nil, errors.Wrapf(ErrBadConversion, "value %+v is not a byte array", v)
-		// }
-		if err := json.Unmarshal(b, p); err != nil {
-			return nil, errors.Wrap(ErrBadConversion, err.Error())
+// indexDel removes the policies with the given IDs from the index at key.
+func (m *RedisManager) indexDel(c redis.Cmdable, key string, ids ...string) redis.Cmder {
+	if m.setIndex {
+		members := make([]interface{}, len(ids))
+		for i, id := range ids {
+			members[i] = id
 		}
-		policies = append(policies, p)
+		return c.SRem(key, members...)
+	}
+	return c.HDel(key, ids...)
+}
+
+// indexGet reads the index at key. It returns a function returning the index as a map of policy IDs to
+// their stored values, so it can be used in pipelines, whose results are only available after Exec.
+// With WithSetIndex all values are empty.
+func (m *RedisManager) indexGet(c redis.Cmdable, key string) func() (map[string]string, error) {
+	if !m.setIndex {
+		return c.HGetAll(key).Result
 	}
 
-	for _, v := range sPolicies {
-		p := &DefaultPolicy{}
-		b := []byte(v)
-		// if !ok {
-		// 	return nil, errors.Wrapf(ErrBadConversion, "value %+v is not a byte array", v)
-		// }
-		if err := json.Unmarshal(b, p); err != nil {
-			return nil, errors.Wrap(ErrBadConversion, err.Error())
+	cmd := c.SMembers(key)
+	return func() (map[string]string, error) {
+		ids, err := cmd.Result()
+		if err != nil {
+			return nil, err
 		}
-		policies = append(policies, p)
+		index := make(map[string]string, len(ids))
+		for _, id := range ids {
+			index[id] = ""
+		}
+		return index, nil
+	}
+}
+
+// indexValue returns the value stored for a policy in the subject and resource hashmaps.
+func (m *RedisManager) indexValue(p []byte) interface{} {
+	if m.idOnlyIndex {
+		return ""
+	}
+	return p
+}
+
+// resolve returns the policies listed in the given subject or resource hashmaps by ID. If the indexes
+// only contain IDs, the policies are loaded from their policy keys with a single MGET.
+func (m *RedisManager) resolve(indexes ...map[string]string) (map[string]*DefaultPolicy, error) {
+	policies := map[string]*DefaultPolicy{}
+	if !m.idOnlyIndex {
+		for _, index := range indexes {
+			for id, v := range index {
+				if _, ok := policies[id]; ok {
+					continue
+				}
+				p, err := m.decodePolicy(id, v)
+				if err != nil {
+					return nil, err
+				}
+				policies[id] = p
+			}
+		}
+		return policies, nil
 	}
 
+	var (
+		ids  []string
+		keys []string
+		seen = map[string]bool{}
+	)
+	for _, index := range indexes {
+		for id := range index {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+				keys = append(keys, m.key(prefixPolicy, id))
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return policies, nil
+	}
+
+	values, err := m.db.MGet(keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	return m.decodeAll(ids, values)
+}
+
+// decodeAll decodes the policies with the given IDs from the values MGET returned for their policy keys.
+func (m *RedisManager) decodeAll(ids []string, values []interface{}) (map[string]*DefaultPolicy, error) {
+	policies := map[string]*DefaultPolicy{}
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			// The policy was deleted after its index was read.
+			continue
+		}
+		p, err := m.decodePolicy(ids[i], s)
+		if err != nil {
+			return nil, err
+		}
+		policies[ids[i]] = p
+	}
 	return policies, nil
 }
 
+// decodePolicy unmarshals the policy with the given ID stored in Redis.
+func (m *RedisManager) decodePolicy(id string, v string) (*DefaultPolicy, error) {
+	unmarshal := condition.UnmarshalPolicy
+	if m.lenientConditions {
+		unmarshal = condition.UnmarshalPolicyLenient
+	}
+
+	p := &DefaultPolicy{}
+	if err := unmarshal([]byte(v), p); err != nil {
+		return nil, &PolicyError{ID: id, Err: errors.Wrap(ErrBadConversion, err.Error())}
+	}
+	return p, nil
+}
+
+// policyID returns the ID of the policy stored at key.
+func (m *RedisManager) policyID(key string) string {
+	return strings.TrimPrefix(key, m.key(prefixPolicy, ""))
+}
+
+// Update replaces an existing policy. Like Create, it removes duplicate subjects, resources and actions.
 func (m *RedisManager) Update(policy Policy) error {
+	policy = manager.Normalize(policy)
+
 	// Make sure that the key doesn't already exist
-	key := prefixKey(m.keyPrefix, prefixPolicy, policy.GetID())
-	if err := m.db.Get(key).Err(); err != nil {
+	key := m.key(prefixPolicy, policy.GetID())
+	v, err := m.db.Get(key).Result()
+	if err == redis.Nil {
 		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	ts, err := decodeTimestamps(policy.GetID(), v)
+	if err != nil {
+		return err
 	}
 
-	p, err := json.Marshal(policy)
+	p, err := m.encode(policy, ts.CreatedAt)
 	if err != nil {
 		return err
 	}
+	if err := m.checkSize(policy.GetID(), p); err != nil {
+		return err
+	}
 
 	// Set the policy key
 	cmd := m.db.Set(key, p, 0)
@@ -329,28 +942,165 @@ func (m *RedisManager) Update(policy Policy) error {
 	if err := cmd.Err(); err != nil {
 		return err
 	}
+	if err := m.recordVersion(m.db, policy.GetID(), p); err != nil {
+		return err
+	}
 
 	// Put this policy in the hashmap for each resource
-	for _, v := range policy.GetResources() {
-		hmkey := prefixKey(m.keyPrefix, prefixResource, v)
+	for _, v := range m.indexed(prefixResource, policy.GetResources()) {
+		hmkey := m.indexKey(prefixResource, v)
 		field := policy.GetID()
-		if err := m.db.HMSet(hmkey, map[string]interface{}{
-			field: p,
-		}).Err(); err != nil {
+		if err := m.indexSet(m.db, hmkey, field, m.indexValue(p)).Err(); err != nil {
 			return err
 		}
 	}
 
 	// Put this policy in the hashmap for each subject
-	for _, v := range policy.GetSubjects() {
-		hmkey := prefixKey(m.keyPrefix, prefixSubject, v)
+	for _, hmkey := range m.subjectKeys(policy) {
 		field := policy.GetID()
-		if err := m.db.HMSet(hmkey, map[string]interface{}{
-			field: p,
-		}).Err(); err != nil {
+		if err := m.indexSet(m.db, hmkey, field, m.indexValue(p)).Err(); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+// ReplaceAll atomically swaps the entire policy set for the given policies. The new set is first written
+// to a temporary key namespace and then renamed into place in a single MULTI/EXEC transaction, so
+// concurrent readers either see the complete old or the complete new set.
+func (m *RedisManager) ReplaceAll(policies Policies) error {
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return errors.WithStack(err)
+	}
+
+	tmp := m.namespaced(prefixKey(prefixReplace, hex.EncodeToString(token)))
+	// The history is recorded below the manager's own key prefix once the new set is in place.
+	tmp.history = false
+	cleanup := func() {
+		if keys, err := tmp.keys(); err == nil && len(keys) > 0 {
+			m.db.Del(keys...)
+		}
+	}
+
+	for _, policy := range policies {
+		if err := tmp.Create(policy); err != nil {
+			cleanup()
+			return err
+		}
+	}
+
+	tmpKeys, err := tmp.keys()
+	if err != nil {
+		cleanup()
+		return err
+	}
+
+	oldKeys, err := m.keys()
+	if err != nil {
+		cleanup()
+		return err
+	}
+
+	versions, err := m.replacementVersions(tmp, policies)
+	if err != nil {
+		cleanup()
+		return err
+	}
+
+	if _, err := m.db.TxPipelined(func(pipe redis.Pipeliner) error {
+		if len(oldKeys) > 0 {
+			pipe.Del(oldKeys...)
+		}
+		for _, key := range tmpKeys {
+			pipe.Rename(key, m.keyPrefix+strings.TrimPrefix(key, tmp.keyPrefix))
+		}
+		return m.recordReplacement(pipe, oldKeys, versions)
+	}); err != nil {
+		cleanup()
+		return err
+	}
+	return nil
+}
+
+// keys returns all policy and index keys owned by this manager.
+func (m *RedisManager) keys() ([]string, error) {
+	keys := []string{}
+	for _, prefix := range []string{prefixPolicy, prefixResource, prefixSubject, prefixSubjectPrefix} {
+		res, err := m.keysMatching(m.key(prefix, "*"))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, res...)
+	}
+	return keys, nil
+}
+
+// keysScript and scanScript run KEYS and SCAN on the node executing them. They are used on a Redis
+// Cluster, where both commands would otherwise go to a random node: running them as a script keyed by
+// the manager's hash tag executes them on the node owning all of the manager's keys.
+var (
+	keysScript = redis.NewScript(`return redis.call('KEYS', ARGV[1])`)
+	scanScript = redis.NewScript(`return redis.call('SCAN', ARGV[1], 'MATCH', ARGV[2], 'COUNT', ARGV[3])`)
+)
+
+// keysMatching returns all keys matching pattern.
+func (m *RedisManager) keysMatching(pattern string) ([]string, error) {
+	if _, ok := m.db.(*redis.ClusterClient); !ok {
+		return m.db.Keys(pattern).Result()
+	}
+
+	res, err := keysScript.Run(m.db, []string{m.keyPrefix}, pattern).Result()
+	if err != nil {
+		return nil, err
+	}
+	return toStrings(res)
+}
+
+// scan runs a single SCAN iteration for pattern.
+func (m *RedisManager) scan(cursor uint64, pattern string, count int64) ([]string, uint64, error) {
+	if _, ok := m.db.(*redis.ClusterClient); !ok {
+		return m.db.Scan(cursor, pattern, count).Result()
+	}
+
+	res, err := scanScript.Run(m.db, []string{m.keyPrefix}, cursor, pattern, count).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	values, ok := res.([]interface{})
+	if !ok || len(values) != 2 {
+		return nil, 0, errors.Errorf("unexpected SCAN reply %v", res)
+	}
+	c, ok := values[0].(string)
+	if !ok {
+		return nil, 0, errors.Errorf("unexpected SCAN cursor %v", values[0])
+	}
+	next, err := strconv.ParseUint(c, 10, 64)
+	if err != nil {
+		return nil, 0, errors.WithStack(err)
+	}
+	keys, err := toStrings(values[1])
+	if err != nil {
+		return nil, 0, err
+	}
+	return keys, next, nil
+}
+
+func toStrings(v interface{}) ([]string, error) {
+	values, ok := v.([]interface{})
+	if !ok {
+		return nil, errors.Errorf("unexpected reply %v", v)
+	}
+
+	res := make([]string, len(values))
+	for i, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			return nil, errors.Errorf("unexpected reply %v", value)
+		}
+		res[i] = s
+	}
+	return res, nil
+}
@@ -0,0 +1,62 @@
+package redis
+
+import (
+	. "github.com/ory/ladon"
+)
+
+// FindRequestCandidatesBatch returns the candidates of each request, in the order of the requests, as
+// FindRequestCandidates would. The subject and resource hashmaps of all requests and, with
+// WithIDOnlyIndex, their policies are read by a single script, so the whole batch costs a single round
+// trip.
+func (m *RedisManager) FindRequestCandidatesBatch(reqs []*Request) (candidates []Policies, err error) {
+	if m.noSubjectIndex || m.noResourceIndex {
+		return nil, ErrIndexDisabled
+	}
+
+	err = m.retry(func() error {
+		candidates, err = m.findRequestCandidatesBatch(reqs)
+		return err
+	})
+	return candidates, err
+}
+
+// findRequestCandidatesBatch implements FindRequestCandidatesBatch without retries.
+func (m *RedisManager) findRequestCandidatesBatch(reqs []*Request) ([]Policies, error) {
+	var (
+		keys []string
+		seen = map[string]bool{}
+	)
+	for _, r := range reqs {
+		for _, key := range append(m.lookupKeys(prefixSubject, r.Subject), m.lookupKeys(prefixResource, r.Resource)...) {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	indexes, resolved, err := m.snapshot(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Policies, len(reqs))
+	for i, r := range reqs {
+		result[i] = m.candidates(resolved, mergedIndex(indexes, m.lookupKeys(prefixSubject, r.Subject)), mergedIndex(indexes, m.lookupKeys(prefixResource, r.Resource)))
+	}
+	return result, nil
+}
+
+// mergedIndex returns the index at keys[0], merged with the indexes at the other keys.
+func mergedIndex(indexes map[string]map[string]string, keys []string) map[string]string {
+	if len(keys) == 1 {
+		return indexes[keys[0]]
+	}
+	merged := map[string]string{}
+	for _, key := range keys {
+		for id, v := range indexes[key] {
+			merged[id] = v
+		}
+	}
+	return merged
+}
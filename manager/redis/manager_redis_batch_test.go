@@ -0,0 +1,125 @@
+package redis
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-redis/redis"
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+)
+
+// countingClient counts the round trips of the commands used to find candidates.
+type countingClient struct {
+	redis.UniversalClient
+	roundTrips int
+}
+
+func (c *countingClient) HGetAll(key string) *redis.StringStringMapCmd {
+	c.roundTrips++
+	return c.UniversalClient.HGetAll(key)
+}
+
+func (c *countingClient) MGet(keys ...string) *redis.SliceCmd {
+	c.roundTrips++
+	return c.UniversalClient.MGet(keys...)
+}
+
+func (c *countingClient) Pipelined(fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	c.roundTrips++
+	return c.UniversalClient.Pipelined(fn)
+}
+
+var batchPolicies = Policies{
+	&DefaultPolicy{ID: "test-policy-1", Subjects: []string{"ex1", "ex2"}, Resources: []string{"exr1", "exr2"}, Conditions: Conditions{}},
+	&DefaultPolicy{ID: "test-policy-2", Subjects: []string{"ex1", "ex2"}, Conditions: Conditions{}},
+	&DefaultPolicy{ID: "test-policy-3", Subjects: []string{"ex3", "ex4"}, Conditions: Conditions{}},
+	&DefaultPolicy{ID: "test-policy-4", Subjects: []string{"ex1"}, Resources: []string{"exr3"}, Conditions: Conditions{}},
+	&DefaultPolicy{ID: "test-policy-5", Resources: []string{"exr1"}, Conditions: Conditions{}},
+	&DefaultPolicy{ID: "test-policy-6", Subjects: []string{"<.*>"}, Resources: []string{"exr1"}, Conditions: Conditions{}},
+}
+
+var batchRequests = []*Request{
+	{Subject: "ex1", Resource: "exr1"},
+	{Subject: "ex2", Resource: "exr2"},
+	{Subject: "ex1", Resource: "exr3"},
+	{Subject: "ex3", Resource: "exr1"},
+	{Subject: "ex1", Resource: "exr1"},
+	{Subject: "unknown", Resource: "unknown"},
+}
+
+func TestFindRequestCandidatesBatch(t *testing.T) {
+	for k, opts := range [][]Option{nil, {WithIDOnlyIndex()}} {
+		client := &countingClient{UniversalClient: db}
+		m := NewRedisManager(client, fmt.Sprintf("batch%d", k), opts...)
+		for _, p := range batchPolicies {
+			if err := m.Create(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var expected []Policies
+		for _, r := range batchRequests {
+			p, err := m.FindRequestCandidates(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected = append(expected, p)
+		}
+
+		client.roundTrips = 0
+		actual, err := m.FindRequestCandidatesBatch(batchRequests)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(expected, actual) {
+			t.Errorf("Case %d: batch differs from single requests\n%s", k, cmp.Diff(expected, actual))
+		}
+		if max := 1 + len(opts); client.roundTrips > max {
+			t.Errorf("Case %d: expected at most %d round trips, got %d", k, max, client.roundTrips)
+		}
+	}
+
+	t.Run("Empty batch", func(t *testing.T) {
+		p, err := NewRedisManager(db, "batch0").FindRequestCandidatesBatch(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(p) != 0 {
+			t.Fatalf("Expected no candidates, got %v", p)
+		}
+	})
+}
+
+func BenchmarkFindRequestCandidates(b *testing.B) {
+	client, m := newBatchBenchmarkManager(b)
+	for i := 0; i < b.N; i++ {
+		for _, r := range batchRequests {
+			if _, err := m.FindRequestCandidates(r); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.ReportMetric(float64(client.roundTrips)/float64(b.N), "roundtrips/op")
+}
+
+func BenchmarkFindRequestCandidatesBatch(b *testing.B) {
+	client, m := newBatchBenchmarkManager(b)
+	for i := 0; i < b.N; i++ {
+		if _, err := m.FindRequestCandidatesBatch(batchRequests); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(client.roundTrips)/float64(b.N), "roundtrips/op")
+}
+
+func newBatchBenchmarkManager(b *testing.B) (*countingClient, *RedisManager) {
+	client := &countingClient{UniversalClient: db}
+	m := NewRedisManager(client, "batchBenchmark")
+	if err := m.ReplaceAll(batchPolicies); err != nil {
+		b.Fatal(err)
+	}
+	client.roundTrips = 0
+	b.ResetTimer()
+	return client, m
+}
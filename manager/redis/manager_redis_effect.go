@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"github.com/go-redis/redis"
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// setEffectAttempts bounds how often SetEffect retries when the policy is modified concurrently.
+const setEffectAttempts = 10
+
+// SetEffect changes only the effect of a policy, e.g. to flip an allow policy to deny during an incident,
+// and bumps its UpdatedAt. Unlike Get followed by Update, no concurrent change of the policy is lost: the
+// policy is read and written atomically using WATCH and MULTI/EXEC, and the transaction is retried if the
+// policy changes in between. With WithHistory the new version is appended to the history in the same
+// transaction. effect must be ladon.AllowAccess or ladon.DenyAccess.
+func (m *RedisManager) SetEffect(id string, effect string) error {
+	if effect != AllowAccess && effect != DenyAccess {
+		return errors.Errorf("invalid effect %q, expected %q or %q", effect, AllowAccess, DenyAccess)
+	}
+
+	key := m.key(prefixPolicy, id)
+	var err error
+	for attempt := 0; attempt < setEffectAttempts; attempt++ {
+		if err = m.db.Watch(func(tx *redis.Tx) error {
+			return m.setEffect(tx, key, id, effect)
+		}, key); err != redis.TxFailedErr {
+			return err
+		}
+	}
+	return ErrConflict
+}
+
+func (m *RedisManager) setEffect(tx *redis.Tx, key, id, effect string) error {
+	v, err := tx.Get(key).Result()
+	if err == redis.Nil {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+
+	policy, err := m.decodePolicy(id, v)
+	if err != nil {
+		return err
+	}
+	ts, err := decodeTimestamps(id, v)
+	if err != nil {
+		return err
+	}
+	policy.Effect = effect
+	p, err := m.encode(policy, ts.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+		pipe.Set(key, p, 0)
+		if err := m.recordVersion(pipe, id, p); err != nil {
+			return err
+		}
+		if m.idOnlyIndex {
+			return nil
+		}
+		for _, r := range m.indexed(prefixResource, policy.GetResources()) {
+			m.indexSet(pipe, m.indexKey(prefixResource, r), id, m.indexValue(p))
+		}
+		for _, key := range m.subjectKeys(policy) {
+			m.indexSet(pipe, key, id, m.indexValue(p))
+		}
+		return nil
+	})
+	return err
+}
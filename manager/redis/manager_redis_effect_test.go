@@ -0,0 +1,77 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/ory/ladon"
+)
+
+func TestSetEffect(t *testing.T) {
+	for k, c := range []struct {
+		prefix string
+		opts   []Option
+	}{
+		{prefix: "effect"},
+		{prefix: "effect_id_only", opts: []Option{WithIDOnlyIndex()}},
+		{prefix: "effect_set", opts: []Option{WithSetIndex()}},
+	} {
+		now := time.Date(2018, 1, 1, 12, 0, 0, 0, time.UTC)
+		m := NewRedisManager(db, c.prefix, c.opts...)
+		m.clock = func() time.Time { return now }
+		w := &Ladon{Manager: m}
+		policy := &DefaultPolicy{
+			ID:         "test-policy-1",
+			Subjects:   []string{"user:alice"},
+			Resources:  []string{"articles:1"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		}
+		if err := m.Create(policy); err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+
+		r := &Request{Subject: "user:alice", Resource: "articles:1", Action: "get"}
+		if err := w.IsAllowed(r); err != nil {
+			t.Fatalf("Case %d: expected the request to be allowed, got %v", k, err)
+		}
+
+		now = now.Add(time.Minute)
+		if err := m.SetEffect(policy.ID, DenyAccess); err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+		if err := w.IsAllowed(r); err == nil {
+			t.Fatalf("Case %d: expected the request to be denied", k)
+		}
+
+		p, err := m.Get(policy.ID)
+		if err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+		if p.GetEffect() != DenyAccess || p.GetDescription() != policy.Description || len(p.GetSubjects()) != 1 {
+			t.Fatalf("Case %d: expected only the effect to change, got %+v", k, p)
+		}
+		ts, err := m.GetTimestamps(policy.ID)
+		if err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+		if !ts.UpdatedAt.Equal(now) || !ts.CreatedAt.Equal(now.Add(-time.Minute)) {
+			t.Fatalf("Case %d: expected UpdatedAt to be bumped, got %+v", k, ts)
+		}
+
+		if err := m.SetEffect(policy.ID, AllowAccess); err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+		if err := w.IsAllowed(r); err != nil {
+			t.Fatalf("Case %d: expected the request to be allowed again, got %v", k, err)
+		}
+
+		if err := m.SetEffect("does-not-exist", DenyAccess); err != ErrNotFound {
+			t.Fatalf("Case %d: expected %v, got %v", k, ErrNotFound, err)
+		}
+		if err := m.SetEffect(policy.ID, "maybe"); err == nil {
+			t.Fatalf("Case %d: expected an invalid effect to be rejected", k)
+		}
+	}
+}
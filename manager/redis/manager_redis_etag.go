@@ -0,0 +1,100 @@
+package redis
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+
+	"github.com/go-redis/redis"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/manager"
+	"github.com/pkg/errors"
+)
+
+// ErrConflict is returned by UpdateIfMatch if the policy was changed since its ETag was read.
+var ErrConflict = errors.New("Policy was modified concurrently")
+
+// etag returns the ETag of a stored policy.
+func etag(stored string) string {
+	sum := md5.Sum([]byte(stored))
+	return hex.EncodeToString(sum[:])
+}
+
+// GetWithETag retrieves a policy together with its ETag, which changes whenever the policy is updated.
+// Pass the ETag to UpdateIfMatch to detect concurrent modifications.
+func (m *RedisManager) GetWithETag(id string) (Policy, string, error) {
+	v, err := m.db.Get(m.key(prefixPolicy, id)).Result()
+	if err == redis.Nil {
+		return nil, "", ErrNotFound
+	} else if err != nil {
+		return nil, "", err
+	}
+
+	p, err := m.decodePolicy(id, v)
+	if err != nil {
+		return nil, "", err
+	}
+	return p, etag(v), nil
+}
+
+// UpdateIfMatch updates a policy if its ETag still equals etag and returns ErrConflict otherwise.
+// ladon's DefaultPolicy has no version field, so the ETag is derived from the stored policy instead.
+//
+// The check and the update run atomically using WATCH and MULTI/EXEC. Unlike Update, UpdateIfMatch also
+// removes the policy from the indexes of subjects and resources it no longer has.
+func (m *RedisManager) UpdateIfMatch(policy Policy, etagValue string) error {
+	policy = manager.Normalize(policy)
+	key := m.key(prefixPolicy, policy.GetID())
+	err := m.db.Watch(func(tx *redis.Tx) error {
+		v, err := tx.Get(key).Result()
+		if err == redis.Nil {
+			return ErrNotFound
+		} else if err != nil {
+			return err
+		}
+		if etag(v) != etagValue {
+			return ErrConflict
+		}
+
+		old, err := m.decodePolicy(policy.GetID(), v)
+		if err != nil {
+			return err
+		}
+		ts, err := decodeTimestamps(policy.GetID(), v)
+		if err != nil {
+			return err
+		}
+		p, err := m.encode(policy, ts.CreatedAt)
+		if err != nil {
+			return err
+		}
+		if err := m.checkSize(policy.GetID(), p); err != nil {
+			return err
+		}
+
+		_, err = tx.Pipelined(func(pipe redis.Pipeliner) error {
+			pipe.Set(key, p, 0)
+			if err := m.recordVersion(pipe, policy.GetID(), p); err != nil {
+				// Returning before EXEC discards the queued commands.
+				return err
+			}
+			for _, r := range m.indexed(prefixResource, old.GetResources()) {
+				m.indexDel(pipe, m.indexKey(prefixResource, r), policy.GetID())
+			}
+			for _, key := range m.subjectKeys(old) {
+				m.indexDel(pipe, key, policy.GetID())
+			}
+			for _, r := range m.indexed(prefixResource, policy.GetResources()) {
+				m.indexSet(pipe, m.indexKey(prefixResource, r), policy.GetID(), m.indexValue(p))
+			}
+			for _, key := range m.subjectKeys(policy) {
+				m.indexSet(pipe, key, policy.GetID(), m.indexValue(p))
+			}
+			return nil
+		})
+		return err
+	}, key)
+	if err == redis.TxFailedErr {
+		return ErrConflict
+	}
+	return err
+}
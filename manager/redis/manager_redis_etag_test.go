@@ -0,0 +1,83 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+)
+
+func TestUpdateIfMatch(t *testing.T) {
+	m := NewRedisManager(db, "etag")
+	policy := &DefaultPolicy{
+		ID:         "test-policy-1",
+		Subjects:   []string{"user:alice"},
+		Resources:  []string{"articles:1"},
+		Actions:    []string{"get"},
+		Effect:     AllowAccess,
+		Conditions: Conditions{},
+	}
+	if err := m.Create(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	_, tag, err := m.GetWithETag(policy.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Update with the current ETag", func(t *testing.T) {
+		updated := *policy
+		updated.Subjects = []string{"user:bob"}
+		if err := m.UpdateIfMatch(&updated, tag); err != nil {
+			t.Fatal(err)
+		}
+
+		p, newTag, err := m.GetWithETag(policy.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(&updated, p) {
+			t.Fatalf("Unexpected policy after update\n%s", cmp.Diff(&updated, p))
+		}
+		if newTag == tag {
+			t.Fatal("Expected the ETag to change")
+		}
+
+		if p, err := m.FindPoliciesForSubject("user:alice"); err != nil {
+			t.Fatal(err)
+		} else if len(p) != 0 {
+			t.Fatalf("Expected the old subject to be removed from the index, got %+v", p)
+		}
+		if p, err := m.FindPoliciesForSubject("user:bob"); err != nil {
+			t.Fatal(err)
+		} else if !contains(p, &updated) {
+			t.Fatalf("Expected the new subject to be indexed, got %+v", p)
+		}
+	})
+
+	t.Run("Reject a stale ETag", func(t *testing.T) {
+		stale := *policy
+		stale.Description = "stale"
+		if err := m.UpdateIfMatch(&stale, tag); err != ErrConflict {
+			t.Fatalf("Expected %v, got %v", ErrConflict, err)
+		}
+
+		p, err := m.Get(policy.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.GetDescription() == "stale" {
+			t.Fatal("Expected the stale update to be discarded")
+		}
+	})
+
+	t.Run("Reject unknown policies", func(t *testing.T) {
+		if err := m.UpdateIfMatch(&DefaultPolicy{ID: "does-not-exist"}, tag); err != ErrNotFound {
+			t.Fatalf("Expected %v, got %v", ErrNotFound, err)
+		}
+		if _, _, err := m.GetWithETag("does-not-exist"); err != ErrNotFound {
+			t.Fatalf("Expected %v, got %v", ErrNotFound, err)
+		}
+	})
+}
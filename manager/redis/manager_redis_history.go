@@ -0,0 +1,138 @@
+package redis
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/manager"
+	"github.com/pkg/errors"
+)
+
+// version is an entry of a policy's history list. Policy is null for deletions.
+type version struct {
+	At     time.Time       `json:"at"`
+	Policy json.RawMessage `json:"policy"`
+}
+
+// recordVersion appends the stored JSON p of the policy id to its history, or a deletion if p is nil. It
+// does nothing unless WithHistory is set.
+func (m *RedisManager) recordVersion(c redis.Cmdable, id string, p []byte) error {
+	if !m.history {
+		return nil
+	}
+
+	v, err := json.Marshal(&version{At: m.now().UTC(), Policy: p})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	return c.RPush(m.key(prefixHistory, id), v).Err()
+}
+
+// GetAllAsOf returns the policies which were stored at the given time, sorted by ID. It requires
+// WithHistory and returns manager.ErrHistoryUnsupported otherwise. Policies changed before the history
+// was enabled are missing from the result until their next change.
+func (m *RedisManager) GetAllAsOf(at time.Time) (Policies, error) {
+	if !m.history {
+		return nil, errors.WithStack(manager.ErrHistoryUnsupported)
+	}
+
+	prefix := m.key(prefixHistory, "")
+	keys, err := m.keysMatching(prefix + "*")
+	if err != nil {
+		return nil, err
+	}
+
+	policies := Policies{}
+	for _, key := range keys {
+		id := strings.TrimPrefix(key, prefix)
+		entries, err := m.db.LRange(key, 0, -1).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		// Versions are appended in the order they were written, so the last one written at or before
+		// the given time is the one which was stored then.
+		var current json.RawMessage
+		for _, e := range entries {
+			var v version
+			if err := json.Unmarshal([]byte(e), &v); err != nil {
+				return nil, &PolicyError{ID: id, Err: errors.Wrap(ErrBadConversion, err.Error())}
+			}
+			if v.At.After(at) {
+				break
+			}
+			current = v.Policy
+		}
+		if len(current) == 0 || string(current) == "null" {
+			continue
+		}
+
+		p, err := m.decodePolicy(id, string(current))
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+
+	sortByID(policies)
+	return policies, nil
+}
+
+// replacementVersions returns the JSON tmp stored for policies by ID when ReplaceAll wrote the new set, so
+// the history records the very bytes which are renamed into place. It returns nil unless WithHistory is
+// set.
+func (m *RedisManager) replacementVersions(tmp *RedisManager, policies Policies) (map[string][]byte, error) {
+	if !m.history || len(policies) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(policies))
+	for i, policy := range policies {
+		keys[i] = tmp.key(prefixPolicy, policy.GetID())
+	}
+	values, err := m.db.MGet(keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string][]byte, len(values))
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.Errorf("policy %s was removed from the new set before it replaced the current one", policies[i].GetID())
+		}
+		versions[policies[i].GetID()] = []byte(s)
+	}
+	return versions, nil
+}
+
+// recordReplacement queues the history of ReplaceAll, which replaces the keys oldKeys with the policies
+// stored as versions, on the MULTI/EXEC pipe doing the replacement: a new version of every policy and a
+// deletion of every policy which isn't part of the new set.
+func (m *RedisManager) recordReplacement(pipe redis.Pipeliner, oldKeys []string, versions map[string][]byte) error {
+	if !m.history {
+		return nil
+	}
+
+	for id, p := range versions {
+		if err := m.recordVersion(pipe, id, p); err != nil {
+			return err
+		}
+	}
+
+	policyPrefix := m.key(prefixPolicy, "")
+	for _, key := range oldKeys {
+		if !strings.HasPrefix(key, policyPrefix) {
+			continue
+		}
+		if id := m.policyID(key); versions[id] == nil {
+			if err := m.recordVersion(pipe, id, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
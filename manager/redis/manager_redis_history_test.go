@@ -0,0 +1,133 @@
+package redis
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/manager"
+	"github.com/pkg/errors"
+)
+
+func TestHistory(t *testing.T) {
+	var (
+		start = time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+		now   = start
+		m     = NewRedisManager(db, "history", WithHistory())
+	)
+	m.clock = func() time.Time { return now }
+
+	allow := &DefaultPolicy{
+		ID:         "articles",
+		Subjects:   []string{"user:alice"},
+		Resources:  []string{"articles:1"},
+		Actions:    []string{"get"},
+		Effect:     AllowAccess,
+		Conditions: Conditions{},
+	}
+	deny := *allow
+	deny.Effect = DenyAccess
+	other := &DefaultPolicy{
+		ID:         "other",
+		Subjects:   []string{"user:bob"},
+		Resources:  []string{"articles:2"},
+		Actions:    []string{"get"},
+		Effect:     AllowAccess,
+		Conditions: Conditions{},
+	}
+
+	if err := m.Create(allow); err != nil {
+		t.Fatal(err)
+	}
+	now = start.Add(time.Hour)
+	if err := m.Update(&deny); err != nil {
+		t.Fatal(err)
+	}
+	now = start.Add(2 * time.Hour)
+	if err := m.Delete(allow.ID); err != nil {
+		t.Fatal(err)
+	}
+	now = start.Add(3 * time.Hour)
+	if err := m.ReplaceAll(Policies{other}); err != nil {
+		t.Fatal(err)
+	}
+	now = start.Add(4 * time.Hour)
+	if err := m.ReplaceAll(Policies{allow}); err != nil {
+		t.Fatal(err)
+	}
+
+	for k, c := range []struct {
+		at       time.Time
+		expected Policies
+	}{
+		{at: start.Add(-time.Minute), expected: Policies{}},
+		{at: start, expected: Policies{allow}},
+		{at: start.Add(30 * time.Minute), expected: Policies{allow}},
+		{at: start.Add(time.Hour), expected: Policies{&deny}},
+		{at: start.Add(2 * time.Hour), expected: Policies{}},
+		{at: start.Add(3 * time.Hour), expected: Policies{other}},
+		{at: start.Add(5 * time.Hour), expected: Policies{allow}},
+	} {
+		policies, err := m.GetAllAsOf(c.at)
+		if err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+		if !cmp.Equal(c.expected, policies) {
+			t.Errorf("Case %d: unexpected policies\n%s", k, cmp.Diff(c.expected, policies))
+		}
+	}
+
+	t.Run("Record the JSON stored by ReplaceAll", func(t *testing.T) {
+		stored, err := db.Get(m.key(prefixPolicy, allow.ID)).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		last, err := db.LIndex(m.key(prefixHistory, allow.ID), -1).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v version
+		if err := json.Unmarshal([]byte(last), &v); err != nil {
+			t.Fatal(err)
+		}
+		if string(v.Policy) != stored {
+			t.Fatalf("Expected the history to record the stored policy %s, got %s", stored, v.Policy)
+		}
+	})
+
+	t.Run("Record effects set by SetEffect", func(t *testing.T) {
+		m := NewRedisManager(db, "history_effect", WithHistory())
+		m.clock = func() time.Time { return now }
+
+		now = start
+		if err := m.Create(allow); err != nil {
+			t.Fatal(err)
+		}
+		now = start.Add(time.Hour)
+		if err := m.SetEffect(allow.ID, DenyAccess); err != nil {
+			t.Fatal(err)
+		}
+
+		for k, c := range []struct {
+			at     time.Time
+			effect string
+		}{
+			{at: start.Add(30 * time.Minute), effect: AllowAccess},
+			{at: start.Add(90 * time.Minute), effect: DenyAccess},
+		} {
+			policies, err := m.GetAllAsOf(c.at)
+			if err != nil {
+				t.Fatalf("Case %d: %s", k, err)
+			}
+			if len(policies) != 1 || policies[0].GetEffect() != c.effect {
+				t.Errorf("Case %d: expected a single policy with effect %s, got %v", k, c.effect, policies)
+			}
+		}
+	})
+
+	if _, err := NewRedisManager(db, "history").GetAllAsOf(now); errors.Cause(err) != manager.ErrHistoryUnsupported {
+		t.Errorf("Expected a manager without history to return ErrHistoryUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package redis
+
+import "time"
+
+// DefaultMaxPolicySize is the default limit of a policy's serialized size in bytes.
+const DefaultMaxPolicySize = 1 << 20
+
+// Option configures a RedisManager.
+type Option func(*options)
+
+type options struct {
+	caseInsensitive bool
+	idOnlyIndex     bool
+	setIndex        bool
+
+	noSubjectIndex  bool
+	noResourceIndex bool
+	emptyMatchesAll bool
+
+	subjectPrefixIndex bool
+
+	hashTag bool
+
+	patternCharacters string
+
+	maxPolicySize int
+
+	lenientConditions bool
+
+	history bool
+
+	attempts int
+	backoff  func(retry int) time.Duration
+
+	// clock returns the current time for timestamps, time.Now if nil.
+	clock func() time.Time
+}
+
+// WithCaseInsensitiveIndex lowercases subjects and resources when indexing policies and when looking
+// them up, so that e.g. a request for `User:Alice` finds policies written for `user:alice`. Use it
+// together with matcher.CaseInsensitiveMatcher in the warden.
+//
+// Existing indexes are not rewritten. Enabling this option on a manager which already stores policies
+// requires rebuilding the indexes, e.g. by passing all policies to ReplaceAll.
+func WithCaseInsensitiveIndex() Option {
+	return func(o *options) {
+		o.caseInsensitive = true
+	}
+}
+
+// WithIDOnlyIndex stores only policy IDs in the subject and resource hashmaps instead of a copy of each
+// policy. Find* then load the policies from their policy keys with MGET in the script reading the
+// indexes, which avoids storing large policies up to three times.
+//
+// Existing indexes are not rewritten. Indexes written with this option can't be read by a manager
+// without it, so rebuild them with ReplaceAll before switching it off again.
+func WithIDOnlyIndex() Option {
+	return func(o *options) {
+		o.idOnlyIndex = true
+	}
+}
+
+// WithSetIndex stores the subject and resource indexes as Redis sets of policy IDs instead of hashmaps.
+// Like WithIDOnlyIndex, Find* read the IDs with SMEMBERS and load the policies from their policy keys with
+// a single MGET, and each policy body is stored once.
+//
+// Existing indexes are not rewritten. Sets and hashmaps can't be read interchangeably, so rebuild the
+// indexes with ReplaceAll whenever this option is switched on or off.
+func WithSetIndex() Option {
+	return func(o *options) {
+		o.setIndex = true
+		o.idOnlyIndex = true
+	}
+}
+
+// WithoutSubjectIndex stops maintaining the subject hashmaps on writes. FindPoliciesForSubject,
+// FindRequestCandidates and ListSubjects then return ErrIndexDisabled, so use it only if policies are
+// looked up by resource alone.
+//
+// Existing subject hashmaps are left in place. RepairIndexes reports and removes them as orphaned.
+func WithoutSubjectIndex() Option {
+	return func(o *options) {
+		o.noSubjectIndex = true
+	}
+}
+
+// WithoutResourceIndex stops maintaining the resource hashmaps on writes. FindPoliciesForResource,
+// FindRequestCandidates and ListResources then return ErrIndexDisabled, so use it only if policies are
+// looked up by subject alone.
+//
+// Existing resource hashmaps are left in place. RepairIndexes reports and removes them as orphaned.
+func WithoutResourceIndex() Option {
+	return func(o *options) {
+		o.noResourceIndex = true
+	}
+}
+
+// WithEmptyMatchesAll indexes policies without subjects or resources under an empty subject or resource
+// and reads those indexes on every lookup, so Find* return such policies for any subject or resource.
+// Use it together with matcher.EmptyMatchesAllMatcher, which lets empty subjects, resources and actions
+// match any request. Without this option a policy with neither subjects nor resources is never a
+// candidate. Lookups read the empty subject or resource hashmap as well, in the same script as the
+// requested one.
+//
+// Existing indexes are not rewritten, so run Reindex after enabling this option.
+func WithEmptyMatchesAll() Option {
+	return func(o *options) {
+		o.emptyMatchesAll = true
+	}
+}
+
+// WithMaxPolicySize limits the serialized size of a policy to n bytes instead of DefaultMaxPolicySize.
+// Create, Update and UpdateIfMatch reject larger policies with ErrPolicyTooLarge before writing anything.
+// Without WithIDOnlyIndex a policy is copied into the index of each of its subjects and resources, so
+// the limit bounds each copy. A limit of zero or less disables the check.
+func WithMaxPolicySize(n int) Option {
+	return func(o *options) {
+		o.maxPolicySize = n
+	}
+}
+
+// WithLenientConditions decodes stored policies with condition.UnmarshalPolicyLenient, so a policy using a
+// condition type this binary doesn't know, e.g. after rolling back a release, still loads. The unknown
+// condition fails safe, it is never fulfilled in an allowing policy and always in a denying one, and is
+// logged whenever the policy is decoded. By default such policies
+// fail to load with ErrBadConversion.
+func WithLenientConditions() Option {
+	return func(o *options) {
+		o.lenientConditions = true
+	}
+}
+
+// WithHistory keeps an append-only history of every policy, so GetAllAsOf can return the policies as
+// they were at an earlier time, e.g. to reconstruct past decisions. Create, Update, UpdateIfMatch,
+// SetEffect, Delete and ReplaceAll append the new version, or a deletion, to a list per policy ID stored
+// next to the policy. The history is never trimmed and survives ReplaceAll.
+//
+// Enabling this option on a manager which already stores policies only records changes from then on.
+func WithHistory() Option {
+	return func(o *options) {
+		o.history = true
+	}
+}
+
+// WithSubjectPrefixIndex additionally indexes policies under the prefix of every subject ending in a
+// wildcard, e.g. `user:` for `user:*`, so Find* return them for any subject starting with the prefix, like
+// `user:alice`. Without this option such subjects are indexed literally and only found by requests for
+// `user:*` itself. Lookups check every prefix of the requested subject against the prefix index in the
+// script reading the other indexes. Use it together with a matcher which understands the wildcard, like
+// matcher.GlobMatcher.
+//
+// Only subjects whose single `*` is their last character are prefix wildcards; other patterns are indexed
+// literally. Existing indexes are not rewritten, so run Reindex after enabling this option.
+func WithSubjectPrefixIndex() Option {
+	return func(o *options) {
+		o.subjectPrefixIndex = true
+	}
+}
+
+// WithPatternCharacters treats subjects and resources containing any of chars as patterns, like those
+// containing a regular expression. Pattern values are indexed literally and never looked up, so without
+// this option a policy written for a matcher with another syntax, like `user:*` for matcher.GlobMatcher,
+// is only a candidate for requests for `user:*` itself. With it, such policies are returned for any value
+// of that dimension if they are indexed for the other one, and matched by the warden's matcher.
+//
+// Pass matcher.GlobPatternCharacters when using matcher.GlobMatcher and
+// matcher.SegmentPatternCharacters when using matcher.SegmentMatcher. Candidates are chosen when reading,
+// so existing indexes keep working.
+func WithPatternCharacters(chars string) Option {
+	return func(o *options) {
+		o.patternCharacters = chars
+	}
+}
+
+// WithHashTag uses the key prefix as a hash tag, so keys are stored as {keyPrefix}_policy_<id> instead of
+// keyPrefix_policy_<id> and all keys of a manager map to the same cluster slot. This keeps multi-key
+// commands and transactions working on a Redis Cluster, which requires this option.
+//
+// Hash tagged keys differ from the keys written without this option, so existing policies aren't found
+// after switching it on or off. Migrate them first, e.g. by renaming every key matching keyPrefix_* to
+// {keyPrefix}_*, or by reading all policies with a manager using the old layout and passing them to
+// ReplaceAll of a manager using the new one.
+func WithHashTag() Option {
+	return func(o *options) {
+		o.hashTag = true
+	}
+}
@@ -0,0 +1,48 @@
+package redis
+
+import (
+	"strings"
+
+	. "github.com/ory/ladon"
+)
+
+// subjectKeys returns the keys of the subject index hashmaps the policy p is stored in: one per subject
+// and, with WithSubjectPrefixIndex, one in the prefix index per subject ending in a wildcard.
+func (m *RedisManager) subjectKeys(p Policy) []string {
+	var keys []string
+	for _, s := range m.indexed(prefixSubject, p.GetSubjects()) {
+		keys = append(keys, m.indexKey(prefixSubject, s))
+	}
+	if !m.subjectPrefixIndex || m.noSubjectIndex {
+		return keys
+	}
+	for _, s := range p.GetSubjects() {
+		if prefix, ok := wildcardPrefix(p, s); ok {
+			keys = append(keys, m.indexKey(prefixSubjectPrefix, prefix))
+		}
+	}
+	return keys
+}
+
+// wildcardPrefix returns the prefix of subject if it is a prefix wildcard like `user:*`, which ends in
+// the only `*` of the subject and contains neither a `?` nor a regular expression.
+func wildcardPrefix(p Policy, subject string) (string, bool) {
+	if !strings.HasSuffix(subject, "*") {
+		return "", false
+	}
+	prefix := strings.TrimSuffix(subject, "*")
+	if strings.ContainsAny(prefix, "*?") || strings.IndexByte(prefix, p.GetStartDelimiter()) >= 0 {
+		return "", false
+	}
+	return prefix, true
+}
+
+// prefixKeys returns the keys of the prefix index hashmaps of every prefix of subject, including the
+// empty one and subject itself, which store the policies with a subject wildcard matching it.
+func (m *RedisManager) prefixKeys(subject string) []string {
+	keys := []string{}
+	for i := range subject {
+		keys = append(keys, m.indexKey(prefixSubjectPrefix, subject[:i]))
+	}
+	return append(keys, m.indexKey(prefixSubjectPrefix, subject))
+}
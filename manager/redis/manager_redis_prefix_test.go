@@ -0,0 +1,106 @@
+package redis
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/matcher"
+	"github.com/pkg/errors"
+)
+
+func TestSubjectPrefixIndex(t *testing.T) {
+	policy := &DefaultPolicy{
+		ID:         "users",
+		Subjects:   []string{"user:*"},
+		Resources:  []string{"articles:1"},
+		Actions:    []string{"get"},
+		Effect:     AllowAccess,
+		Conditions: Conditions{},
+	}
+	r := &Request{Subject: "user:alice", Resource: "articles:1", Action: "get"}
+
+	for k, c := range []struct {
+		opts    []Option
+		allowed bool
+	}{
+		{allowed: false},
+		{opts: []Option{WithSubjectPrefixIndex()}, allowed: true},
+		{opts: []Option{WithSubjectPrefixIndex(), WithSetIndex()}, allowed: true},
+		{opts: []Option{WithSubjectPrefixIndex(), WithCaseInsensitiveIndex()}, allowed: true},
+	} {
+		m := NewRedisManager(db, fmt.Sprintf("subject_prefix%d", k), c.opts...)
+		if err := m.Create(policy); err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+		w := &Ladon{Manager: m, Matcher: matcher.NewGlobMatcher()}
+
+		if err := w.IsAllowed(r); (err == nil) != c.allowed {
+			t.Errorf("Case %d: expected allowed to be %t, got error %v", k, c.allowed, err)
+		}
+		if err := w.IsAllowed(&Request{Subject: "group:admins", Resource: "articles:1", Action: "get"}); errors.Cause(err) != ErrRequestDenied {
+			t.Errorf("Case %d: expected a subject without the prefix to be denied, got %v", k, err)
+		}
+		if !c.allowed {
+			continue
+		}
+
+		for i, found := range []func() (Policies, error){
+			func() (Policies, error) { return m.FindPoliciesForSubject("user:bob") },
+			func() (Policies, error) { return m.FindPoliciesForSubject("user:*") },
+			func() (Policies, error) {
+				batch, err := m.FindRequestCandidatesBatch([]*Request{r})
+				if err != nil {
+					return nil, err
+				}
+				return batch[0], nil
+			},
+		} {
+			policies, err := found()
+			if err != nil {
+				t.Fatalf("Case %d/%d: %s", k, i, err)
+			}
+			if !cmp.Equal(Policies{policy}, policies) {
+				t.Errorf("Case %d/%d: unexpected policies\n%s", k, i, cmp.Diff(Policies{policy}, policies))
+			}
+		}
+
+		if err := m.Delete(policy.ID); err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+		if policies, err := m.FindPoliciesForSubject("user:bob"); err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		} else if len(policies) != 0 {
+			t.Errorf("Case %d: expected the deleted policy to be removed from the prefix index, got %v", k, policies)
+		}
+	}
+}
+
+func TestSubjectPrefixReindex(t *testing.T) {
+	policy := &DefaultPolicy{ID: "users", Subjects: []string{"user:*", "admin"}, Conditions: Conditions{}}
+
+	if err := NewRedisManager(db, "subject_prefix_reindex").Create(policy); err != nil {
+		t.Fatal(err)
+	}
+	m := NewRedisManager(db, "subject_prefix_reindex", WithSubjectPrefixIndex())
+
+	if report, err := m.RepairIndexes(); err != nil {
+		t.Fatal(err)
+	} else if expected := []IndexEntry{{Key: m.key(prefixSubjectPrefix, "user:"), PolicyID: policy.ID}}; !cmp.Equal(expected, report.Missing) {
+		t.Fatalf("Expected the prefix index to be reported missing\n%s", cmp.Diff(expected, report.Missing))
+	}
+	if err := m.Reindex(); err != nil {
+		t.Fatal(err)
+	}
+	if policies, err := m.FindPoliciesForSubject("user:alice"); err != nil {
+		t.Fatal(err)
+	} else if !cmp.Equal(Policies{policy}, policies) {
+		t.Fatalf("Unexpected policies after reindexing\n%s", cmp.Diff(Policies{policy}, policies))
+	}
+	if report, err := m.RepairIndexes(); err != nil {
+		t.Fatal(err)
+	} else if len(report.Missing)+len(report.Orphaned)+len(report.Stale) != 0 {
+		t.Fatalf("Expected the reindexed prefix index to be consistent, got %+v", report)
+	}
+}
@@ -0,0 +1,95 @@
+package redis
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/go-redis/redis"
+	"github.com/pkg/errors"
+)
+
+// reindexBatchSize is the number of index entries Reindex writes per pipeline.
+const reindexBatchSize = 500
+
+// Reindex throws away the subject and resource indexes and rebuilds them from the stored policies, e.g.
+// after changing WithCaseInsensitiveIndex or the way policies name their subjects and resources. The
+// policies themselves are not rewritten.
+//
+// The new indexes are written to temporary keys in pipelined batches and then swapped in for the old
+// ones in a single MULTI/EXEC transaction, so concurrent readers see either the complete old or the
+// complete new indexes. Policies written while Reindex runs might be missing from the new indexes, so
+// run RepairIndexes afterwards if writes can't be paused.
+func (m *RedisManager) Reindex() error {
+	expected, err := m.expectedIndexes()
+	if err != nil {
+		return err
+	}
+
+	token := make([]byte, 8)
+	if _, err := rand.Read(token); err != nil {
+		return errors.WithStack(err)
+	}
+	tmp := m.namespaced(prefixKey(prefixReindex, hex.EncodeToString(token)))
+	tmpKey := func(key string) string {
+		return tmp.keyPrefix + strings.TrimPrefix(key, m.keyPrefix)
+	}
+	cleanup := func() {
+		if keys, err := tmp.keys(); err == nil && len(keys) > 0 {
+			m.db.Del(keys...)
+		}
+	}
+
+	pipe := m.db.Pipeline()
+	queued := 0
+	flush := func() error {
+		if queued == 0 {
+			return nil
+		}
+		queued = 0
+		_, err := pipe.Exec()
+		return err
+	}
+	for key, fields := range expected {
+		for id, value := range fields {
+			m.indexSet(pipe, tmpKey(key), id, value)
+			if queued++; queued >= reindexBatchSize {
+				if err := flush(); err != nil {
+					pipe.Close()
+					cleanup()
+					return err
+				}
+			}
+		}
+	}
+	err = flush()
+	pipe.Close()
+	if err != nil {
+		cleanup()
+		return err
+	}
+
+	var oldKeys []string
+	for _, prefix := range []string{prefixResource, prefixSubject, prefixSubjectPrefix} {
+		keys, err := m.keysMatching(m.key(prefix, "*"))
+		if err != nil {
+			cleanup()
+			return err
+		}
+		oldKeys = append(oldKeys, keys...)
+	}
+
+	if _, err := m.db.TxPipelined(func(pipe redis.Pipeliner) error {
+		if len(oldKeys) > 0 {
+			pipe.Del(oldKeys...)
+		}
+		for key := range expected {
+			pipe.Rename(tmpKey(key), key)
+		}
+		return nil
+	}); err != nil {
+		cleanup()
+		return err
+	}
+	return nil
+}
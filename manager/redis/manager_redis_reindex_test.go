@@ -0,0 +1,101 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+)
+
+func TestReindex(t *testing.T) {
+	m := NewRedisManager(db, "reindex")
+	policies := Policies{
+		&DefaultPolicy{
+			ID:         "test-policy-1",
+			Subjects:   []string{"User:Alice"},
+			Resources:  []string{"articles:1"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		},
+		&DefaultPolicy{
+			ID:         "test-policy-2",
+			Subjects:   []string{"user:bob"},
+			Resources:  []string{"articles:1", "articles:2"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		},
+	}
+	for _, p := range policies {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stored, err := db.Get(m.key(prefixPolicy, "test-policy-1")).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Corrupt the indexes: add a phantom policy and a stray index, drop an entry and make another one stale.
+	phantom := `{"id":"phantom","subjects":["user:carol"],"resources":["articles:1"],"actions":["get"],"effect":"allow","conditions":{}}`
+	for _, key := range []string{m.indexKey(prefixResource, "articles:1"), m.indexKey(prefixSubject, "user:carol")} {
+		if err := db.HSet(key, "phantom", phantom).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.HDel(m.indexKey(prefixSubject, "user:bob"), "test-policy-2").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.HSet(m.indexKey(prefixResource, "articles:2"), "test-policy-2", phantom).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Switch to lowercased indexes, which requires rebuilding them.
+	m = NewRedisManager(db, "reindex", WithCaseInsensitiveIndex())
+	if err := m.Reindex(); err != nil {
+		t.Fatal(err)
+	}
+
+	for k, c := range []struct {
+		find     func() (Policies, error)
+		expected Policies
+	}{
+		{find: func() (Policies, error) { return m.FindPoliciesForSubject("User:Alice") }, expected: policies[:1]},
+		{find: func() (Policies, error) { return m.FindPoliciesForSubject("user:bob") }, expected: policies[1:]},
+		{find: func() (Policies, error) { return m.FindPoliciesForSubject("user:carol") }, expected: Policies{}},
+		{find: func() (Policies, error) { return m.FindPoliciesForResource("articles:1") }, expected: policies},
+		{find: func() (Policies, error) { return m.FindPoliciesForResource("articles:2") }, expected: policies[1:]},
+		{find: func() (Policies, error) {
+			return m.FindRequestCandidates(&Request{Subject: "user:alice", Resource: "articles:1", Action: "get"})
+		}, expected: policies[:1]},
+	} {
+		actual, err := c.find()
+		if err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+		sortByID(actual)
+		if diff := cmp.Diff(c.expected, actual); diff != "" {
+			t.Errorf("Case %d: unexpected policies (-want +got):\n%s", k, diff)
+		}
+	}
+
+	subjects, err := m.ListSubjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"user:alice", "user:bob"}, subjects); diff != "" {
+		t.Fatalf("Expected only the rebuilt subject indexes (-want +got):\n%s", diff)
+	}
+
+	if v, err := db.Get(m.key(prefixPolicy, "test-policy-1")).Result(); err != nil {
+		t.Fatal(err)
+	} else if v != stored {
+		t.Fatalf("Expected the policy body to be untouched, got %s", v)
+	}
+	if keys, err := db.Keys(m.key(prefixReindex, "*")).Result(); err != nil {
+		t.Fatal(err)
+	} else if len(keys) != 0 {
+		t.Fatalf("Expected the temporary keys to be gone, got %v", keys)
+	}
+}
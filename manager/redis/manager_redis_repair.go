@@ -0,0 +1,157 @@
+package redis
+
+import "sort"
+
+// IndexEntry is a field of a subject or resource index hashmap.
+type IndexEntry struct {
+	Key      string
+	PolicyID string
+}
+
+// RepairReport lists the index entries fixed by RepairIndexes.
+type RepairReport struct {
+	// Orphaned entries referenced policies which don't exist or don't have the indexed subject or
+	// resource anymore. They were removed.
+	Orphaned []IndexEntry
+	// Missing entries were added for policies which weren't indexed for one of their subjects or resources.
+	Missing []IndexEntry
+	// Stale entries held an outdated copy of their policy. They were overwritten.
+	Stale []IndexEntry
+}
+
+// RepairIndexes rebuilds the subject and resource index hashmaps from the stored policies, which are
+// authoritative. Create, Update and Delete write several keys without a transaction, so a crash halfway
+// through can leave the indexes referencing deleted policies or missing entries for existing ones.
+//
+// RepairIndexes is not atomic. Run it while no policies are being written, or repeat it afterwards.
+func (m *RedisManager) RepairIndexes() (*RepairReport, error) {
+	expected, err := m.expectedIndexes()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RepairReport{}
+	for _, prefix := range []string{prefixResource, prefixSubject, prefixSubjectPrefix} {
+		keys, err := m.keysMatching(m.key(prefix, "*"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fields, err := m.indexGet(m.db, key)()
+			if err != nil {
+				return nil, err
+			}
+
+			var orphaned []string
+			for id, v := range fields {
+				want, ok := expected[key][id]
+				switch {
+				case !ok:
+					orphaned = append(orphaned, id)
+				case v != want:
+					if err := m.indexSet(m.db, key, id, want).Err(); err != nil {
+						return nil, err
+					}
+					report.Stale = append(report.Stale, IndexEntry{Key: key, PolicyID: id})
+				}
+			}
+
+			if len(orphaned) > 0 {
+				if err := m.indexDel(m.db, key, orphaned...).Err(); err != nil {
+					return nil, err
+				}
+				for _, id := range orphaned {
+					report.Orphaned = append(report.Orphaned, IndexEntry{Key: key, PolicyID: id})
+				}
+			}
+
+			for id, want := range expected[key] {
+				if _, ok := fields[id]; ok {
+					continue
+				}
+				if err := m.indexSet(m.db, key, id, want).Err(); err != nil {
+					return nil, err
+				}
+				report.Missing = append(report.Missing, IndexEntry{Key: key, PolicyID: id})
+			}
+			delete(expected, key)
+		}
+	}
+
+	// Whatever is left belongs to index hashmaps which don't exist at all.
+	for key, fields := range expected {
+		for id, want := range fields {
+			if err := m.indexSet(m.db, key, id, want).Err(); err != nil {
+				return nil, err
+			}
+			report.Missing = append(report.Missing, IndexEntry{Key: key, PolicyID: id})
+		}
+	}
+
+	for _, entries := range [][]IndexEntry{report.Orphaned, report.Missing, report.Stale} {
+		sortEntries(entries)
+	}
+	return report, nil
+}
+
+// expectedIndexes returns the fields every index hashmap should contain, keyed by index key and policy ID.
+func (m *RedisManager) expectedIndexes() (map[string]map[string]string, error) {
+	keys, err := m.keysMatching(m.key(prefixPolicy, "*"))
+	if err != nil {
+		return nil, err
+	}
+
+	expected := map[string]map[string]string{}
+	if len(keys) == 0 {
+		return expected, nil
+	}
+
+	values, err := m.db.MGet(keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	add := func(key, id, value string) {
+		if expected[key] == nil {
+			expected[key] = map[string]string{}
+		}
+		expected[key][id] = value
+	}
+
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			// The policy was deleted after it was listed.
+			continue
+		}
+
+		id := m.policyID(keys[i])
+		p, err := m.decodePolicy(id, s)
+		if err != nil {
+			return nil, err
+		}
+
+		value := s
+		if m.idOnlyIndex {
+			value = ""
+		}
+		for _, r := range m.indexed(prefixResource, p.GetResources()) {
+			add(m.indexKey(prefixResource, r), id, value)
+		}
+		for _, key := range m.subjectKeys(p) {
+			add(key, id, value)
+		}
+	}
+	return expected, nil
+}
+
+func sortEntries(entries []IndexEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Key != entries[j].Key {
+			return entries[i].Key < entries[j].Key
+		}
+		return entries[i].PolicyID < entries[j].PolicyID
+	})
+}
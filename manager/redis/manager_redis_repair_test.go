@@ -0,0 +1,99 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+)
+
+func TestRepairIndexes(t *testing.T) {
+	m := NewRedisManager(db, "repair")
+	policies := Policies{
+		&DefaultPolicy{
+			ID:         "test-policy-1",
+			Subjects:   []string{"user:alice"},
+			Resources:  []string{"articles:1"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		},
+		&DefaultPolicy{
+			ID:         "test-policy-2",
+			Subjects:   []string{"user:bob"},
+			Resources:  []string{"articles:1"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		},
+	}
+	for _, p := range policies {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		subjectKey  = m.indexKey(prefixSubject, "user:alice")
+		resourceKey = m.indexKey(prefixResource, "articles:1")
+		phantom     = `{"id":"phantom","subjects":["user:alice"],"resources":["articles:1"],"actions":["get"],"effect":"allow","conditions":{}}`
+	)
+
+	// Corrupt the indexes: add a phantom policy, drop an entry and make another one stale.
+	for _, key := range []string{subjectKey, resourceKey} {
+		if err := db.HSet(key, "phantom", phantom).Err(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.HDel(m.indexKey(prefixSubject, "user:bob"), "test-policy-2").Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.HSet(resourceKey, "test-policy-1", phantom).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Request{Subject: "user:alice", Resource: "articles:1", Action: "get"}
+	if p, err := m.FindRequestCandidates(r); err != nil {
+		t.Fatal(err)
+	} else if len(p) != 2 {
+		t.Fatalf("Expected the phantom policy to be returned before the repair, got %+v", p)
+	}
+
+	report, err := m.RepairIndexes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := &RepairReport{
+		Orphaned: []IndexEntry{{Key: resourceKey, PolicyID: "phantom"}, {Key: subjectKey, PolicyID: "phantom"}},
+		Missing:  []IndexEntry{{Key: m.indexKey(prefixSubject, "user:bob"), PolicyID: "test-policy-2"}},
+		Stale:    []IndexEntry{{Key: resourceKey, PolicyID: "test-policy-1"}},
+	}
+	if !cmp.Equal(expected, report) {
+		t.Fatalf("Unexpected report\n%s", cmp.Diff(expected, report))
+	}
+
+	p, err := m.FindRequestCandidates(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(Policies{policies[0]}, p) {
+		t.Fatalf("Unexpected candidates after the repair\n%s", cmp.Diff(Policies{policies[0]}, p))
+	}
+
+	if p, err := m.FindPoliciesForSubject("user:bob"); err != nil {
+		t.Fatal(err)
+	} else if !cmp.Equal(Policies{policies[1]}, p) {
+		t.Fatalf("Expected the missing entry to be restored\n%s", cmp.Diff(Policies{policies[1]}, p))
+	}
+
+	t.Run("Report nothing for intact indexes", func(t *testing.T) {
+		report, err := m.RepairIndexes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(&RepairReport{}, report) {
+			t.Fatalf("Expected an empty report\n%s", cmp.Diff(&RepairReport{}, report))
+		}
+	})
+}
@@ -0,0 +1,66 @@
+package redis
+
+import (
+	"io"
+	"net"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// WithRetry retries read operations failing with a transient error, like a refused connection or an
+// i/o timeout, up to attempts times in total. backoff returns how long to wait before the given retry,
+// counting from 1, and may be nil to retry immediately. Errors returned by Redis itself and ladon's
+// ErrNotFound are never retried.
+//
+// Writes are not retried, as a write failing with a timeout might have been applied anyway.
+func WithRetry(attempts int, backoff func(retry int) time.Duration) Option {
+	return func(o *options) {
+		o.attempts = attempts
+		o.backoff = backoff
+	}
+}
+
+// ExponentialBackoff returns a backoff for WithRetry which starts at base and doubles with every retry,
+// up to max.
+func ExponentialBackoff(base, max time.Duration) func(retry int) time.Duration {
+	return func(retry int) time.Duration {
+		d := base
+		for i := 1; i < retry && d < max; i++ {
+			d *= 2
+		}
+		if d > max {
+			return max
+		}
+		return d
+	}
+}
+
+// retry calls fn until it succeeds, fails with an error which isn't transient, or the configured
+// number of attempts is exhausted.
+func (m *RedisManager) retry(fn func() error) error {
+	err := fn()
+	for retry := 1; retry < m.attempts && isTransient(err); retry++ {
+		if m.backoff != nil {
+			time.Sleep(m.backoff(retry))
+		}
+		err = fn()
+	}
+	return err
+}
+
+// isTransient returns true for network errors, which might go away when retrying.
+func isTransient(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	cause := errors.Cause(err)
+	if cause == io.EOF || cause == io.ErrUnexpectedEOF || cause == syscall.ECONNREFUSED || cause == syscall.ECONNRESET {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
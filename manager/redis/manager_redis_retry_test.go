@@ -0,0 +1,148 @@
+package redis
+
+import (
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// flakyClient fails the first failures calls of HGetAll, Get and Exists with err.
+type flakyClient struct {
+	redis.UniversalClient
+	err      error
+	failures int
+	calls    int
+}
+
+func (c *flakyClient) fail() bool {
+	c.calls++
+	return c.calls <= c.failures
+}
+
+func (c *flakyClient) HGetAll(key string) *redis.StringStringMapCmd {
+	if c.fail() {
+		return redis.NewStringStringMapResult(nil, c.err)
+	}
+	return c.UniversalClient.HGetAll(key)
+}
+
+func (c *flakyClient) Get(key string) *redis.StringCmd {
+	if c.fail() {
+		return redis.NewStringResult("", c.err)
+	}
+	return c.UniversalClient.Get(key)
+}
+
+func (c *flakyClient) Exists(keys ...string) *redis.IntCmd {
+	if c.fail() {
+		return redis.NewIntResult(0, c.err)
+	}
+	return c.UniversalClient.Exists(keys...)
+}
+
+func TestRetry(t *testing.T) {
+	policy := &DefaultPolicy{
+		ID:         "test-policy-1",
+		Subjects:   []string{"user:alice"},
+		Resources:  []string{"articles:1"},
+		Actions:    []string{"get"},
+		Effect:     AllowAccess,
+		Conditions: Conditions{},
+	}
+	if err := NewRedisManager(db, "retry").Create(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		refused = &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+		backoff = func(retries *[]int) func(int) time.Duration {
+			return func(retry int) time.Duration {
+				*retries = append(*retries, retry)
+				return 0
+			}
+		}
+	)
+
+	t.Run("Retry transient errors", func(t *testing.T) {
+		var retries []int
+		client := &flakyClient{UniversalClient: db, err: refused, failures: 1}
+		m := NewRedisManager(client, "retry", WithRetry(3, backoff(&retries)))
+
+		p, err := m.FindPoliciesForSubject("user:alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !contains(p, policy) {
+			t.Fatalf("Policy %+v not found after retrying", policy)
+		}
+		if len(retries) != 1 {
+			t.Fatalf("Expected a single retry, got %v", retries)
+		}
+
+		client.calls = 0
+		if _, err := m.Get(policy.ID); err != nil {
+			t.Fatalf("Expected Get to succeed after retrying: %s", err)
+		}
+	})
+
+	t.Run("Give up after the configured attempts", func(t *testing.T) {
+		var retries []int
+		client := &flakyClient{UniversalClient: db, err: refused, failures: 5}
+		m := NewRedisManager(client, "retry", WithRetry(3, backoff(&retries)))
+
+		if _, err := m.FindPoliciesForResource("articles:1"); errors.Cause(err) != refused {
+			t.Fatalf("Expected %v, got %v", refused, err)
+		}
+		if client.calls != 3 || len(retries) != 2 || retries[0] != 1 || retries[1] != 2 {
+			t.Fatalf("Expected 3 attempts and 2 retries, got %d attempts and retries %v", client.calls, retries)
+		}
+	})
+
+	t.Run("Don't retry logical errors", func(t *testing.T) {
+		client := &flakyClient{UniversalClient: db, err: errors.New("WRONGTYPE Operation against a key holding the wrong kind of value"), failures: 1}
+		m := NewRedisManager(client, "retry", WithRetry(3, nil))
+
+		if _, err := m.FindPoliciesForSubject("user:alice"); err == nil {
+			t.Fatal("Expected an error")
+		}
+		if client.calls != 1 {
+			t.Fatalf("Expected a single attempt, got %d", client.calls)
+		}
+
+		if _, err := m.Get("does-not-exist"); err != ErrNotFound {
+			t.Fatalf("Expected %v, got %v", ErrNotFound, err)
+		}
+		if client.calls != 2 {
+			t.Fatalf("Expected missing policies not to be retried, got %d calls", client.calls)
+		}
+	})
+
+	t.Run("Don't retry by default", func(t *testing.T) {
+		client := &flakyClient{UniversalClient: db, err: refused, failures: 1}
+		m := NewRedisManager(client, "retry")
+
+		if _, err := m.FindPoliciesForSubject("user:alice"); errors.Cause(err) != refused {
+			t.Fatalf("Expected %v, got %v", refused, err)
+		}
+	})
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	for retry, expected := range map[int]time.Duration{
+		1: 10 * time.Millisecond,
+		2: 20 * time.Millisecond,
+		3: 40 * time.Millisecond,
+		4: 50 * time.Millisecond,
+		9: 50 * time.Millisecond,
+	} {
+		if d := b(retry); d != expected {
+			t.Errorf("Retry %d: expected %s, got %s", retry, expected, d)
+		}
+	}
+}
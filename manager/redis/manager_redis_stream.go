@@ -0,0 +1,118 @@
+package redis
+
+import (
+	. "github.com/ory/ladon"
+)
+
+// StreamRequestCandidates calls fn for each policy FindRequestCandidates would return, without loading
+// them all first. The subject index of the request is scanned page by page with HSCAN, or SSCAN if the
+// manager uses WithSetIndex, followed by the resource index, and each page is decoded only once fn has
+// consumed the previous one. If fn returns an error streaming stops and the error is returned, so a
+// warden can stop reading candidates as soon as one of them denies the request.
+//
+// Candidates are passed in no particular order. Policies written while the indexes are scanned may or
+// may not be passed to fn.
+func (m *RedisManager) StreamRequestCandidates(r *Request, fn func(Policy) error) error {
+	if m.noSubjectIndex || m.noResourceIndex {
+		return ErrIndexDisabled
+	}
+
+	var (
+		rKey = m.indexKey(prefixResource, r.Resource)
+		seen = map[string]bool{}
+	)
+
+	// Policies in the subject index are candidates if they are indexed for the resource as well, or if
+	// the index can't tell which resources they match.
+	for _, key := range m.lookupKeys(prefixSubject, r.Subject) {
+		if err := m.streamIndex(key, func(p *DefaultPolicy) error {
+			if seen[p.GetID()] {
+				return nil
+			}
+			seen[p.GetID()] = true
+			if m.constrains(p, p.GetResources()) && !m.indexedFor(prefixResource, p.GetResources(), rKey) {
+				return nil
+			}
+			return fn(p)
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Policies only in the resource index are candidates if the index can't tell which subjects they match.
+	for _, key := range m.lookupKeys(prefixResource, r.Resource) {
+		if err := m.streamIndex(key, func(p *DefaultPolicy) error {
+			if seen[p.GetID()] {
+				return nil
+			}
+			seen[p.GetID()] = true
+			if m.constrains(p, p.GetSubjects()) {
+				return nil
+			}
+			return fn(p)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// indexedFor returns true if one of values is indexed under key.
+func (m *RedisManager) indexedFor(prefix string, values []string, key string) bool {
+	for _, v := range values {
+		if m.indexKey(prefix, v) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// streamIndex scans the index at key and calls fn for each of its policies, one page at a time.
+func (m *RedisManager) streamIndex(key string, fn func(*DefaultPolicy) error) error {
+	var cursor uint64
+	for {
+		page, next, err := m.indexScan(key, cursor)
+		if err != nil {
+			return err
+		}
+
+		resolved, err := m.resolve(page)
+		if err != nil {
+			return err
+		}
+		for _, p := range resolved {
+			if err := fn(p); err != nil {
+				return err
+			}
+		}
+
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// indexScan reads a page of the index at key like indexGet.
+func (m *RedisManager) indexScan(key string, cursor uint64) (map[string]string, uint64, error) {
+	page := map[string]string{}
+	if m.setIndex {
+		ids, next, err := m.db.SScan(key, cursor, "", iteratePageSize).Result()
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, id := range ids {
+			page[id] = ""
+		}
+		return page, next, nil
+	}
+
+	fields, next, err := m.db.HScan(key, cursor, "", iteratePageSize).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := 0; i+1 < len(fields); i += 2 {
+		page[fields[i]] = fields[i+1]
+	}
+	return page, next, nil
+}
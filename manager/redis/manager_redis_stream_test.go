@@ -0,0 +1,75 @@
+package redis
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+func TestStreamRequestCandidates(t *testing.T) {
+	for k, opts := range [][]Option{nil, {WithIDOnlyIndex()}, {WithSetIndex()}} {
+		m := NewRedisManager(db, fmt.Sprintf("stream%d", k), opts...)
+		for _, p := range batchPolicies {
+			if err := m.Create(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for i, r := range batchRequests {
+			expected, err := m.FindRequestCandidates(r)
+			if err != nil {
+				t.Fatalf("Case %d/%d: %s", k, i, err)
+			}
+
+			streamed := Policies{}
+			if err := m.StreamRequestCandidates(r, func(p Policy) error {
+				streamed = append(streamed, p)
+				return nil
+			}); err != nil {
+				t.Fatalf("Case %d/%d: %s", k, i, err)
+			}
+			sortByID(streamed)
+			if diff := cmp.Diff(expected, streamed); diff != "" {
+				t.Errorf("Case %d/%d: unexpected candidates (-want +got):\n%s", k, i, diff)
+			}
+		}
+	}
+
+	t.Run("Stop when the callback fails", func(t *testing.T) {
+		m := NewRedisManager(db, "stream_stop")
+		for i := 0; i < 500; i++ {
+			if err := m.Create(&DefaultPolicy{
+				ID:         fmt.Sprintf("deny-%d", i),
+				Subjects:   []string{"group:everyone"},
+				Resources:  []string{"<.*>"},
+				Actions:    []string{"<.*>"},
+				Effect:     DenyAccess,
+				Conditions: Conditions{},
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		stop := errors.New("stop")
+		calls := 0
+		if err := m.StreamRequestCandidates(&Request{Subject: "group:everyone", Resource: "articles:1"}, func(Policy) error {
+			calls++
+			return stop
+		}); err != stop {
+			t.Fatalf("Expected %v, got %v", stop, err)
+		}
+		if calls != 1 {
+			t.Fatalf("Expected streaming to stop after the first candidate, got %d calls", calls)
+		}
+	})
+
+	t.Run("Reject disabled indexes", func(t *testing.T) {
+		m := NewRedisManager(db, "stream_disabled", WithoutResourceIndex())
+		if err := m.StreamRequestCandidates(&Request{}, func(Policy) error { return nil }); err != ErrIndexDisabled {
+			t.Fatalf("Expected %v, got %v", ErrIndexDisabled, err)
+		}
+	})
+}
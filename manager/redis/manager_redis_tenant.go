@@ -0,0 +1,38 @@
+package redis
+
+import (
+	"strings"
+
+	"github.com/go-redis/redis"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidTenant is returned by NewTenantRedisManager for tenants which can't be used as a key prefix.
+var ErrInvalidTenant = errors.New("Tenant must not be empty or contain glob metacharacters or braces")
+
+// tenantForbiddenChars are the characters a tenant must not contain. Glob metacharacters would let the
+// KEYS and SCAN patterns of GetAll and Iterate match keys of other tenants, e.g. the tenant `*` would see
+// all of them, and braces would close the hash tag early, e.g. the keys of the tenant `a}_policy_x{`
+// would start with `{a}_policy_` and be returned by GetAll of the tenant `a`.
+const tenantForbiddenChars = `*?[]\{}`
+
+// ValidateTenant returns ErrInvalidTenant if tenant can't safely be used as the key prefix of a manager
+// sharing a Redis instance with other tenants.
+func ValidateTenant(tenant string) error {
+	if tenant == "" || strings.ContainsAny(tenant, tenantForbiddenChars) {
+		return errors.WithStack(ErrInvalidTenant)
+	}
+	return nil
+}
+
+// NewTenantRedisManager initializes a new RedisManager storing the policies of tenant in a Redis instance
+// shared with other tenants. The tenant is used as the key prefix like in NewRedisManager, so every key
+// of the manager, including those GetAll and Iterate look for, is stored below {tenant}. Unlike
+// NewRedisManager, which trusts its key prefix, tenants are validated with ValidateTenant, so no tenant
+// can read or overwrite the policies of another one.
+func NewTenantRedisManager(db redis.UniversalClient, tenant string, opts ...Option) (*RedisManager, error) {
+	if err := ValidateTenant(tenant); err != nil {
+		return nil, err
+	}
+	return NewRedisManager(db, tenant, opts...), nil
+}
@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+func TestTenantRedisManager(t *testing.T) {
+	for _, tenant := range []string{"", "*", "tenant*", "tenant?", "[tenant]", `tenant\`, "tenant}_policy_x{", "{tenant}"} {
+		if _, err := NewTenantRedisManager(db, tenant); errors.Cause(err) != ErrInvalidTenant {
+			t.Errorf("Expected tenant %q to be rejected, got %v", tenant, err)
+		}
+	}
+
+	tenants := []string{"tenant", "tenant_policy", "tenant_subject_x", "tenant:other", "tenant "}
+	for _, tenant := range tenants {
+		m, err := NewTenantRedisManager(db, tenant)
+		if err != nil {
+			t.Fatalf("Tenant %q: %s", tenant, err)
+		}
+		if err := m.Create(&DefaultPolicy{
+			ID:        tenant,
+			Subjects:  []string{"user:alice"},
+			Resources: []string{"articles:1"},
+			Actions:   []string{"get"},
+			Effect:    AllowAccess,
+		}); err != nil {
+			t.Fatalf("Tenant %q: %s", tenant, err)
+		}
+	}
+
+	for _, tenant := range tenants {
+		m, err := NewTenantRedisManager(db, tenant)
+		if err != nil {
+			t.Fatalf("Tenant %q: %s", tenant, err)
+		}
+
+		all, err := m.GetAll(100, 0)
+		if err != nil {
+			t.Fatalf("Tenant %q: %s", tenant, err)
+		}
+		if len(all) != 1 || all[0].GetID() != tenant {
+			t.Errorf("Tenant %q: expected only its own policy, got %d policies", tenant, len(all))
+		}
+
+		candidates, err := m.FindRequestCandidates(&Request{Subject: "user:alice", Resource: "articles:1", Action: "get"})
+		if err != nil {
+			t.Fatalf("Tenant %q: %s", tenant, err)
+		}
+		if len(candidates) != 1 || candidates[0].GetID() != tenant {
+			t.Errorf("Tenant %q: expected only its own candidate, got %d policies", tenant, len(candidates))
+		}
+	}
+}
@@ -1,28 +1,46 @@
 package redis
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/go-redis/redis"
 	"github.com/google/go-cmp/cmp"
 	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/condition"
+	"github.com/ory/ladon-community/manager"
+	"github.com/ory/ladon-community/matcher"
+	"github.com/pkg/errors"
 	"gopkg.in/ory-am/dockertest.v3"
 )
 
-var db *redis.Client
+var (
+	db       *redis.Client
+	redisURL string
+)
 
 func contains(s []Policy, p Policy) bool {
 	for _, v := range s {
-		if cmp.Equal(v, p) {
+		if cmp.Equal(withoutTimestamps(v), withoutTimestamps(p)) {
 			return true
 		}
 	}
 	return false
 }
 
+// withoutTimestamps returns the policy Get or GetAll returned without its timestamps.
+func withoutTimestamps(p Policy) Policy {
+	if tp, ok := p.(*manager.TimestampedPolicy); ok {
+		return tp.DefaultPolicy
+	}
+	return p
+}
+
 func TestMain(m *testing.M) {
 	pool, err := dockertest.NewPool("")
 	if err != nil {
@@ -34,7 +52,8 @@ func TestMain(m *testing.M) {
 	if err != nil {
 		log.Fatalf("Could not start resource: %s", err)
 	}
-	settings, err := redis.ParseURL(fmt.Sprintf("redis://localhost:%s", resource.GetPort("6379/tcp")))
+	redisURL = fmt.Sprintf("redis://localhost:%s", resource.GetPort("6379/tcp"))
+	settings, err := redis.ParseURL(redisURL)
 	if err != nil {
 		log.Fatal(err.Error())
 	}
@@ -109,8 +128,8 @@ func TestGet(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if cmp.Equal(policy, p) != true {
-			t.Fatalf("Unexpected policy.\n%s", cmp.Diff(policy, p))
+		if cmp.Equal(policy, withoutTimestamps(p)) != true {
+			t.Fatalf("Unexpected policy.\n%s", cmp.Diff(policy, withoutTimestamps(p)))
 		}
 	})
 
@@ -147,8 +166,8 @@ func TestUpdate(t *testing.T) {
 			t.Fatal(err)
 		}
 
-		if cmp.Equal(u, policy) != true {
-			t.Fatalf("Unexpected policy from 'Get' after 'Update'\n%s", cmp.Diff(u, policy))
+		if cmp.Equal(withoutTimestamps(u), policy) != true {
+			t.Fatalf("Unexpected policy from 'Get' after 'Update'\n%s", cmp.Diff(withoutTimestamps(u), policy))
 		}
 	})
 
@@ -208,6 +227,23 @@ func TestFindRequestCandidate(t *testing.T) {
 			Subjects:   []string{"ex3", "ex4"},
 			Conditions: Conditions{},
 		},
+		&DefaultPolicy{
+			ID:         "test-policy-4",
+			Subjects:   []string{"ex1"},
+			Resources:  []string{"exr3"},
+			Conditions: Conditions{},
+		},
+		&DefaultPolicy{
+			ID:         "test-policy-5",
+			Resources:  []string{"exr1"},
+			Conditions: Conditions{},
+		},
+		&DefaultPolicy{
+			ID:         "test-policy-6",
+			Subjects:   []string{"<.*>"},
+			Resources:  []string{"exr1"},
+			Conditions: Conditions{},
+		},
 	}
 
 	for _, v := range policies {
@@ -232,6 +268,20 @@ func TestFindRequestCandidate(t *testing.T) {
 	if contains(p, policies[2]) {
 		t.Fatalf("Policy %+v was not expected from FindRequestCandidates", policies[2])
 	}
+
+	if contains(p, policies[3]) {
+		t.Fatalf("Policy %+v matches the subject but not the resource and was not expected from FindRequestCandidates", policies[3])
+	}
+
+	for _, expected := range []Policy{policies[1], policies[4], policies[5]} {
+		if contains(p, expected) != true {
+			t.Fatalf("Policy %+v constrains a single dimension and was expected from FindRequestCandidates", expected)
+		}
+	}
+
+	if len(p) != 4 {
+		t.Fatalf("Expected 4 candidates without duplicates, got %d", len(p))
+	}
 }
 
 func TestFindPoliciesForResource(t *testing.T) {
@@ -356,3 +406,1333 @@ func TestWithWarden(t *testing.T) {
 		}
 	}
 }
+
+func TestReplaceAll(t *testing.T) {
+	m := NewRedisManager(db, "replaceAll")
+
+	set := func(name string, n int) Policies {
+		policies := Policies{}
+		for i := 0; i < n; i++ {
+			policies = append(policies, &DefaultPolicy{
+				ID:         fmt.Sprintf("%s-policy-%d", name, i),
+				Subjects:   []string{"ex1", name},
+				Resources:  []string{"exr1"},
+				Conditions: Conditions{},
+			})
+		}
+		return policies
+	}
+	old, replacement := set("old", 10), set("new", 5)
+
+	for _, p := range old {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// observe calls find until stop is called and fails if it ever returns a mix of both sets.
+	observe := func(find func() (Policies, error)) (stop func() error) {
+		done := make(chan struct{})
+		errs := make(chan error, 1)
+		go func() {
+			defer close(errs)
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				p, err := find()
+				if err != nil {
+					errs <- err
+					return
+				}
+				if !(len(p) == len(old) && contains(old, p[0])) && !(len(p) == len(replacement) && contains(replacement, p[0])) {
+					errs <- fmt.Errorf("Observed a partial policy set of %d policies", len(p))
+					return
+				}
+			}
+		}()
+		return func() error {
+			close(done)
+			return <-errs
+		}
+	}
+	swap := func(m *RedisManager) {
+		for i := 0; i < 10; i++ {
+			next := replacement
+			if i%2 == 1 {
+				next = old
+			}
+			if err := m.ReplaceAll(next); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	stops := []func() error{
+		observe(func() (Policies, error) { return m.FindPoliciesForSubject("ex1") }),
+		observe(func() (Policies, error) { return m.FindRequestCandidates(&Request{Subject: "ex1", Resource: "exr1"}) }),
+	}
+	swap(m)
+	for _, stop := range stops {
+		if err := stop(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Candidates loaded by ID are consistent", func(t *testing.T) {
+		m := NewRedisManager(db, "replaceAllIDs", WithIDOnlyIndex())
+		if err := m.ReplaceAll(old); err != nil {
+			t.Fatal(err)
+		}
+
+		stop := observe(func() (Policies, error) { return m.FindRequestCandidates(&Request{Subject: "ex1", Resource: "exr1"}) })
+		swap(m)
+		if err := stop(); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Old policies are gone after the swap", func(t *testing.T) {
+		if err := m.ReplaceAll(replacement); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := m.Get(old[0].GetID()); err != ErrNotFound {
+			t.Fatalf("Expected policy %s to be removed", old[0].GetID())
+		}
+		if p, err := m.FindPoliciesForSubject("old"); err != nil {
+			t.Fatal(err)
+		} else if len(p) != 0 {
+			t.Fatalf("Expected no policies for subject old, got %d", len(p))
+		}
+
+		all, err := m.GetAll(100, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != len(replacement) {
+			t.Fatalf("Expected %d policies, got %d", len(replacement), len(all))
+		}
+	})
+
+	t.Run("Duplicate policies leave the current set untouched", func(t *testing.T) {
+		if err := m.ReplaceAll(Policies{old[0], old[0]}); err != ErrPolicyExists {
+			t.Fatalf("Expected ErrPolicyExists, got %v", err)
+		}
+
+		all, err := m.GetAll(100, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != len(replacement) {
+			t.Fatalf("Expected %d policies, got %d", len(replacement), len(all))
+		}
+		if keys, err := db.Keys("replaceAll_replace_*").Result(); err != nil {
+			t.Fatal(err)
+		} else if len(keys) > 0 {
+			t.Fatalf("Expected temporary keys to be cleaned up, got %v", keys)
+		}
+	})
+}
+
+func TestGetAllPaginated(t *testing.T) {
+	m := NewRedisManager(db, "paginated")
+
+	ids := map[string]int{}
+	for i := 0; i < 25; i++ {
+		id := fmt.Sprintf("test-policy-%d", i)
+		ids[id] = 0
+		if err := m.Create(&DefaultPolicy{
+			ID:         id,
+			Subjects:   []string{"ex1"},
+			Conditions: Conditions{},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		cursor  string
+		deleted string
+		pages   int
+	)
+	for {
+		policies, next, err := m.GetAllPaginated(cursor, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, p := range policies {
+			ids[p.GetID()]++
+		}
+
+		// Delete a policy which hasn't been seen yet in the middle of the iteration.
+		if deleted == "" {
+			for id, seen := range ids {
+				if seen == 0 {
+					deleted = id
+					break
+				}
+			}
+			if deleted != "" {
+				if err := m.Delete(deleted); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+
+		pages++
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if pages < 2 {
+		t.Fatalf("Expected more than one page, got %d", pages)
+	}
+	for id, seen := range ids {
+		if id == deleted {
+			continue
+		}
+		if seen != 1 {
+			t.Errorf("Expected policy %s to be returned exactly once, got %d", id, seen)
+		}
+	}
+	if ids[deleted] > 1 {
+		t.Errorf("Expected deleted policy %s to be returned at most once, got %d", deleted, ids[deleted])
+	}
+
+	t.Run("Invalid cursor", func(t *testing.T) {
+		if _, _, err := m.GetAllPaginated("not-a-cursor", 10); err == nil {
+			t.Fatal("Expected an error for an invalid cursor")
+		}
+	})
+}
+
+func TestClose(t *testing.T) {
+	t.Run("Close the owned client", func(t *testing.T) {
+		m, err := NewRedisManagerFromURL(redisURL, "close")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := m.Create(&DefaultPolicy{ID: "test-policy-1"}); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 2; i++ {
+			if err := m.Close(); err != nil {
+				t.Fatalf("Close %d failed: %s", i, err)
+			}
+		}
+
+		if _, err := m.Get("test-policy-1"); err == nil {
+			t.Fatal("Expected the client to be closed")
+		}
+	})
+
+	t.Run("Leave a shared client open", func(t *testing.T) {
+		m := NewRedisManager(db, "close")
+		if err := m.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := db.Ping().Err(); err != nil {
+			t.Fatalf("Expected the shared client to remain open: %s", err)
+		}
+	})
+
+	t.Run("Invalid URL", func(t *testing.T) {
+		if _, err := NewRedisManagerFromURL("not a url", "close"); err == nil {
+			t.Fatal("Expected an error for an invalid URL")
+		}
+	})
+}
+
+func TestErrors(t *testing.T) {
+	m := NewRedisManager(db, "errors")
+
+	policy := &DefaultPolicy{
+		ID:         "test-policy-1",
+		Subjects:   []string{"ex1"},
+		Conditions: Conditions{},
+	}
+	if err := m.Create(policy); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Set(m.key(prefixPolicy, policy.GetID()), "not json", 0).Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.HSet(m.key(prefixSubject, "ex1"), policy.GetID(), "not json").Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Conversion errors carry the policy ID", func(t *testing.T) {
+		_, getErr := m.Get(policy.GetID())
+		_, findErr := m.FindPoliciesForSubject("ex1")
+		_, allErr := m.GetAll(10, 0)
+
+		for k, err := range []error{getErr, findErr, allErr, errors.Wrap(findErr, "wrapped")} {
+			if !errors.Is(err, ErrBadConversion) {
+				t.Errorf("Case %d: expected %v to be ErrBadConversion", k, err)
+			}
+
+			var perr *PolicyError
+			if !errors.As(err, &perr) || perr.ID != policy.GetID() {
+				t.Errorf("Case %d: expected %v to carry policy ID %s", k, err, policy.GetID())
+			}
+
+			if errors.Cause(err) != ErrBadConversion {
+				t.Errorf("Case %d: expected the cause of %v to be ErrBadConversion", k, err)
+			}
+		}
+
+		if !errors.Is(fmt.Errorf("wrapped: %w", getErr), ErrBadConversion) {
+			t.Errorf("Expected %v to be ErrBadConversion", getErr)
+		}
+	})
+
+	t.Run("Not found errors can be wrapped", func(t *testing.T) {
+		_, err := m.Get("policy that doesn't exist")
+		if !errors.Is(errors.Wrap(err, "wrapped"), ErrNotFound) {
+			t.Fatalf("Expected %v to be ErrNotFound", err)
+		}
+		if !errors.Is(fmt.Errorf("wrapped: %w", err), ErrNotFound) {
+			t.Fatalf("Expected %v to be ErrNotFound", err)
+		}
+	})
+
+	t.Run("Malformed conditions are conversion errors", func(t *testing.T) {
+		malformed := &DefaultPolicy{ID: "malformed", Subjects: []string{"alice"}, Resources: []string{"articles:1"}, Actions: []string{"get"}, Conditions: Conditions{}}
+		if err := m.Create(malformed); err != nil {
+			t.Fatal(err)
+		}
+		// The JSONPath expression used to make the decoder panic.
+		if err := db.Set(m.key(prefixPolicy, malformed.ID), `{"id": "malformed", "subjects": ["alice"], "resources": ["articles:1"], "actions": ["get"], "effect": "allow", "conditions": {"a": {"type": "JSONPathCondition", "options": {"path": "$[?(@.a == ]"}}}}`, 0).Err(); err != nil {
+			t.Fatal(err)
+		}
+
+		_, getErr := m.Get(malformed.ID)
+		_, findErr := m.FindRequestCandidates(&Request{Subject: "alice", Resource: "articles:1", Action: "get"})
+		for k, err := range []error{getErr, findErr} {
+			if errors.Cause(err) != ErrBadConversion {
+				t.Errorf("Case %d: expected the cause of %v to be ErrBadConversion", k, err)
+			}
+		}
+	})
+}
+
+func TestUniversalClient(t *testing.T) {
+	settings, err := redis.ParseURL(redisURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs: []string{settings.Addr},
+	})
+	defer client.Close()
+
+	m := NewRedisManager(client, "universal", WithHashTag())
+	policy := &DefaultPolicy{
+		ID:         "test-policy-1",
+		Subjects:   []string{"ex1"},
+		Resources:  []string{"exr1"},
+		Conditions: Conditions{},
+	}
+	if err := m.Create(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	p, err := m.FindRequestCandidates(&Request{Subject: "ex1", Resource: "exr1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !contains(p, policy) {
+		t.Fatalf("Policy %+v not found in result of FindRequestCandidates", policy)
+	}
+
+	if err := m.ReplaceAll(Policies{policy}); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := m.GetAll(10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || !contains(all, policy) {
+		t.Fatalf("Unexpected policies from GetAll: %+v", all)
+	}
+
+	t.Run("All keys share the hash tag", func(t *testing.T) {
+		keys, err := client.Keys("*universal*").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 3 {
+			t.Fatalf("Expected a policy and two index keys, got %v", keys)
+		}
+		for _, key := range keys {
+			if !strings.HasPrefix(key, "{universal}_") {
+				t.Errorf("Key %s is not hash tagged", key)
+			}
+		}
+	})
+
+	t.Run("Keys aren't hash tagged by default", func(t *testing.T) {
+		m := NewRedisManager(client, "untagged")
+		if err := m.Create(policy); err != nil {
+			t.Fatal(err)
+		}
+
+		keys, err := client.Keys("*untagged*").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(keys) != 3 {
+			t.Fatalf("Expected a policy and two index keys, got %v", keys)
+		}
+		for _, key := range keys {
+			if !strings.HasPrefix(key, "untagged_") {
+				t.Errorf("Key %s doesn't use the unwrapped key prefix", key)
+			}
+		}
+	})
+}
+
+func TestIterate(t *testing.T) {
+	m := NewRedisManager(db, "iterate")
+
+	for i := 0; i < 250; i++ {
+		if err := m.Create(&DefaultPolicy{
+			ID:         fmt.Sprintf("test-policy-%d", i),
+			Conditions: Conditions{},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Visit every policy", func(t *testing.T) {
+		seen := map[string]bool{}
+		if err := m.Iterate(context.Background(), func(p Policy) error {
+			seen[p.GetID()] = true
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if len(seen) != 250 {
+			t.Fatalf("Expected 250 policies, got %d", len(seen))
+		}
+	})
+
+	t.Run("Stop when the callback fails", func(t *testing.T) {
+		stop := errors.New("stop")
+		var count int
+		if err := m.Iterate(context.Background(), func(p Policy) error {
+			count++
+			if count == 3 {
+				return stop
+			}
+			return nil
+		}); err != stop {
+			t.Fatalf("Expected the callback's error, got %v", err)
+		}
+		if count != 3 {
+			t.Fatalf("Expected iteration to stop after 3 policies, got %d", count)
+		}
+	})
+
+	t.Run("Stop when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if err := m.Iterate(ctx, func(p Policy) error {
+			t.Fatal("Callback should not be called")
+			return nil
+		}); err != context.Canceled {
+			t.Fatalf("Expected context.Canceled, got %v", err)
+		}
+	})
+}
+
+func TestCaseInsensitiveIndex(t *testing.T) {
+	policy := &DefaultPolicy{
+		ID:         "test-policy-1",
+		Subjects:   []string{"user:alice"},
+		Resources:  []string{"Articles:1"},
+		Actions:    []string{"get"},
+		Effect:     AllowAccess,
+		Conditions: Conditions{},
+	}
+	r := &Request{Subject: "User:Alice", Resource: "articles:1", Action: "get"}
+
+	t.Run("Mixed case requests find policies when enabled", func(t *testing.T) {
+		m := NewRedisManager(db, "caseInsensitive", WithCaseInsensitiveIndex())
+		if err := m.Create(policy); err != nil {
+			t.Fatal(err)
+		}
+
+		p, err := m.FindRequestCandidates(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !contains(p, policy) {
+			t.Fatalf("Policy %+v not found in result of FindRequestCandidates", policy)
+		}
+
+		w := &Ladon{Manager: m, Matcher: matcher.NewCaseInsensitiveMatcher(nil)}
+		if err := w.IsAllowed(r); err != nil {
+			t.Fatalf("Expected request to be allowed: %s", err)
+		}
+
+		if err := m.Delete(policy.GetID()); err != nil {
+			t.Fatal(err)
+		}
+		if p, err := m.FindPoliciesForSubject("USER:ALICE"); err != nil {
+			t.Fatal(err)
+		} else if len(p) != 0 {
+			t.Fatalf("Expected the index to be cleaned up, got %+v", p)
+		}
+	})
+
+	t.Run("Mixed case requests miss policies when disabled", func(t *testing.T) {
+		m := NewRedisManager(db, "caseSensitive")
+		if err := m.Create(policy); err != nil {
+			t.Fatal(err)
+		}
+
+		p, err := m.FindRequestCandidates(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if contains(p, policy) {
+			t.Fatalf("Policy %+v was not expected from FindRequestCandidates", policy)
+		}
+	})
+}
+
+func TestWildcardAction(t *testing.T) {
+	m := NewRedisManager(db, "wildcardAction")
+	policies := []*DefaultPolicy{
+		{
+			ID:         "glob-action",
+			Subjects:   []string{"user:alice"},
+			Resources:  []string{"articles:1"},
+			Actions:    []string{"*"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		},
+		{
+			ID:         "regexp-action",
+			Subjects:   []string{"user:bob"},
+			Resources:  []string{"articles:1"},
+			Actions:    []string{"<.*>"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		},
+	}
+	for _, p := range policies {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for k, c := range []struct {
+		policy  *DefaultPolicy
+		matcher matcher.Matcher
+	}{
+		{policy: policies[0], matcher: matcher.NewGlobMatcher()},
+		{policy: policies[1], matcher: DefaultMatcher},
+	} {
+		w := &Ladon{Manager: m, Matcher: c.matcher}
+		for _, action := range []string{"get", "update", "delete", "publish:draft"} {
+			r := &Request{Subject: c.policy.Subjects[0], Resource: "articles:1", Action: action}
+
+			p, err := m.FindRequestCandidates(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !contains(p, c.policy) {
+				t.Fatalf("Case %d: policy %s not found in candidates for action %s", k, c.policy.ID, action)
+			}
+
+			if err := w.IsAllowed(r); err != nil {
+				t.Fatalf("Case %d: expected action %s to be allowed: %s", k, action, err)
+			}
+		}
+	}
+}
+
+func TestSortedResults(t *testing.T) {
+	m := NewRedisManager(db, "sorted")
+	for _, id := range []string{"c", "a", "e", "b", "d"} {
+		if err := m.Create(&DefaultPolicy{
+			ID:         id,
+			Subjects:   []string{"user:alice"},
+			Resources:  []string{"articles:1"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids := func(policies Policies) []string {
+		ids := make([]string, len(policies))
+		for i, p := range policies {
+			ids[i] = p.GetID()
+		}
+		return ids
+	}
+	expected := []string{"a", "b", "c", "d", "e"}
+
+	for k, find := range []func() (Policies, error){
+		func() (Policies, error) { return m.GetAll(100, 0) },
+		func() (Policies, error) { return m.FindPoliciesForSubject("user:alice") },
+		func() (Policies, error) { return m.FindPoliciesForResource("articles:1") },
+		func() (Policies, error) {
+			return m.FindRequestCandidates(&Request{Subject: "user:alice", Resource: "articles:1", Action: "get"})
+		},
+	} {
+		first, err := find()
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := find()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !cmp.Equal(ids(first), ids(second)) {
+			t.Fatalf("Case %d: expected identical calls to return the same order\n%s", k, cmp.Diff(ids(first), ids(second)))
+		}
+		if !cmp.Equal(expected, ids(first)) {
+			t.Fatalf("Case %d: expected policies sorted by ID\n%s", k, cmp.Diff(expected, ids(first)))
+		}
+	}
+}
+
+func TestIDOnlyIndex(t *testing.T) {
+	m := NewRedisManager(db, "idOnly", WithIDOnlyIndex())
+	policies := Policies{
+		&DefaultPolicy{
+			ID:         "test-policy-1",
+			Subjects:   []string{"user:alice", "user:bob"},
+			Resources:  []string{"articles:1", "articles:2"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{"ip": &CIDRCondition{CIDR: "10.0.0.0/8"}},
+			Meta:       []byte(`{"blob":"` + strings.Repeat("x", 1024) + `"}`),
+		},
+		&DefaultPolicy{
+			ID:         "test-policy-2",
+			Subjects:   []string{"<.*>"},
+			Resources:  []string{"articles:1"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		},
+	}
+	for _, p := range policies {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Store IDs only in the indexes", func(t *testing.T) {
+		v, err := db.HGet(m.indexKey(prefixSubject, "user:alice"), "test-policy-1").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != "" {
+			t.Fatalf("Expected an empty index value, got %d bytes", len(v))
+		}
+	})
+
+	t.Run("Find complete policies", func(t *testing.T) {
+		for k, find := range []func() (Policies, error){
+			func() (Policies, error) { return m.FindPoliciesForSubject("user:alice") },
+			func() (Policies, error) { return m.FindPoliciesForResource("articles:2") },
+			func() (Policies, error) {
+				return m.FindRequestCandidates(&Request{Subject: "user:bob", Resource: "articles:1", Action: "get"})
+			},
+		} {
+			p, err := find()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !contains(p, policies[0]) {
+				t.Fatalf("Case %d: policy %+v not found", k, policies[0])
+			}
+		}
+
+		p, err := m.FindRequestCandidates(&Request{Subject: "user:bob", Resource: "articles:1", Action: "get"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(policies, p) {
+			t.Fatalf("Unexpected candidates\n%s", cmp.Diff(policies, p))
+		}
+	})
+
+	t.Run("Reflect updates and deletes", func(t *testing.T) {
+		updated := *policies[0].(*DefaultPolicy)
+		updated.Description = "updated"
+		if err := m.Update(&updated); err != nil {
+			t.Fatal(err)
+		}
+
+		p, err := m.FindPoliciesForResource("articles:2")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !contains(p, &updated) {
+			t.Fatalf("Expected updated policy, got %+v", p)
+		}
+
+		if err := m.Delete(updated.ID); err != nil {
+			t.Fatal(err)
+		}
+		if p, err := m.FindPoliciesForResource("articles:2"); err != nil {
+			t.Fatal(err)
+		} else if len(p) != 0 {
+			t.Fatalf("Expected no policies after delete, got %+v", p)
+		}
+	})
+}
+
+func TestFindPoliciesByDescription(t *testing.T) {
+	m := NewRedisManager(db, "description")
+	policies := Policies{}
+	for id, description := range map[string]string{
+		"a": "Allow editors to publish Articles",
+		"b": "Deny access to archived articles",
+		"c": "Allow reading users",
+		"d": "",
+	} {
+		p := &DefaultPolicy{ID: id, Description: description, Conditions: Conditions{}}
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+		policies = append(policies, p)
+	}
+	sortByID(policies)
+
+	for k, c := range []struct {
+		substring string
+		expected  Policies
+	}{
+		{substring: "articles", expected: Policies{policies[0], policies[1]}},
+		{substring: "ALLOW", expected: Policies{policies[0], policies[2]}},
+		{substring: "nothing", expected: Policies{}},
+		{substring: "", expected: policies},
+	} {
+		p, err := m.FindPoliciesByDescription(c.substring)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(c.expected, p) {
+			t.Errorf("Case %d: unexpected policies\n%s", k, cmp.Diff(c.expected, p))
+		}
+	}
+}
+
+func TestListSubjectsAndResources(t *testing.T) {
+	m := NewRedisManager(db, "list")
+	for _, p := range []*DefaultPolicy{
+		{ID: "a", Subjects: []string{"user:bob", "user:alice"}, Resources: []string{"articles:1"}, Conditions: Conditions{}},
+		{ID: "b", Subjects: []string{"user:alice", "<.*>"}, Resources: []string{"articles:1", "articles:2"}, Conditions: Conditions{}},
+		{ID: "c", Subjects: []string{""}, Conditions: Conditions{}},
+	} {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	subjects, err := m.ListSubjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"<.*>", "user:alice", "user:bob"}; !cmp.Equal(expected, subjects) {
+		t.Fatalf("Unexpected subjects\n%s", cmp.Diff(expected, subjects))
+	}
+
+	resources, err := m.ListResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"articles:1", "articles:2"}; !cmp.Equal(expected, resources) {
+		t.Fatalf("Unexpected resources\n%s", cmp.Diff(expected, resources))
+	}
+
+	if err := m.Delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	subjects, err = m.ListSubjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"<.*>", "user:alice"}; !cmp.Equal(expected, subjects) {
+		t.Fatalf("Unexpected subjects after delete\n%s", cmp.Diff(expected, subjects))
+	}
+}
+
+func TestDisabledIndex(t *testing.T) {
+	policy := &DefaultPolicy{
+		ID:         "test-policy-1",
+		Subjects:   []string{"user:alice"},
+		Resources:  []string{"articles:1"},
+		Actions:    []string{"get"},
+		Effect:     AllowAccess,
+		Conditions: Conditions{},
+	}
+	updated := &DefaultPolicy{
+		ID:         "test-policy-1",
+		Subjects:   []string{"user:alice"},
+		Resources:  []string{"articles:1"},
+		Actions:    []string{"get", "update"},
+		Effect:     AllowAccess,
+		Conditions: Conditions{},
+	}
+
+	for k, tc := range []struct {
+		option   Option
+		disabled string
+		enabled  string
+		find     func(m *RedisManager) (Policies, error)
+		list     func(m *RedisManager) ([]string, error)
+	}{
+		{
+			option:   WithoutResourceIndex(),
+			disabled: prefixResource,
+			enabled:  prefixSubject,
+			find:     func(m *RedisManager) (Policies, error) { return m.FindPoliciesForResource("articles:1") },
+			list:     func(m *RedisManager) ([]string, error) { return m.ListResources() },
+		},
+		{
+			option:   WithoutSubjectIndex(),
+			disabled: prefixSubject,
+			enabled:  prefixResource,
+			find:     func(m *RedisManager) (Policies, error) { return m.FindPoliciesForSubject("user:alice") },
+			list:     func(m *RedisManager) ([]string, error) { return m.ListSubjects() },
+		},
+	} {
+		m := NewRedisManager(db, fmt.Sprintf("disabledIndex%d", k), tc.option)
+
+		if err := m.Create(policy); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.Update(updated); err != nil {
+			t.Fatal(err)
+		}
+
+		disabled, err := m.keysMatching(m.key(tc.disabled, "*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(disabled) > 0 {
+			t.Errorf("Case %d: expected no %s index, got %v", k, tc.disabled, disabled)
+		}
+		enabled, err := m.keysMatching(m.key(tc.enabled, "*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(enabled) != 1 {
+			t.Errorf("Case %d: expected one %s index key, got %v", k, tc.enabled, enabled)
+		}
+
+		if _, err := tc.find(m); errors.Cause(err) != ErrIndexDisabled {
+			t.Errorf("Case %d: expected ErrIndexDisabled from Find, got %v", k, err)
+		}
+		if _, err := tc.list(m); errors.Cause(err) != ErrIndexDisabled {
+			t.Errorf("Case %d: expected ErrIndexDisabled from List, got %v", k, err)
+		}
+		if _, err := m.FindRequestCandidates(&Request{Subject: "user:alice", Resource: "articles:1"}); errors.Cause(err) != ErrIndexDisabled {
+			t.Errorf("Case %d: expected ErrIndexDisabled from FindRequestCandidates, got %v", k, err)
+		}
+
+		if err := m.Delete(policy.ID); err != nil {
+			t.Fatal(err)
+		}
+		enabled, err = m.keysMatching(m.key(tc.enabled, "*"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(enabled) != 0 {
+			t.Errorf("Case %d: expected the %s index to be empty after Delete, got %v", k, tc.enabled, enabled)
+		}
+	}
+}
+
+func TestNotFoundVersusConnectionError(t *testing.T) {
+	policy := &DefaultPolicy{
+		ID:         "test-policy-1",
+		Subjects:   []string{"user:alice"},
+		Resources:  []string{"articles:1"},
+		Actions:    []string{"get"},
+		Effect:     AllowAccess,
+		Conditions: Conditions{},
+	}
+	if err := NewRedisManager(db, "notFound").Create(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	unreachable := errors.New("dial tcp: connection refused")
+	for k, tc := range []struct {
+		m    *RedisManager
+		id   string
+		want error
+	}{
+		{m: NewRedisManager(db, "notFound"), id: "missing", want: ErrNotFound},
+		{m: NewRedisManager(&flakyClient{UniversalClient: db, err: unreachable, failures: 1 << 30}, "notFound"), id: policy.ID, want: unreachable},
+	} {
+		if _, err := tc.m.Get(tc.id); errors.Cause(err) != tc.want {
+			t.Errorf("Case %d: expected Get to return %v, got %v", k, tc.want, err)
+		}
+		if err := tc.m.Delete(tc.id); errors.Cause(err) != tc.want {
+			t.Errorf("Case %d: expected Delete to return %v, got %v", k, tc.want, err)
+		}
+		if err := tc.m.Update(&DefaultPolicy{ID: tc.id}); errors.Cause(err) != tc.want {
+			t.Errorf("Case %d: expected Update to return %v, got %v", k, tc.want, err)
+		}
+	}
+
+	for k, tc := range []struct {
+		m      *RedisManager
+		id     string
+		exists bool
+		want   error
+	}{
+		{m: NewRedisManager(db, "notFound"), id: policy.ID, exists: true},
+		{m: NewRedisManager(db, "notFound"), id: "missing", exists: false},
+		{m: NewRedisManager(&flakyClient{UniversalClient: db, err: unreachable, failures: 1 << 30}, "notFound"), id: policy.ID, want: unreachable},
+	} {
+		if exists, err := tc.m.Exists(tc.id); errors.Cause(err) != tc.want || exists != tc.exists {
+			t.Errorf("Case %d: expected Exists to return %t, %v, got %t, %v", k, tc.exists, tc.want, exists, err)
+		}
+	}
+
+	if _, err := NewRedisManager(db, "notFound").Get(policy.ID); err != nil {
+		t.Fatalf("Expected the policy to survive the outage, got %v", err)
+	}
+}
+
+func TestPolicyTooLarge(t *testing.T) {
+	m := NewRedisManager(db, "tooLarge", WithMaxPolicySize(1024))
+	policy := &DefaultPolicy{
+		ID:         "test-policy-1",
+		Subjects:   []string{"user:alice"},
+		Resources:  []string{"articles:1"},
+		Actions:    []string{"get"},
+		Effect:     AllowAccess,
+		Conditions: Conditions{},
+	}
+	if err := m.Create(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	oversized := &DefaultPolicy{
+		ID:         "test-policy-2",
+		Subjects:   []string{"user:bob"},
+		Actions:    []string{"get"},
+		Effect:     AllowAccess,
+		Conditions: Conditions{},
+	}
+	for i := 0; i < 100; i++ {
+		oversized.Resources = append(oversized.Resources, fmt.Sprintf("articles:%d", i))
+	}
+
+	keys, err := m.keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(keys)
+
+	for k, write := range []func() error{
+		func() error { return m.Create(oversized) },
+		func() error {
+			grown := *policy
+			grown.Resources = oversized.Resources
+			return m.Update(&grown)
+		},
+	} {
+		err := write()
+		if errors.Cause(err) != ErrPolicyTooLarge {
+			t.Fatalf("Case %d: expected ErrPolicyTooLarge, got %v", k, err)
+		}
+		var pe *PolicyError
+		if !errors.As(err, &pe) {
+			t.Fatalf("Case %d: expected a PolicyError, got %T", k, err)
+		}
+
+		after, err := m.keys()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(after)
+		if !cmp.Equal(keys, after) {
+			t.Fatalf("Case %d: expected no keys to be written\n%s", k, cmp.Diff(keys, after))
+		}
+	}
+
+	p, err := m.Get(policy.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(policy, withoutTimestamps(p)) {
+		t.Fatalf("Expected the policy to be unchanged\n%s", cmp.Diff(policy, withoutTimestamps(p)))
+	}
+
+	if err := NewRedisManager(db, "tooLarge", WithMaxPolicySize(0)).Create(oversized); err != nil {
+		t.Fatalf("Expected no limit, got %v", err)
+	}
+}
+
+func TestSetIndex(t *testing.T) {
+	m := NewRedisManager(db, "setIndex", WithSetIndex())
+	policies := Policies{
+		&DefaultPolicy{
+			ID:         "test-policy-1",
+			Subjects:   []string{"user:alice", "user:bob"},
+			Resources:  []string{"articles:1", "articles:2"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{"ip": &CIDRCondition{CIDR: "10.0.0.0/8"}},
+		},
+		&DefaultPolicy{
+			ID:         "test-policy-2",
+			Subjects:   []string{"<.*>"},
+			Resources:  []string{"articles:1"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		},
+	}
+	for _, p := range policies {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Store IDs in sets", func(t *testing.T) {
+		key := m.indexKey(prefixResource, "articles:1")
+		if typ, err := db.Type(key).Result(); err != nil {
+			t.Fatal(err)
+		} else if typ != "set" {
+			t.Fatalf("Expected %s to be a set, got %s", key, typ)
+		}
+
+		ids, err := db.SMembers(key).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(ids)
+		if expected := []string{"test-policy-1", "test-policy-2"}; !cmp.Equal(expected, ids) {
+			t.Fatalf("Unexpected members\n%s", cmp.Diff(expected, ids))
+		}
+	})
+
+	t.Run("Find complete policies", func(t *testing.T) {
+		for k, c := range []struct {
+			find     func() (Policies, error)
+			expected Policies
+		}{
+			{find: func() (Policies, error) { return m.FindPoliciesForSubject("user:alice") }, expected: policies[:1]},
+			{find: func() (Policies, error) { return m.FindPoliciesForResource("articles:1") }, expected: policies},
+			{
+				find: func() (Policies, error) {
+					return m.FindRequestCandidates(&Request{Subject: "user:bob", Resource: "articles:1", Action: "get"})
+				},
+				expected: policies,
+			},
+			{
+				find: func() (Policies, error) {
+					batch, err := m.FindRequestCandidatesBatch([]*Request{{Subject: "user:carol", Resource: "articles:1"}})
+					if err != nil {
+						return nil, err
+					}
+					return batch[0], nil
+				},
+				expected: policies[1:],
+			},
+		} {
+			p, err := c.find()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(c.expected, p) {
+				t.Errorf("Case %d: unexpected policies\n%s", k, cmp.Diff(c.expected, p))
+			}
+		}
+	})
+
+	t.Run("Repair the sets", func(t *testing.T) {
+		if err := db.SRem(m.indexKey(prefixSubject, "user:bob"), "test-policy-1").Err(); err != nil {
+			t.Fatal(err)
+		}
+		report, err := m.RepairIndexes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := &RepairReport{Missing: []IndexEntry{{Key: m.indexKey(prefixSubject, "user:bob"), PolicyID: "test-policy-1"}}}
+		if !cmp.Equal(expected, report) {
+			t.Fatalf("Unexpected report\n%s", cmp.Diff(expected, report))
+		}
+	})
+
+	t.Run("Remove deleted policies from the sets", func(t *testing.T) {
+		if err := m.Delete("test-policy-1"); err != nil {
+			t.Fatal(err)
+		}
+		p, err := m.FindPoliciesForResource("articles:1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(policies[1:], p) {
+			t.Fatalf("Unexpected policies after delete\n%s", cmp.Diff(policies[1:], p))
+		}
+		if n, err := db.Exists(m.indexKey(prefixSubject, "user:alice")).Result(); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Fatal("Expected the empty set to be removed")
+		}
+	})
+}
+
+func TestEmptyMatchesAll(t *testing.T) {
+	policies := Policies{
+		&DefaultPolicy{ID: "global", Effect: AllowAccess, Conditions: Conditions{}},
+		&DefaultPolicy{ID: "any-subject", Resources: []string{"articles:1"}, Effect: DenyAccess, Conditions: Conditions{}},
+		&DefaultPolicy{ID: "any-resource", Subjects: []string{"user:bob"}, Actions: []string{"get"}, Effect: DenyAccess, Conditions: Conditions{}},
+	}
+	requests := []*Request{
+		{Subject: "user:alice", Resource: "articles:2", Action: "get"},
+		{Subject: "service:billing", Resource: "invoices:7", Action: "delete"},
+		{Subject: "user:alice", Resource: "articles:1", Action: "get"},
+		{Subject: "user:bob", Resource: "articles:2", Action: "get"},
+		{Subject: "user:bob", Resource: "articles:2", Action: "update"},
+	}
+
+	for k, c := range []struct {
+		opts     []Option
+		matcher  matcher.Matcher
+		allowed  []bool
+		subjects []string
+	}{
+		{
+			opts:     []Option{WithEmptyMatchesAll()},
+			matcher:  matcher.NewEmptyMatchesAllMatcher(nil),
+			allowed:  []bool{true, true, false, false, true},
+			subjects: []string{"user:bob"},
+		},
+		{
+			matcher:  DefaultMatcher,
+			allowed:  []bool{false, false, false, false, false},
+			subjects: []string{"user:bob"},
+		},
+	} {
+		m := NewRedisManager(db, fmt.Sprintf("empty_matches_all%d", k), c.opts...)
+		for _, p := range policies {
+			if err := m.Create(p); err != nil {
+				t.Fatalf("Case %d: %s", k, err)
+			}
+		}
+		w := &Ladon{Manager: m, Matcher: c.matcher}
+
+		for i, r := range requests {
+			if err := w.IsAllowed(r); (err == nil) != c.allowed[i] {
+				t.Errorf("Case %d/%d: expected allowed to be %t, got error %v", k, i, c.allowed[i], err)
+			}
+		}
+
+		if c.opts != nil {
+			found, err := m.FindPoliciesForSubject("user:carol")
+			if err != nil {
+				t.Fatalf("Case %d: %s", k, err)
+			}
+			if !cmp.Equal(Policies{policies[1], policies[0]}, found) {
+				t.Errorf("Case %d: expected the policies without subjects\n%s", k, cmp.Diff(Policies{policies[1], policies[0]}, found))
+			}
+			batch, err := m.FindRequestCandidatesBatch(requests[:1])
+			if err != nil {
+				t.Fatalf("Case %d: %s", k, err)
+			}
+			if !cmp.Equal(Policies{policies[0]}, batch[0]) {
+				t.Errorf("Case %d: unexpected batch candidates\n%s", k, cmp.Diff(Policies{policies[0]}, batch[0]))
+			}
+		}
+
+		subjects, err := m.ListSubjects()
+		if err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+		if !cmp.Equal(c.subjects, subjects) {
+			t.Errorf("Case %d: unexpected subjects\n%s", k, cmp.Diff(c.subjects, subjects))
+		}
+	}
+}
+
+func TestLenientConditions(t *testing.T) {
+	stored := `{"id":"rolled-back","subjects":["user:alice"],"resources":["articles:1"],"actions":["get"],"effect":"allow","conditions":{"risk":{"type":"RiskScoreCondition","options":{"max":0.3}}}}`
+
+	for k, c := range []struct {
+		opts    []Option
+		lenient bool
+	}{
+		{opts: nil, lenient: false},
+		{opts: []Option{WithLenientConditions()}, lenient: true},
+	} {
+		m := NewRedisManager(db, fmt.Sprintf("lenient%d", k), c.opts...)
+		if err := m.Create(&DefaultPolicy{ID: "rolled-back", Subjects: []string{"user:alice"}, Resources: []string{"articles:1"}, Conditions: Conditions{}}); err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+		// Overwrite the policy as a newer release would have written it.
+		if err := db.Set(m.key(prefixPolicy, "rolled-back"), stored, 0).Err(); err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+		for _, key := range []string{m.indexKey(prefixSubject, "user:alice"), m.indexKey(prefixResource, "articles:1")} {
+			if err := db.HSet(key, "rolled-back", stored).Err(); err != nil {
+				t.Fatalf("Case %d: %s", k, err)
+			}
+		}
+
+		p, err := m.Get("rolled-back")
+		if !c.lenient {
+			if !errors.Is(err, ErrBadConversion) {
+				t.Fatalf("Case %d: expected %v, got %v", k, ErrBadConversion, err)
+			}
+			if _, err := m.FindRequestCandidates(&Request{Subject: "user:alice", Resource: "articles:1"}); !errors.Is(err, ErrBadConversion) {
+				t.Fatalf("Case %d: expected %v, got %v", k, ErrBadConversion, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+		if _, ok := p.GetConditions()["risk"].(*condition.UnknownCondition); !ok {
+			t.Fatalf("Case %d: expected an UnknownCondition, got %T", k, p.GetConditions()["risk"])
+		}
+
+		w := &Ladon{Manager: m}
+		if err := w.IsAllowed(&Request{Subject: "user:alice", Resource: "articles:1", Action: "get", Context: Context{"risk": 0.1}}); err == nil {
+			t.Fatalf("Case %d: expected the unknown condition to deny the request", k)
+		}
+		if err := m.Delete("rolled-back"); err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+	}
+}
+
+func TestDuplicateValues(t *testing.T) {
+	m := NewRedisManager(db, "duplicate_values")
+	policy := &DefaultPolicy{
+		ID:         "duplicates",
+		Subjects:   []string{"user:alice", "user:bob", "user:alice"},
+		Resources:  []string{"articles:1", "articles:1"},
+		Actions:    []string{"get", "get"},
+		Effect:     AllowAccess,
+		Conditions: Conditions{},
+	}
+	expected := &DefaultPolicy{
+		ID:         "duplicates",
+		Subjects:   []string{"user:alice", "user:bob"},
+		Resources:  []string{"articles:1"},
+		Actions:    []string{"get"},
+		Effect:     AllowAccess,
+		Conditions: Conditions{},
+	}
+
+	for k, write := range []func(Policy) error{m.Create, m.Update} {
+		if err := write(policy); err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+
+		stored, err := m.Get("duplicates")
+		if err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+		if !cmp.Equal(expected, withoutTimestamps(stored)) {
+			t.Errorf("Case %d: expected duplicates to be removed\n%s", k, cmp.Diff(expected, withoutTimestamps(stored)))
+		}
+
+		candidates, err := m.FindRequestCandidates(&Request{Subject: "user:alice", Resource: "articles:1", Action: "get"})
+		if err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+		if !cmp.Equal(Policies{expected}, candidates) {
+			t.Errorf("Case %d: expected the policy once\n%s", k, cmp.Diff(Policies{expected}, candidates))
+		}
+
+		for _, key := range []string{m.indexKey(prefixSubject, "user:alice"), m.indexKey(prefixResource, "articles:1")} {
+			if n, err := db.HLen(key).Result(); err != nil {
+				t.Fatalf("Case %d: %s", k, err)
+			} else if n != 1 {
+				t.Errorf("Case %d: expected %s to index the policy once, got %d fields", k, key, n)
+			}
+		}
+	}
+
+	if len(policy.Subjects) != 3 {
+		t.Errorf("expected the passed policy to be left untouched, got subjects %v", policy.Subjects)
+	}
+}
+
+func TestPatternCharacters(t *testing.T) {
+	policies := Policies{
+		&DefaultPolicy{
+			ID:         "glob-subject",
+			Subjects:   []string{"user:?"},
+			Resources:  []string{"articles:1"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		},
+		&DefaultPolicy{
+			ID:         "glob-resource",
+			Subjects:   []string{"alice"},
+			Resources:  []string{"articles:*"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		},
+		&DefaultPolicy{
+			ID:         "segments",
+			Subjects:   []string{"tenant:*|role:admin"},
+			Resources:  []string{"reports:1"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		},
+	}
+
+	for k, c := range []struct {
+		matcher matcher.Matcher
+		chars   string
+		request *Request
+	}{
+		{matcher: matcher.NewGlobMatcher(), chars: matcher.GlobPatternCharacters, request: &Request{Subject: "user:a", Resource: "articles:1", Action: "get"}},
+		{matcher: matcher.NewGlobMatcher(), chars: matcher.GlobPatternCharacters, request: &Request{Subject: "alice", Resource: "articles:2", Action: "get"}},
+		{matcher: matcher.NewSegmentMatcher(nil), chars: matcher.SegmentPatternCharacters, request: &Request{Subject: "role:admin|tenant:acme", Resource: "reports:1", Action: "get"}},
+	} {
+		for _, allowed := range []bool{false, true} {
+			var opts []Option
+			if allowed {
+				opts = append(opts, WithPatternCharacters(c.chars))
+			}
+
+			m := NewRedisManager(db, fmt.Sprintf("pattern%d_%t", k, allowed), opts...)
+			for _, p := range policies {
+				if err := m.Create(p); err != nil {
+					t.Fatal(err)
+				}
+			}
+			w := &Ladon{Manager: m, Matcher: c.matcher}
+			if err := w.IsAllowed(c.request); (err == nil) != allowed {
+				t.Errorf("Case %d: expected allowed to be %t, got error %v", k, allowed, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/manager"
+	"github.com/pkg/errors"
+)
+
+// Timestamps records when a policy was created and last changed.
+type Timestamps = manager.Timestamps
+
+// GetTimestamps returns when the policy was created and last changed, without decoding the policy. The
+// timestamps are set by Create, Update and UpdateIfMatch and stored in the policy's JSON next to its
+// fields. Get, GetAll and GetAllPaginated return them too, as a *manager.TimestampedPolicy.
+//
+// Policies written before timestamps were tracked have zero timestamps. ReplaceAll creates all policies
+// anew and thus resets their CreatedAt.
+func (m *RedisManager) GetTimestamps(id string) (*Timestamps, error) {
+	v, err := m.db.Get(m.key(prefixPolicy, id)).Result()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return decodeTimestamps(id, v)
+}
+
+func decodeTimestamps(id string, v string) (*Timestamps, error) {
+	ts := &Timestamps{}
+	if err := json.Unmarshal([]byte(v), ts); err != nil {
+		return nil, &PolicyError{ID: id, Err: errors.Wrap(ErrBadConversion, err.Error())}
+	}
+	return ts, nil
+}
+
+// decodeTimestampedPolicy unmarshals the policy with the given ID stored in Redis together with its
+// timestamps.
+func (m *RedisManager) decodeTimestampedPolicy(id string, v string) (*manager.TimestampedPolicy, error) {
+	p, err := m.decodePolicy(id, v)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := decodeTimestamps(id, v)
+	if err != nil {
+		return nil, err
+	}
+	return &manager.TimestampedPolicy{DefaultPolicy: p, Timestamps: *ts}, nil
+}
+
+// encode returns the JSON stored for policy, created at the given time and changed now. The timestamps
+// are appended to the policy's fields and thus override any the policy itself marshals.
+func (m *RedisManager) encode(policy Policy, created time.Time) ([]byte, error) {
+	p, err := json.Marshal(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	now := m.now().UTC()
+	if created.IsZero() {
+		created = now
+	}
+	ts, err := json.Marshal(&Timestamps{CreatedAt: created, UpdatedAt: now})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(p) < 2 || p[0] != '{' || p[len(p)-1] != '}' {
+		return nil, errors.Errorf("policy %s doesn't marshal to a JSON object", policy.GetID())
+	}
+	if len(p) > 2 {
+		ts[0] = ','
+	}
+	return append(p[:len(p)-1], ts...), nil
+}
+
+func (m *RedisManager) now() time.Time {
+	if m.clock != nil {
+		return m.clock()
+	}
+	return time.Now()
+}
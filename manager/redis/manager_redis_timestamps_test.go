@@ -0,0 +1,101 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/manager"
+)
+
+func TestTimestamps(t *testing.T) {
+	var (
+		created = time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+		now     = created
+		m       = NewRedisManager(db, "timestamps")
+	)
+	m.clock = func() time.Time { return now }
+
+	policy := &DefaultPolicy{
+		ID:         "test-policy-1",
+		Subjects:   []string{"user:alice"},
+		Resources:  []string{"articles:1"},
+		Actions:    []string{"get"},
+		Effect:     AllowAccess,
+		Conditions: Conditions{},
+	}
+	if err := m.Create(policy); err != nil {
+		t.Fatal(err)
+	}
+
+	expectTimestamps := func(t *testing.T, expected Timestamps) {
+		ts, err := m.GetTimestamps(policy.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cmp.Equal(&expected, ts) {
+			t.Fatalf("Unexpected timestamps\n%s", cmp.Diff(&expected, ts))
+		}
+
+		p, err := m.Get(policy.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		all, err := m.GetAll(10, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paginated, _, err := m.GetAllPaginated("", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, got := range []Policy{p, all[0], paginated[0]} {
+			if expected := (&manager.TimestampedPolicy{DefaultPolicy: policy, Timestamps: expected}); !cmp.Equal(expected, got) {
+				t.Fatalf("Unexpected policy\n%s", cmp.Diff(expected, got))
+			}
+		}
+	}
+
+	t.Run("Set both timestamps on Create", func(t *testing.T) {
+		expectTimestamps(t, Timestamps{CreatedAt: created, UpdatedAt: created})
+	})
+
+	t.Run("Advance UpdatedAt on Update", func(t *testing.T) {
+		now = created.Add(time.Hour)
+		if err := m.Update(policy); err != nil {
+			t.Fatal(err)
+		}
+		expectTimestamps(t, Timestamps{CreatedAt: created, UpdatedAt: now})
+
+		_, tag, err := m.GetWithETag(policy.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		now = created.Add(2 * time.Hour)
+		if err := m.UpdateIfMatch(policy, tag); err != nil {
+			t.Fatal(err)
+		}
+		expectTimestamps(t, Timestamps{CreatedAt: created, UpdatedAt: now})
+	})
+
+	t.Run("Ignore timestamps marshalled by the policy", func(t *testing.T) {
+		now = created.Add(3 * time.Hour)
+		p, err := m.Get(policy.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stale := p.(*manager.TimestampedPolicy)
+		stale.CreatedAt = time.Time{}
+		if err := m.Update(stale); err != nil {
+			t.Fatal(err)
+		}
+		expectTimestamps(t, Timestamps{CreatedAt: created, UpdatedAt: now})
+	})
+
+	t.Run("Missing policy", func(t *testing.T) {
+		if _, err := m.GetTimestamps("does-not-exist"); err != ErrNotFound {
+			t.Fatalf("Expected %s, got %v", ErrNotFound, err)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+// Package sharded contains a manager decorator that distributes policies across several managers.
+package sharded
+
+import (
+	"hash/fnv"
+	"sort"
+	"sync"
+
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// ShardFunc returns the index of the shard, between 0 and n-1, storing the policy with the given ID.
+type ShardFunc func(id string, n int) int
+
+// HashShard distributes policies evenly by the FNV-1a hash of their ID.
+func HashShard(id string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return int(h.Sum32() % uint32(n))
+}
+
+// ShardedManager distributes policies across several managers, e.g. one RedisManager per Redis server.
+// Get, Create, Update and Delete are routed to the shard chosen by the shard function for the policy's
+// ID, so each policy and its index entries live on exactly one shard. Find* query all shards in parallel
+// and merge their results, sorted by ID.
+//
+// The shard function must not change while policies are stored, and neither may the number of shards,
+// or policies can't be found anymore.
+type ShardedManager struct {
+	shards []Manager
+	shard  ShardFunc
+}
+
+// NewShardedManager initializes a new ShardedManager distributing policies across shards using shard.
+// Passing a nil shard function uses HashShard.
+func NewShardedManager(shard ShardFunc, shards ...Manager) *ShardedManager {
+	if shard == nil {
+		shard = HashShard
+	}
+	return &ShardedManager{shards: shards, shard: shard}
+}
+
+func (m *ShardedManager) manager(id string) Manager {
+	return m.shards[m.shard(id, len(m.shards))]
+}
+
+// Create persists the policy on its shard.
+func (m *ShardedManager) Create(policy Policy) error {
+	return m.manager(policy.GetID()).Create(policy)
+}
+
+// Update updates an existing policy on its shard.
+func (m *ShardedManager) Update(policy Policy) error {
+	return m.manager(policy.GetID()).Update(policy)
+}
+
+// Get retrieves a policy from its shard.
+func (m *ShardedManager) Get(id string) (Policy, error) {
+	return m.manager(id).Get(id)
+}
+
+// Delete removes a policy from its shard.
+func (m *ShardedManager) Delete(id string) error {
+	return m.manager(id).Delete(id)
+}
+
+// GetAll retrieves all policies, sorted by ID. Each shard has to return its policies sorted by ID, as
+// the managers of this repository and ladon's memory manager do. To find the requested page, the first
+// offset+limit policies of every shard are read.
+func (m *ShardedManager) GetAll(limit, offset int64) (Policies, error) {
+	if limit <= 0 || offset < 0 {
+		return Policies{}, nil
+	}
+
+	policies, err := m.fanOut(func(shard Manager) (Policies, error) {
+		return shard.GetAll(offset+limit, 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if offset >= int64(len(policies)) {
+		return Policies{}, nil
+	}
+	policies = policies[offset:]
+	if limit < int64(len(policies)) {
+		policies = policies[:limit]
+	}
+	return policies, nil
+}
+
+// FindRequestCandidates returns the candidates of all shards, sorted by ID.
+func (m *ShardedManager) FindRequestCandidates(r *Request) (Policies, error) {
+	return m.fanOut(func(shard Manager) (Policies, error) {
+		return shard.FindRequestCandidates(r)
+	})
+}
+
+// FindPoliciesForSubject returns the policies of all shards that could match the subject, sorted by ID.
+func (m *ShardedManager) FindPoliciesForSubject(subject string) (Policies, error) {
+	return m.fanOut(func(shard Manager) (Policies, error) {
+		return shard.FindPoliciesForSubject(subject)
+	})
+}
+
+// FindPoliciesForResource returns the policies of all shards that could match the resource, sorted by ID.
+func (m *ShardedManager) FindPoliciesForResource(resource string) (Policies, error) {
+	return m.fanOut(func(shard Manager) (Policies, error) {
+		return shard.FindPoliciesForResource(resource)
+	})
+}
+
+// fanOut calls find on all shards in parallel and merges the results, dropping duplicate IDs. If a shard
+// fails, the error of the first failing shard is returned.
+func (m *ShardedManager) fanOut(find func(shard Manager) (Policies, error)) (Policies, error) {
+	var (
+		wg      sync.WaitGroup
+		results = make([]Policies, len(m.shards))
+		errs    = make([]error, len(m.shards))
+	)
+	for i, shard := range m.shards {
+		wg.Add(1)
+		go func(i int, shard Manager) {
+			defer wg.Done()
+			results[i], errs[i] = find(shard)
+		}(i, shard)
+	}
+	wg.Wait()
+
+	policies := Policies{}
+	seen := map[string]bool{}
+	for i, err := range errs {
+		if err != nil {
+			return nil, errors.Wrapf(err, "shard %d", i)
+		}
+		for _, p := range results[i] {
+			if !seen[p.GetID()] {
+				seen[p.GetID()] = true
+				policies = append(policies, p)
+			}
+		}
+	}
+
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].GetID() < policies[j].GetID()
+	})
+	return policies, nil
+}
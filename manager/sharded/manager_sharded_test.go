@@ -0,0 +1,150 @@
+package sharded
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+// byPrefix stores policies whose ID starts with "a" on the first shard and all others on the second.
+func byPrefix(id string, n int) int {
+	if id != "" && id[0] == 'a' {
+		return 0
+	}
+	return 1
+}
+
+type failingManager struct {
+	Manager
+}
+
+func (failingManager) FindPoliciesForSubject(string) (Policies, error) {
+	return nil, errors.New("shard unavailable")
+}
+
+func TestShardedManager(t *testing.T) {
+	var (
+		first  = memory.NewMemoryManager()
+		second = memory.NewMemoryManager()
+		m      = NewShardedManager(byPrefix, first, second)
+	)
+
+	policies := Policies{
+		&DefaultPolicy{ID: "a1", Subjects: []string{"alice"}, Resources: []string{"articles:1"}, Actions: []string{"get"}, Effect: AllowAccess},
+		&DefaultPolicy{ID: "a2", Subjects: []string{"bob"}, Resources: []string{"articles:2"}, Actions: []string{"get"}, Effect: AllowAccess},
+		&DefaultPolicy{ID: "b1", Subjects: []string{"alice"}, Resources: []string{"articles:2"}, Actions: []string{"get"}, Effect: AllowAccess},
+		&DefaultPolicy{ID: "b2", Subjects: []string{"bob"}, Resources: []string{"articles:1"}, Actions: []string{"get"}, Effect: DenyAccess},
+	}
+	for _, p := range policies {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("Route writes by ID", func(t *testing.T) {
+		for k, c := range []struct {
+			shard *memory.MemoryManager
+			ids   []string
+		}{
+			{shard: first, ids: []string{"a1", "a2"}},
+			{shard: second, ids: []string{"b1", "b2"}},
+		} {
+			if len(c.shard.Policies) != len(c.ids) {
+				t.Errorf("Case %d: expected %d policies, got %d", k, len(c.ids), len(c.shard.Policies))
+			}
+			for _, id := range c.ids {
+				if _, ok := c.shard.Policies[id]; !ok {
+					t.Errorf("Case %d: expected policy %s on the shard", k, id)
+				}
+			}
+		}
+
+		updated := *policies[2].(*DefaultPolicy)
+		updated.Description = "updated"
+		if err := m.Update(&updated); err != nil {
+			t.Fatal(err)
+		}
+		if p, err := second.Get("b1"); err != nil {
+			t.Fatal(err)
+		} else if p.GetDescription() != "updated" {
+			t.Fatalf("Expected the policy to be updated on its shard, got %+v", p)
+		}
+		if p, err := m.Get("b1"); err != nil {
+			t.Fatal(err)
+		} else if p.GetDescription() != "updated" {
+			t.Fatalf("Expected Get to read the updated policy, got %+v", p)
+		}
+		if err := m.Update(policies[2]); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("Merge results of all shards", func(t *testing.T) {
+		// ladon's memory manager returns all of its policies as candidates.
+		for k, c := range []struct {
+			find     func() (Policies, error)
+			expected Policies
+		}{
+			{find: func() (Policies, error) { return m.FindPoliciesForSubject("alice") }, expected: policies},
+			{find: func() (Policies, error) { return m.FindPoliciesForResource("articles:1") }, expected: policies},
+			{find: func() (Policies, error) { return m.FindRequestCandidates(&Request{Subject: "bob"}) }, expected: policies},
+			{find: func() (Policies, error) { return m.GetAll(10, 0) }, expected: policies},
+			{find: func() (Policies, error) { return m.GetAll(2, 1) }, expected: policies[1:3]},
+			{find: func() (Policies, error) { return m.GetAll(2, 3) }, expected: policies[3:]},
+			{find: func() (Policies, error) { return m.GetAll(2, 4) }, expected: Policies{}},
+		} {
+			p, err := c.find()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(c.expected, p) {
+				t.Errorf("Case %d: unexpected policies\n%s", k, cmp.Diff(c.expected, p))
+			}
+		}
+	})
+
+	t.Run("Work with the warden", func(t *testing.T) {
+		w := &Ladon{Manager: m}
+		if err := w.IsAllowed(&Request{Subject: "alice", Resource: "articles:2", Action: "get"}); err != nil {
+			t.Fatalf("Expected request to be allowed: %s", err)
+		}
+		if err := w.IsAllowed(&Request{Subject: "bob", Resource: "articles:1", Action: "get"}); errors.Cause(err) != ErrRequestForcefullyDenied {
+			t.Fatalf("Expected the deny policy on the second shard to win, got %v", err)
+		}
+	})
+
+	t.Run("Delete from the shard", func(t *testing.T) {
+		if err := m.Delete("a1"); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := first.Policies["a1"]; ok {
+			t.Fatal("Expected policy a1 to be deleted")
+		}
+	})
+
+	t.Run("Fail if a shard fails", func(t *testing.T) {
+		m := NewShardedManager(nil, first, failingManager{second})
+		if _, err := m.FindPoliciesForSubject("alice"); err == nil {
+			t.Fatal("Expected an error")
+		}
+	})
+}
+
+func TestHashShard(t *testing.T) {
+	counts := make([]int, 4)
+	for i := 0; i < 1000; i++ {
+		shard := HashShard(string(rune('a'+i%26))+string(rune('a'+i/26)), len(counts))
+		if shard < 0 || shard >= len(counts) {
+			t.Fatalf("Shard %d out of range", shard)
+		}
+		counts[shard]++
+	}
+	for k, n := range counts {
+		if n < 150 {
+			t.Errorf("Case %d: expected policies to be spread evenly, got %v", k, counts)
+		}
+	}
+}
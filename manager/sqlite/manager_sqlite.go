@@ -0,0 +1,203 @@
+// Package sqlite contains a ladon.Manager storing every policy as a single JSON row in SQLite.
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+	"github.com/ory/ladon-community/manager/sqlmanager"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrPolicyExists  = sqlmanager.ErrPolicyExists
+	ErrBadConversion = sqlmanager.ErrBadConversion
+
+	// ErrConflict is returned by UpdateIfMatch if the policy was changed since its ETag was read.
+	ErrConflict = sqlmanager.ErrConflict
+
+	// ErrBusy is the cause of errors returned if the database was locked by another connection for longer
+	// than the busy timeout. The operation didn't take effect and may be retried, see IsRetryable.
+	ErrBusy = errors.New("Database is locked by another connection")
+)
+
+// Timestamps records when a policy was created and last changed.
+type Timestamps = sqlmanager.Timestamps
+
+// IsRetryable returns true if err was returned because the database was busy, in which case the operation
+// didn't take effect and may be retried.
+func IsRetryable(err error) bool {
+	return errors.Cause(err) == ErrBusy
+}
+
+// now is the current time with millisecond precision in a format database drivers like
+// github.com/mattn/go-sqlite3 parse into a time.Time.
+const now = `strftime('%Y-%m-%d %H:%M:%f', 'now')`
+
+// migrations are applied in order by CreateSchemas. Never change a migration which has been released,
+// append a new one instead.
+var migrations = []string{
+	`CREATE TABLE ladon_jsonb_policy (
+	id              varchar(255) NOT NULL PRIMARY KEY,
+	policy          text NOT NULL CHECK (json_valid(policy)),
+	subjects_any    boolean NOT NULL,
+	resources_any   boolean NOT NULL,
+	created_at      timestamp NOT NULL DEFAULT (` + now + `),
+	updated_at      timestamp NOT NULL DEFAULT (` + now + `)
+)`,
+	`CREATE TABLE ladon_jsonb_policy_subjects (
+	value           text NOT NULL,
+	policy_id       varchar(255) NOT NULL,
+	PRIMARY KEY (value, policy_id)
+) WITHOUT ROWID`,
+	`CREATE TABLE ladon_jsonb_policy_resources (
+	value           text NOT NULL,
+	policy_id       varchar(255) NOT NULL,
+	PRIMARY KEY (value, policy_id)
+) WITHOUT ROWID`,
+	`CREATE INDEX ladon_jsonb_policy_subjects_policy_idx ON ladon_jsonb_policy_subjects (policy_id)`,
+	`CREATE INDEX ladon_jsonb_policy_resources_policy_idx ON ladon_jsonb_policy_resources (policy_id)`,
+	`INSERT OR IGNORE INTO ladon_jsonb_policy_subjects (value, policy_id)
+	SELECT e.value, p.id FROM ladon_jsonb_policy p, json_each(p.policy, '$.subjects') e WHERE e.type = 'text'`,
+	`INSERT OR IGNORE INTO ladon_jsonb_policy_resources (value, policy_id)
+	SELECT e.value, p.id FROM ladon_jsonb_policy p, json_each(p.policy, '$.resources') e WHERE e.type = 'text'`,
+	`CREATE TRIGGER ladon_jsonb_policy_insert AFTER INSERT ON ladon_jsonb_policy BEGIN
+	INSERT OR IGNORE INTO ladon_jsonb_policy_subjects (value, policy_id)
+		SELECT value, NEW.id FROM json_each(NEW.policy, '$.subjects') WHERE type = 'text';
+	INSERT OR IGNORE INTO ladon_jsonb_policy_resources (value, policy_id)
+		SELECT value, NEW.id FROM json_each(NEW.policy, '$.resources') WHERE type = 'text';
+END`,
+	`CREATE TRIGGER ladon_jsonb_policy_update AFTER UPDATE OF id, policy ON ladon_jsonb_policy BEGIN
+	DELETE FROM ladon_jsonb_policy_subjects WHERE policy_id = OLD.id;
+	DELETE FROM ladon_jsonb_policy_resources WHERE policy_id = OLD.id;
+	INSERT OR IGNORE INTO ladon_jsonb_policy_subjects (value, policy_id)
+		SELECT value, NEW.id FROM json_each(NEW.policy, '$.subjects') WHERE type = 'text';
+	INSERT OR IGNORE INTO ladon_jsonb_policy_resources (value, policy_id)
+		SELECT value, NEW.id FROM json_each(NEW.policy, '$.resources') WHERE type = 'text';
+END`,
+	`CREATE TRIGGER ladon_jsonb_policy_delete AFTER DELETE ON ladon_jsonb_policy BEGIN
+	DELETE FROM ladon_jsonb_policy_subjects WHERE policy_id = OLD.id;
+	DELETE FROM ladon_jsonb_policy_resources WHERE policy_id = OLD.id;
+END`,
+}
+
+// indexed are the policy fields whose values are copied into a join table named ladon_jsonb_policy_<field>
+// by triggers, so they can be looked up using its primary key.
+var indexed = map[string]bool{"subjects": true, "resources": true}
+
+// Dialect is the SQLite dialect of sqlmanager.SQLManager. It requires the JSON1 functions, which are
+// built into SQLite since 3.38 and into github.com/mattn/go-sqlite3, and ON CONFLICT, which requires
+// SQLite 3.24. Subjects and resources are copied into join tables by triggers and looked up using their
+// primary keys.
+//
+// Errors caused by SQLITE_BUSY and SQLITE_LOCKED are translated to errors caused by ErrBusy.
+type Dialect struct{}
+
+// Migrations returns the migrations of the SQLite schema.
+func (Dialect) Migrations() []string {
+	return migrations
+}
+
+// Placeholder returns ?n.
+func (Dialect) Placeholder(n int) string {
+	return fmt.Sprintf("?%d", n)
+}
+
+// InsertIgnore returns an INSERT ... ON CONFLICT DO NOTHING statement.
+func (d Dialect) InsertIgnore(table string, columns ...string) string {
+	return sqlmanager.InsertOnConflictDoNothing(d, table, columns...)
+}
+
+// JSONText returns json_extract(policy, '$.field').
+func (Dialect) JSONText(field string) string {
+	return `json_extract(policy, '$.` + field + `')`
+}
+
+// JSONSet returns json_set(policy, '$.field', arg).
+func (Dialect) JSONSet(field string, arg string) string {
+	return `json_set(policy, '$.` + field + `', ` + arg + `)`
+}
+
+// ArrayContains returns a predicate looking for arg in the join table of field. Fields without a join
+// table are looked up in json_each(policy, '$.field').
+func (Dialect) ArrayContains(field string, arg string) string {
+	if indexed[field] {
+		return `EXISTS (SELECT 1 FROM ladon_jsonb_policy_` + field + ` WHERE value = ` + arg + ` AND policy_id = ladon_jsonb_policy.id)`
+	}
+	return `EXISTS (SELECT 1 FROM json_each(policy, '$.` + field + `') WHERE value = ` + arg + `)`
+}
+
+// ArrayElements returns json_each(policy, '$.field').
+func (Dialect) ArrayElements(field string) string {
+	return `json_each(policy, '$.` + field + `')`
+}
+
+// Now returns the current time with millisecond precision.
+func (Dialect) Now() string {
+	return now
+}
+
+// ForUpdate returns an empty string, SQLite transactions are serializable.
+func (Dialect) ForUpdate() string {
+	return ``
+}
+
+// TranslateError translates the errors SQLite returns if the database is locked to errors caused by
+// ErrBusy.
+func (Dialect) TranslateError(err error) error {
+	if e, ok := err.(sqlite3.Error); ok && (e.Code == sqlite3.ErrBusy || e.Code == sqlite3.ErrLocked) {
+		return errors.Wrap(ErrBusy, err.Error())
+	}
+	return err
+}
+
+// SQLiteManager is a SQLite implementation of ladon.Manager. Each policy is stored as a JSON document,
+// including its conditions, so policies can be queried with SQLite's JSON functions. Subjects and
+// resources are indexed in join tables, so FindRequestCandidates is a single indexed query. Policies
+// whose subjects or resources are empty or contain a regular expression are flagged, so they are returned
+// as candidates for any value.
+//
+// SQLite allows a single writer at a time. A connection which finds the database locked waits for the
+// busy timeout of the connection and then fails with an error caused by ErrBusy, see IsRetryable.
+//
+// It is a sqlmanager.SQLManager using Dialect.
+type SQLiteManager struct {
+	*sqlmanager.SQLManager
+
+	db    *sql.DB
+	owned bool
+}
+
+// NewSQLiteManager initializes a new SQLiteManager. db must use the driver github.com/mattn/go-sqlite3;
+// setting a busy timeout, e.g. with the DSN parameter _busy_timeout, lets writers wait for each other
+// instead of failing immediately. Call CreateSchemas before using the manager.
+func NewSQLiteManager(db *sql.DB, opts ...sqlmanager.Option) *SQLiteManager {
+	return &SQLiteManager{SQLManager: sqlmanager.NewSQLManager(db, Dialect{}, opts...), db: db}
+}
+
+// NewSQLiteManagerFromFile initializes a new SQLiteManager using the database file at path, which is
+// created if it doesn't exist. The connections use write-ahead logging, so readers don't block the writer,
+// wait up to five seconds for a locked database, and begin transactions immediately, so transactions
+// can't deadlock while upgrading their locks. The manager owns the database, which is released by Close.
+// Call CreateSchemas before using the manager.
+func NewSQLiteManagerFromFile(path string, opts ...sqlmanager.Option) (*SQLiteManager, error) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	m := NewSQLiteManager(db, opts...)
+	m.owned = true
+	return m, nil
+}
+
+// Close closes the database if it was opened by NewSQLiteManagerFromFile. Managers initialized with
+// NewSQLiteManager use a database passed in by the caller, who might share it, so Close is a no-op for
+// them. Calling Close more than once is safe.
+func (m *SQLiteManager) Close() error {
+	if !m.owned {
+		return nil
+	}
+	return errors.WithStack(m.db.Close())
+}
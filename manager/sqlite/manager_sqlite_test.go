@@ -0,0 +1,276 @@
+package sqlite
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/manager"
+)
+
+// newManager returns a manager of a new in-memory database with the schema applied.
+func newManager(t *testing.T) *SQLiteManager {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Every connection to :memory: opens a database of its own.
+	db.SetMaxOpenConns(1)
+
+	m := NewSQLiteManager(db)
+	if _, err := m.CreateSchemas(); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// joined returns the values of the join table of field, each followed by its policy ID.
+func joined(t *testing.T, db *sql.DB, field string) []string {
+	rows, err := db.Query(`SELECT value, policy_id FROM ladon_jsonb_policy_` + field + ` ORDER BY value, policy_id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value, id string
+		if err := rows.Scan(&value, &id); err != nil {
+			t.Fatal(err)
+		}
+		values = append(values, value, id)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return values
+}
+
+func TestCreate(t *testing.T) {
+	m := newManager(t)
+	policy := &DefaultPolicy{ID: "example-policy-1", Conditions: Conditions{}}
+
+	if err := m.Create(policy); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Create(policy); err != ErrPolicyExists {
+		t.Fatalf("Expected %v, got %v", ErrPolicyExists, err)
+	}
+
+	p, err := m.Get(policy.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.(*manager.TimestampedPolicy).DefaultPolicy; !cmp.Equal(policy, got) {
+		t.Fatalf("Unexpected policy\n%s", cmp.Diff(policy, got))
+	}
+}
+
+func TestFindRequestCandidates(t *testing.T) {
+	m := newManager(t)
+
+	policies := Policies{
+		&DefaultPolicy{ID: "test-policy-1", Subjects: []string{"ex1", "ex2"}, Resources: []string{"exr1", "exr2"}, Conditions: Conditions{}},
+		&DefaultPolicy{ID: "test-policy-2", Subjects: []string{"ex1", "ex2"}, Conditions: Conditions{}},
+		&DefaultPolicy{ID: "test-policy-3", Subjects: []string{"ex3", "ex4"}, Conditions: Conditions{}},
+		&DefaultPolicy{ID: "test-policy-4", Subjects: []string{"ex1"}, Resources: []string{"exr3"}, Conditions: Conditions{}},
+		&DefaultPolicy{ID: "test-policy-5", Resources: []string{"exr1"}, Conditions: Conditions{}},
+		&DefaultPolicy{ID: "test-policy-6", Subjects: []string{"<.*>"}, Resources: []string{"exr1"}, Conditions: Conditions{}},
+	}
+	for _, p := range policies {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for k, c := range []struct {
+		r        *Request
+		expected Policies
+	}{
+		{
+			r:        &Request{Subject: "ex1", Resource: "exr1", Action: "get"},
+			expected: Policies{policies[0], policies[1], policies[4], policies[5]},
+		},
+		{
+			r:        &Request{Subject: "ex3", Resource: "exr3", Action: "get"},
+			expected: Policies{policies[2]},
+		},
+		{
+			r:        &Request{Subject: "ex5", Resource: "exr1", Action: "get"},
+			expected: Policies{policies[4], policies[5]},
+		},
+	} {
+		found, err := m.FindRequestCandidates(c.r)
+		if err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+		if !cmp.Equal(c.expected, found) {
+			t.Errorf("Case %d: unexpected candidates\n%s", k, cmp.Diff(c.expected, found))
+		}
+	}
+
+	found, err := m.FindPoliciesForSubject("ex4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (Policies{policies[2], policies[4], policies[5]}); !cmp.Equal(expected, found) {
+		t.Errorf("Unexpected policies for subject\n%s", cmp.Diff(expected, found))
+	}
+
+	found, err = m.FindPoliciesForResource("exr2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (Policies{policies[0], policies[1], policies[2]}); !cmp.Equal(expected, found) {
+		t.Errorf("Unexpected policies for resource\n%s", cmp.Diff(expected, found))
+	}
+}
+
+func TestWithWarden(t *testing.T) {
+	m := newManager(t)
+	w := &Ladon{Manager: m}
+
+	for _, p := range []Policy{
+		&DefaultPolicy{
+			ID:         "test-policy-1",
+			Subjects:   []string{"user:example", "group:example"},
+			Resources:  []string{"resource:example1"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		},
+		&DefaultPolicy{
+			ID:         "test-policy-2",
+			Subjects:   []string{"user:<.*>"},
+			Resources:  []string{"resource:example2"},
+			Actions:    []string{"get"},
+			Effect:     DenyAccess,
+			Conditions: Conditions{},
+		},
+	} {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for k, c := range []struct {
+		r       *Request
+		allowed bool
+	}{
+		{r: &Request{Subject: "user:example", Resource: "resource:example1", Action: "get"}, allowed: true},
+		{r: &Request{Subject: "group:example", Resource: "resource:example1", Action: "get"}, allowed: true},
+		{r: &Request{Subject: "user:other", Resource: "resource:example1", Action: "get"}, allowed: false},
+		{r: &Request{Subject: "user:example", Resource: "resource:example2", Action: "get"}, allowed: false},
+	} {
+		if err := w.IsAllowed(c.r); (err == nil) != c.allowed {
+			t.Errorf("Case %d: expected allowed to be %t, got error %v", k, c.allowed, err)
+		}
+	}
+}
+
+func TestJoinTables(t *testing.T) {
+	m := newManager(t)
+	policy := &DefaultPolicy{
+		ID:         "test-policy-1",
+		Subjects:   []string{"user:alice", "user:alice", "user:bob"},
+		Resources:  []string{"articles:1"},
+		Conditions: Conditions{},
+	}
+
+	if err := m.Create(policy); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Create(&DefaultPolicy{ID: "test-policy-2", Conditions: Conditions{}}); err != nil {
+		t.Fatal(err)
+	}
+	if expected, values := []string{"user:alice", "test-policy-1", "user:bob", "test-policy-1"}, joined(t, m.db, "subjects"); !cmp.Equal(expected, values) {
+		t.Fatalf("Unexpected subjects after create\n%s", cmp.Diff(expected, values))
+	}
+
+	updated := *policy
+	updated.Subjects = []string{"user:carol"}
+	if err := m.Update(&updated); err != nil {
+		t.Fatal(err)
+	}
+	if expected, values := []string{"user:carol", "test-policy-1"}, joined(t, m.db, "subjects"); !cmp.Equal(expected, values) {
+		t.Fatalf("Unexpected subjects after update\n%s", cmp.Diff(expected, values))
+	}
+
+	if err := m.SetEffect(policy.ID, AllowAccess); err != nil {
+		t.Fatal(err)
+	}
+	if expected, values := []string{"articles:1", "test-policy-1"}, joined(t, m.db, "resources"); !cmp.Equal(expected, values) {
+		t.Fatalf("Unexpected resources after setting the effect\n%s", cmp.Diff(expected, values))
+	}
+
+	if err := m.Delete(policy.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range []string{"subjects", "resources"} {
+		if values := joined(t, m.db, field); len(values) != 0 {
+			t.Fatalf("Expected no %s after delete, got %v", field, values)
+		}
+	}
+}
+
+func TestBusy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ladon-sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "policies.db")
+
+	m, err := NewSQLiteManagerFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if _, err := m.CreateSchemas(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Hold the write lock until the end of the test.
+	tx, err := m.db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`DELETE FROM ladon_jsonb_policy`); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?_busy_timeout=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	other := NewSQLiteManager(db)
+
+	err = other.Create(&DefaultPolicy{ID: "test-policy-1", Conditions: Conditions{}})
+	if !IsRetryable(err) {
+		t.Fatalf("Expected a retryable error, got %v", err)
+	}
+	if IsRetryable(ErrPolicyExists) {
+		t.Fatalf("Expected %v not to be retryable", ErrPolicyExists)
+	}
+
+	// Readers aren't blocked by the writer thanks to write-ahead logging.
+	if _, err := other.GetAll(10, 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClose(t *testing.T) {
+	m := newManager(t)
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetAll(10, 0); err != nil {
+		t.Fatalf("Expected a database passed in by the caller to stay open, got %v", err)
+	}
+}
@@ -0,0 +1,402 @@
+// Package sqlmanager contains a ladon.Manager storing every policy as a single JSON row in a SQL database.
+// The SQL which differs between databases is provided by a Dialect, so adding a database only requires
+// implementing one, see postgres.Dialect and sqlite.Dialect.
+package sqlmanager
+
+import (
+	"database/sql"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/condition"
+	"github.com/ory/ladon-community/manager"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrPolicyExists  = errors.New("Policy exists")
+	ErrBadConversion = errors.New("Could not convert policy from database")
+)
+
+// Dialect provides the SQL which differs between databases. Statements written by SQLManager number their
+// arguments like `$1`, which are replaced with Placeholder before a statement is executed, so fragments
+// returned by a dialect may use them as well. The JSON policy is stored in the column `policy`.
+type Dialect interface {
+	// Migrations returns the statements creating the table ladon_jsonb_policy with the columns id,
+	// policy, subjects_any, resources_any, created_at and updated_at and the dialect's indexes. They are
+	// applied in order by CreateSchemas. Never change a migration which has been released, append a new
+	// one instead.
+	Migrations() []string
+
+	// Placeholder returns the placeholder of the n-th argument of a statement, counting from 1.
+	Placeholder(n int) string
+
+	// InsertIgnore returns an INSERT statement of the columns into table which does nothing if a row with
+	// the same primary key exists. The values are passed as arguments in the order of the columns.
+	InsertIgnore(table string, columns ...string) string
+
+	// JSONText returns an expression of the string at field of the policy column.
+	JSONText(field string) string
+
+	// JSONSet returns an expression of the policy column with the string at field replaced by arg.
+	JSONSet(field string, arg string) string
+
+	// ArrayContains returns a predicate which is true if the array at field of the policy column contains
+	// the string arg.
+	ArrayContains(field string, arg string) string
+
+	// ArrayElements returns a table expression for a FROM clause which lists the strings of the array at
+	// field of the policy column in a column named value.
+	ArrayElements(field string) string
+
+	// Now returns an expression of the current time.
+	Now() string
+
+	// ForUpdate returns the clause appended to a SELECT statement to lock the selected rows until the end
+	// of the transaction, e.g. ` FOR UPDATE`, or an empty string if transactions are serializable anyway.
+	ForUpdate() string
+}
+
+// ErrorTranslator may be implemented by a Dialect to translate the errors of its driver, e.g. to make
+// transient errors recognizable. Errors returned by SQLManager pass through TranslateError before they are
+// wrapped with a stack trace.
+type ErrorTranslator interface {
+	// TranslateError returns the error to return instead of the non-nil err.
+	TranslateError(err error) error
+}
+
+// SQLManager is a SQL implementation of ladon.Manager. Each policy is stored as a JSON document, including
+// its conditions.
+//
+// Policies whose subjects or resources are empty or contain a regular expression are flagged, so they are
+// returned as candidates for any value; the others are looked up using Dialect.ArrayContains.
+type SQLManager struct {
+	db      *sql.DB
+	dialect Dialect
+
+	patternCharacters string
+}
+
+// Option configures a SQLManager.
+type Option func(*SQLManager)
+
+// WithPatternCharacters flags subjects and resources containing any of chars like those containing a
+// regular expression, so they are returned as candidates for any value instead of being looked up
+// literally. Without this option a policy written for a matcher with another syntax, like `user:*` for
+// matcher.GlobMatcher, is only a candidate for requests for `user:*` itself.
+//
+// Pass matcher.GlobPatternCharacters when using matcher.GlobMatcher and matcher.SegmentPatternCharacters
+// when using matcher.SegmentMatcher. The flags are written with the policies, so update the policies
+// stored before enabling this option, e.g. by passing them to Update.
+func WithPatternCharacters(chars string) Option {
+	return func(m *SQLManager) {
+		m.patternCharacters = chars
+	}
+}
+
+// NewSQLManager initializes a new SQLManager using dialect, which has to match the driver of db. Call
+// CreateSchemas before using the manager.
+func NewSQLManager(db *sql.DB, dialect Dialect, opts ...Option) *SQLManager {
+	m := &SQLManager{db: db, dialect: dialect}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// placeholders matches the placeholders of the statements written by SQLManager.
+var placeholders = regexp.MustCompile(`\$([0-9]+)`)
+
+// rebind replaces the placeholders of query with those of the dialect.
+func (m *SQLManager) rebind(query string) string {
+	return placeholders.ReplaceAllStringFunc(query, func(p string) string {
+		n, _ := strconv.Atoi(p[1:])
+		return m.dialect.Placeholder(n)
+	})
+}
+
+// wrap translates err if the dialect is an ErrorTranslator and adds a stack trace. It returns nil if err is
+// nil.
+func (m *SQLManager) wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+	if t, ok := m.dialect.(ErrorTranslator); ok {
+		err = t.TranslateError(err)
+	}
+	return errors.WithStack(err)
+}
+
+func (m *SQLManager) exec(query string, args ...interface{}) (sql.Result, error) {
+	return m.db.Exec(m.rebind(query), args...)
+}
+
+func (m *SQLManager) queryRow(query string, args ...interface{}) *sql.Row {
+	return m.db.QueryRow(m.rebind(query), args...)
+}
+
+// CreateSchemas applies all migrations which haven't been applied yet and returns how many were applied.
+func (m *SQLManager) CreateSchemas() (int, error) {
+	if _, err := m.exec(`CREATE TABLE IF NOT EXISTS ladon_jsonb_migration (version integer NOT NULL PRIMARY KEY)`); err != nil {
+		return 0, m.wrap(err)
+	}
+
+	var applied int
+	if err := m.queryRow(`SELECT COUNT(*) FROM ladon_jsonb_migration`).Scan(&applied); err != nil {
+		return 0, m.wrap(err)
+	}
+
+	migrations := m.dialect.Migrations()
+	for i := applied; i < len(migrations); i++ {
+		tx, err := m.db.Begin()
+		if err != nil {
+			return i - applied, m.wrap(err)
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return i - applied, errors.Wrapf(err, "could not apply migration %d", i)
+		}
+		if _, err := tx.Exec(m.rebind(`INSERT INTO ladon_jsonb_migration (version) VALUES ($1)`), i); err != nil {
+			tx.Rollback()
+			return i - applied, m.wrap(err)
+		}
+		if err := tx.Commit(); err != nil {
+			return i - applied, m.wrap(err)
+		}
+	}
+	return len(migrations) - applied, nil
+}
+
+// Create a new policy. Duplicate subjects, resources and actions are removed with manager.Normalize.
+func (m *SQLManager) Create(policy Policy) error {
+	policy = manager.Normalize(policy)
+	p, err := json.Marshal(policy)
+	if err != nil {
+		return m.wrap(err)
+	}
+
+	res, err := m.exec(
+		m.dialect.InsertIgnore("ladon_jsonb_policy", "id", "policy", "subjects_any", "resources_any"),
+		policy.GetID(), string(p), m.matchesAny(policy, policy.GetSubjects()), m.matchesAny(policy, policy.GetResources()),
+	)
+	if err != nil {
+		return m.wrap(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return m.wrap(err)
+	} else if n == 0 {
+		return ErrPolicyExists
+	}
+	return nil
+}
+
+// Update an existing policy. Like Create, it removes duplicate subjects, resources and actions.
+func (m *SQLManager) Update(policy Policy) error {
+	policy = manager.Normalize(policy)
+	p, err := json.Marshal(policy)
+	if err != nil {
+		return m.wrap(err)
+	}
+
+	res, err := m.exec(
+		`UPDATE ladon_jsonb_policy SET policy = $2, subjects_any = $3, resources_any = $4, updated_at = `+m.dialect.Now()+` WHERE id = $1`,
+		policy.GetID(), string(p), m.matchesAny(policy, policy.GetSubjects()), m.matchesAny(policy, policy.GetResources()),
+	)
+	if err != nil {
+		return m.wrap(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return m.wrap(err)
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Get retrieves a policy as a *manager.TimestampedPolicy.
+func (m *SQLManager) Get(id string) (Policy, error) {
+	var (
+		p  []byte
+		ts Timestamps
+	)
+	if err := m.queryRow(`SELECT policy, created_at, updated_at FROM ladon_jsonb_policy WHERE id = $1`, id).Scan(&p, &ts.CreatedAt, &ts.UpdatedAt); err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, m.wrap(err)
+	}
+
+	policy, err := decodePolicy(p)
+	if err != nil {
+		return nil, err
+	}
+	return &manager.TimestampedPolicy{DefaultPolicy: policy, Timestamps: ts}, nil
+}
+
+// Exists returns true if the policy exists. Unlike Get, it doesn't transfer or decode the policy.
+func (m *SQLManager) Exists(id string) (bool, error) {
+	var exists bool
+	if err := m.queryRow(`SELECT EXISTS (SELECT 1 FROM ladon_jsonb_policy WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return false, m.wrap(err)
+	}
+	return exists, nil
+}
+
+// Delete removes a policy.
+func (m *SQLManager) Delete(id string) error {
+	res, err := m.exec(`DELETE FROM ladon_jsonb_policy WHERE id = $1`, id)
+	if err != nil {
+		return m.wrap(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return m.wrap(err)
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetAll retrieves all policies as *manager.TimestampedPolicy, sorted by ID.
+func (m *SQLManager) GetAll(limit, offset int64) (Policies, error) {
+	return m.queryTimestamped(`SELECT policy, created_at, updated_at FROM ladon_jsonb_policy ORDER BY id LIMIT $1 OFFSET $2`, limit, offset)
+}
+
+// FindRequestCandidates returns candidates that could match the request object, sorted by ID. It either
+// returns a set that exactly matches the request, or a superset of it.
+func (m *SQLManager) FindRequestCandidates(r *Request) (Policies, error) {
+	return m.query(
+		`SELECT policy FROM ladon_jsonb_policy
+		WHERE (`+m.dialect.ArrayContains("subjects", "$1")+` OR subjects_any) AND (`+m.dialect.ArrayContains("resources", "$2")+` OR resources_any)
+		ORDER BY id`,
+		r.Subject, r.Resource,
+	)
+}
+
+// FindPoliciesForSubject returns policies that could match the subject, sorted by ID. It either returns
+// a set of policies that applies to the subject, or a superset of it.
+func (m *SQLManager) FindPoliciesForSubject(subject string) (Policies, error) {
+	return m.query(
+		`SELECT policy FROM ladon_jsonb_policy WHERE `+m.dialect.ArrayContains("subjects", "$1")+` OR subjects_any ORDER BY id`,
+		subject,
+	)
+}
+
+// FindPoliciesForResource returns policies that could match the resource, sorted by ID. It either
+// returns a set of policies that apply to the resource, or a superset of it.
+func (m *SQLManager) FindPoliciesForResource(resource string) (Policies, error) {
+	return m.query(
+		`SELECT policy FROM ladon_jsonb_policy WHERE `+m.dialect.ArrayContains("resources", "$1")+` OR resources_any ORDER BY id`,
+		resource,
+	)
+}
+
+// ListSubjects returns the distinct, non-empty subjects of all policies in ascending order.
+func (m *SQLManager) ListSubjects() ([]string, error) {
+	return m.listDistinct("subjects")
+}
+
+// ListResources returns the distinct, non-empty resources of all policies in ascending order.
+func (m *SQLManager) ListResources() ([]string, error) {
+	return m.listDistinct("resources")
+}
+
+// listDistinct returns the distinct, non-empty values of the given policy field, which must be an array.
+func (m *SQLManager) listDistinct(field string) ([]string, error) {
+	rows, err := m.db.Query(
+		`SELECT DISTINCT value FROM ladon_jsonb_policy, ` + m.dialect.ArrayElements(field) + ` WHERE value <> ''`,
+	)
+	if err != nil {
+		return nil, m.wrap(err)
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, m.wrap(err)
+		}
+		values = append(values, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, m.wrap(err)
+	}
+
+	// Sort in Go rather than in SQL, so the order doesn't depend on the database's collation.
+	sort.Strings(values)
+	return values, nil
+}
+
+// FindPoliciesByDescription returns the policies whose description contains substring, ignoring case
+// and sorted by ID. An empty substring matches every policy.
+func (m *SQLManager) FindPoliciesByDescription(substring string) (Policies, error) {
+	return m.query(
+		`SELECT policy FROM ladon_jsonb_policy WHERE LOWER(`+m.dialect.JSONText("description")+`) LIKE LOWER($1) ESCAPE '\' ORDER BY id`,
+		"%"+likeEscaper.Replace(substring)+"%",
+	)
+}
+
+// likeEscaper escapes the wildcards of LIKE patterns.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (m *SQLManager) query(query string, args ...interface{}) (Policies, error) {
+	rows, err := m.db.Query(m.rebind(query), args...)
+	if err != nil {
+		return nil, m.wrap(err)
+	}
+	defer rows.Close()
+
+	policies := Policies{}
+	for rows.Next() {
+		var p []byte
+		if err := rows.Scan(&p); err != nil {
+			return nil, m.wrap(err)
+		}
+
+		policy, err := decodePolicy(p)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, m.wrap(rows.Err())
+}
+
+// matchesAny returns true if values could match values the database can't look up, which is the case if
+// they are empty or contain a regular expression or, with WithPatternCharacters, a pattern character.
+func (m *SQLManager) matchesAny(p Policy, values []string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if strings.IndexByte(v, p.GetStartDelimiter()) >= 0 {
+			return true
+		}
+		if m.patternCharacters != "" && strings.ContainsAny(v, m.patternCharacters) {
+			return true
+		}
+	}
+	return false
+}
+
+func decodePolicy(p []byte) (*DefaultPolicy, error) {
+	policy := &DefaultPolicy{}
+	if err := condition.UnmarshalPolicy(p, policy); err != nil {
+		return nil, errors.Wrap(ErrBadConversion, err.Error())
+	}
+	return policy, nil
+}
+
+// InsertOnConflictDoNothing returns an `INSERT ... ON CONFLICT DO NOTHING` statement of the columns into
+// table using the placeholders of d. Dialects of databases supporting this syntax, like PostgreSQL and
+// SQLite, can use it to implement Dialect.InsertIgnore.
+func InsertOnConflictDoNothing(d Dialect, table string, columns ...string) string {
+	values := make([]string, len(columns))
+	for i := range columns {
+		values[i] = d.Placeholder(i + 1)
+	}
+	return `INSERT INTO ` + table + ` (` + strings.Join(columns, ", ") + `) VALUES (` + strings.Join(values, ", ") + `) ON CONFLICT DO NOTHING`
+}
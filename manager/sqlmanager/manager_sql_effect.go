@@ -0,0 +1,30 @@
+package sqlmanager
+
+import (
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// SetEffect changes only the effect of a policy, e.g. to flip an allow policy to deny during an incident,
+// and bumps its updated_at. The change is a single UPDATE of the policy's effect field, so unlike Get
+// followed by Update no concurrent change of the policy is lost. effect must be ladon.AllowAccess or
+// ladon.DenyAccess.
+func (m *SQLManager) SetEffect(id string, effect string) error {
+	if effect != AllowAccess && effect != DenyAccess {
+		return errors.Errorf("invalid effect %q, expected %q or %q", effect, AllowAccess, DenyAccess)
+	}
+
+	res, err := m.exec(
+		`UPDATE ladon_jsonb_policy SET policy = `+m.dialect.JSONSet("effect", "$2")+`, updated_at = `+m.dialect.Now()+` WHERE id = $1`,
+		id, effect,
+	)
+	if err != nil {
+		return m.wrap(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return m.wrap(err)
+	} else if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package sqlmanager
+
+import (
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/manager"
+	"github.com/pkg/errors"
+)
+
+// ErrConflict is returned by UpdateIfMatch if the policy was changed since its ETag was read.
+var ErrConflict = errors.New("Policy was modified concurrently")
+
+// GetWithETag retrieves a policy together with its ETag, which changes whenever the policy is updated.
+// Pass the ETag to UpdateIfMatch to detect concurrent modifications.
+func (m *SQLManager) GetWithETag(id string) (Policy, string, error) {
+	var p []byte
+	if err := m.queryRow(`SELECT policy FROM ladon_jsonb_policy WHERE id = $1`, id).Scan(&p); err == sql.ErrNoRows {
+		return nil, "", ErrNotFound
+	} else if err != nil {
+		return nil, "", m.wrap(err)
+	}
+
+	policy, err := decodePolicy(p)
+	if err != nil {
+		return nil, "", err
+	}
+	return policy, etag(p), nil
+}
+
+// UpdateIfMatch updates a policy if its ETag still equals etag and returns ErrConflict otherwise.
+// ladon's DefaultPolicy has no version field, so the ETag is derived from the stored policy instead.
+// The check and the update run in one transaction, which locks the policy's row using
+// Dialect.ForUpdate, and are thus atomic.
+func (m *SQLManager) UpdateIfMatch(policy Policy, etagValue string) error {
+	policy = manager.Normalize(policy)
+	p, err := json.Marshal(policy)
+	if err != nil {
+		return m.wrap(err)
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return m.wrap(err)
+	}
+	defer tx.Rollback()
+
+	var stored []byte
+	if err := tx.QueryRow(m.rebind(`SELECT policy FROM ladon_jsonb_policy WHERE id = $1`+m.dialect.ForUpdate()), policy.GetID()).Scan(&stored); err == sql.ErrNoRows {
+		return ErrNotFound
+	} else if err != nil {
+		return m.wrap(err)
+	}
+	if etag(stored) != etagValue {
+		return ErrConflict
+	}
+
+	if _, err := tx.Exec(
+		m.rebind(`UPDATE ladon_jsonb_policy SET policy = $2, subjects_any = $3, resources_any = $4, updated_at = `+m.dialect.Now()+` WHERE id = $1`),
+		policy.GetID(), string(p), m.matchesAny(policy, policy.GetSubjects()), m.matchesAny(policy, policy.GetResources()),
+	); err != nil {
+		return m.wrap(err)
+	}
+	return m.wrap(tx.Commit())
+}
+
+// etag returns the ETag of the stored policy p, the MD5 sum of its text like PostgreSQL's
+// md5(policy::text), so ETags handed out before SQLManager was split from postgres.PostgresManager stay
+// valid.
+func etag(p []byte) string {
+	sum := md5.Sum(p)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,54 @@
+package sqlmanager
+
+import (
+	"database/sql"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/manager"
+)
+
+// Timestamps records when a policy was created and last changed.
+type Timestamps = manager.Timestamps
+
+// GetTimestamps returns when the policy was created and last changed, without decoding the policy. The
+// timestamps are stored in the created_at and updated_at columns, which are set by the database on
+// Create, Update and UpdateIfMatch. Get and GetAll return them too, as a *manager.TimestampedPolicy.
+//
+// Policies created before the columns were added carry the time of the migration instead.
+func (m *SQLManager) GetTimestamps(id string) (*Timestamps, error) {
+	ts := &Timestamps{}
+	if err := m.queryRow(`SELECT created_at, updated_at FROM ladon_jsonb_policy WHERE id = $1`, id).Scan(&ts.CreatedAt, &ts.UpdatedAt); err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, m.wrap(err)
+	}
+	return ts, nil
+}
+
+// queryTimestamped runs a query selecting the policy, created_at and updated_at columns and returns the
+// policies as *manager.TimestampedPolicy.
+func (m *SQLManager) queryTimestamped(query string, args ...interface{}) (Policies, error) {
+	rows, err := m.db.Query(m.rebind(query), args...)
+	if err != nil {
+		return nil, m.wrap(err)
+	}
+	defer rows.Close()
+
+	policies := Policies{}
+	for rows.Next() {
+		var (
+			p  []byte
+			ts Timestamps
+		)
+		if err := rows.Scan(&p, &ts.CreatedAt, &ts.UpdatedAt); err != nil {
+			return nil, m.wrap(err)
+		}
+
+		policy, err := decodePolicy(p)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, &manager.TimestampedPolicy{DefaultPolicy: policy, Timestamps: ts})
+	}
+	return policies, m.wrap(rows.Err())
+}
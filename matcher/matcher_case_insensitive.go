@@ -0,0 +1,98 @@
+package matcher
+
+import (
+	"regexp"
+	"strings"
+
+	. "github.com/ory/ladon"
+)
+
+// Matcher is the interface implemented by ladon's matchers and the matchers in this package.
+type Matcher interface {
+	Matches(p Policy, haystack []string, needle string) (bool, error)
+}
+
+// CaseInsensitiveMatcher makes the wrapped matcher ignore casing. Literal values are lowercased together
+// with the needle. Values containing the policy's start delimiter are regular expressions in ladon's
+// syntax, which are matched case-insensitively with the `(?i)` flag instead, so escapes like `\D` or
+// `\P{Lu}` keep their meaning. Their text outside the delimiters is matched case-insensitively as well.
+//
+// The matcher only sees the candidates the manager returns. The Redis and SQL managers look up literal
+// subjects and resources byte by byte, so a policy for `Alice` is no candidate for a request for `alice`.
+// Lowercase the subjects and resources of stored policies, or use redis.WithCaseInsensitiveIndex, which
+// lowercases the Redis indexes.
+type CaseInsensitiveMatcher struct {
+	matcher Matcher
+}
+
+// NewCaseInsensitiveMatcher initializes a new CaseInsensitiveMatcher wrapping m. Passing nil wraps
+// ladon.DefaultMatcher.
+func NewCaseInsensitiveMatcher(m Matcher) *CaseInsensitiveMatcher {
+	if m == nil {
+		m = DefaultMatcher
+	}
+	return &CaseInsensitiveMatcher{matcher: m}
+}
+
+// Matches passes the lowercased literal values and the lowercased needle to the wrapped matcher, and the
+// case-insensitive regular expressions and the needle as is.
+func (m *CaseInsensitiveMatcher) Matches(p Policy, haystack []string, needle string) (bool, error) {
+	var literals, patterns []string
+	for _, h := range haystack {
+		if strings.IndexByte(h, p.GetStartDelimiter()) >= 0 {
+			patterns = append(patterns, caseInsensitivePattern(p, h))
+		} else {
+			literals = append(literals, strings.ToLower(h))
+		}
+	}
+
+	if len(literals) > 0 {
+		if ok, err := m.matcher.Matches(p, literals, strings.ToLower(needle)); err != nil || ok {
+			return ok, err
+		}
+	}
+	if len(patterns) == 0 {
+		return false, nil
+	}
+	return m.matcher.Matches(p, patterns, needle)
+}
+
+// caseInsensitivePattern prefixes every regular expression of pattern with the `(?i)` flag and turns the
+// text between them into case-insensitive regular expressions as well, e.g. `Group:<.*>` into
+// `<(?i)Group:><(?i).*>`. Patterns with unbalanced delimiters are returned as is, so the wrapped matcher
+// reports them.
+func caseInsensitivePattern(p Policy, pattern string) string {
+	var (
+		start, end = p.GetStartDelimiter(), p.GetEndDelimiter()
+		b          strings.Builder
+		level, idx int
+	)
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case start:
+			if level == 0 {
+				if idx < i {
+					b.WriteString(string(start) + "(?i)" + regexp.QuoteMeta(pattern[idx:i]) + string(end))
+				}
+				idx = i + 1
+			}
+			level++
+		case end:
+			level--
+			if level < 0 {
+				return pattern
+			}
+			if level == 0 {
+				b.WriteString(string(start) + "(?i)" + pattern[idx:i] + string(end))
+				idx = i + 1
+			}
+		}
+	}
+	if level != 0 {
+		return pattern
+	}
+	if idx < len(pattern) {
+		b.WriteString(string(start) + "(?i)" + regexp.QuoteMeta(pattern[idx:]) + string(end))
+	}
+	return b.String()
+}
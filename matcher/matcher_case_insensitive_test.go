@@ -0,0 +1,92 @@
+package matcher
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+)
+
+func TestCaseInsensitiveMatcher(t *testing.T) {
+	policy := &DefaultPolicy{
+		ID:        "lowercase-policy",
+		Subjects:  []string{"user:alice", "<group:.*>", `Service:<\D+>`},
+		Resources: []string{"articles:1"},
+		Actions:   []string{"get"},
+		Effect:    AllowAccess,
+	}
+
+	for k, c := range []struct {
+		matcher Matcher
+		request *Request
+		allowed bool
+	}{
+		{
+			matcher: NewCaseInsensitiveMatcher(nil),
+			request: &Request{Subject: "User:Alice", Resource: "Articles:1", Action: "GET"},
+			allowed: true,
+		},
+		{
+			matcher: NewCaseInsensitiveMatcher(NewGlobMatcher()),
+			request: &Request{Subject: "USER:ALICE", Resource: "articles:1", Action: "get"},
+			allowed: true,
+		},
+		{
+			matcher: NewCaseInsensitiveMatcher(nil),
+			request: &Request{Subject: "Group:Admins", Resource: "articles:1", Action: "get"},
+			allowed: true,
+		},
+		{
+			// Escapes of regular expressions keep their meaning.
+			matcher: NewCaseInsensitiveMatcher(nil),
+			request: &Request{Subject: "service:Billing", Resource: "articles:1", Action: "get"},
+			allowed: true,
+		},
+		{
+			matcher: NewCaseInsensitiveMatcher(nil),
+			request: &Request{Subject: "SERVICE:42", Resource: "articles:1", Action: "get"},
+			allowed: false,
+		},
+		{
+			matcher: DefaultMatcher,
+			request: &Request{Subject: "User:Alice", Resource: "Articles:1", Action: "GET"},
+			allowed: false,
+		},
+		{
+			matcher: DefaultMatcher,
+			request: &Request{Subject: "user:alice", Resource: "articles:1", Action: "get"},
+			allowed: true,
+		},
+	} {
+		w := &Ladon{
+			Manager: memory.NewMemoryManager(),
+			Matcher: c.matcher,
+		}
+		if err := w.Manager.Create(policy); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := w.IsAllowed(c.request); (err == nil) != c.allowed {
+			t.Errorf("Case %d: expected allowed to be %t, got error %v", k, c.allowed, err)
+		}
+	}
+}
+
+func TestCaseInsensitivePattern(t *testing.T) {
+	p := &DefaultPolicy{}
+	for k, c := range []struct {
+		pattern  string
+		expected string
+	}{
+		{pattern: "<.*>", expected: "<(?i).*>"},
+		{pattern: `Group:<\D+>`, expected: `<(?i)Group:><(?i)\D+>`},
+		{pattern: "<a>.<b{1,2}>c", expected: `<(?i)a><(?i)\.><(?i)b{1,2}><(?i)c>`},
+		{pattern: "<<a>|<b>>", expected: "<(?i)<a>|<b>>"},
+		{pattern: "<a", expected: "<a"},
+		{pattern: "a>b<c>", expected: "a>b<c>"},
+	} {
+		if actual := caseInsensitivePattern(p, c.pattern); actual != c.expected {
+			t.Errorf("Case %d: expected %s, got %s", k, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package matcher
+
+import (
+	. "github.com/ory/ladon"
+)
+
+// EmptyMatchesAllMatcher treats an empty haystack as matching any needle and passes everything else to
+// the wrapped matcher. Used as the warden's matcher, a policy without subjects, resources or actions
+// applies to every subject, resource or action, e.g. for global policies which would otherwise have to
+// list `<.*>` everywhere.
+//
+// This changes the meaning of existing policies: ladon's matchers never match an empty haystack, so
+// until now such policies applied to nothing. The manager has to return them as candidates too, which
+// the memory and Postgres managers do and RedisManager does with redis.WithEmptyMatchesAll.
+type EmptyMatchesAllMatcher struct {
+	matcher Matcher
+}
+
+// NewEmptyMatchesAllMatcher initializes a new EmptyMatchesAllMatcher wrapping m. Passing nil wraps
+// ladon.DefaultMatcher.
+func NewEmptyMatchesAllMatcher(m Matcher) *EmptyMatchesAllMatcher {
+	if m == nil {
+		m = DefaultMatcher
+	}
+	return &EmptyMatchesAllMatcher{matcher: m}
+}
+
+// Matches returns true if the haystack is empty and asks the wrapped matcher otherwise.
+func (m *EmptyMatchesAllMatcher) Matches(p Policy, haystack []string, needle string) (bool, error) {
+	if len(haystack) == 0 {
+		return true, nil
+	}
+	return m.matcher.Matches(p, haystack, needle)
+}
@@ -0,0 +1,41 @@
+package matcher
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+)
+
+func TestEmptyMatchesAllMatcher(t *testing.T) {
+	global := &DefaultPolicy{ID: "global", Effect: AllowAccess, Conditions: Conditions{}}
+	scoped := &DefaultPolicy{ID: "scoped", Resources: []string{"articles:<.*>"}, Effect: AllowAccess, Conditions: Conditions{}}
+
+	for k, c := range []struct {
+		matcher Matcher
+		policy  Policy
+		request *Request
+		allowed bool
+	}{
+		{matcher: NewEmptyMatchesAllMatcher(nil), policy: global, request: &Request{Subject: "alice", Resource: "articles:1", Action: "get"}, allowed: true},
+		{matcher: NewEmptyMatchesAllMatcher(nil), policy: global, request: &Request{Subject: "service:billing", Resource: "invoices:7", Action: "delete"}, allowed: true},
+		{matcher: NewEmptyMatchesAllMatcher(nil), policy: global, request: &Request{}, allowed: true},
+		{matcher: NewEmptyMatchesAllMatcher(NewGlobMatcher()), policy: global, request: &Request{Subject: "bob", Resource: "users:1", Action: "update"}, allowed: true},
+		{matcher: NewEmptyMatchesAllMatcher(nil), policy: scoped, request: &Request{Subject: "alice", Resource: "articles:1", Action: "get"}, allowed: true},
+		{matcher: NewEmptyMatchesAllMatcher(nil), policy: scoped, request: &Request{Subject: "alice", Resource: "users:1", Action: "get"}, allowed: false},
+		{matcher: DefaultMatcher, policy: global, request: &Request{Subject: "alice", Resource: "articles:1", Action: "get"}, allowed: false},
+		{matcher: DefaultMatcher, policy: scoped, request: &Request{Subject: "alice", Resource: "articles:1", Action: "get"}, allowed: false},
+	} {
+		w := &Ladon{
+			Manager: memory.NewMemoryManager(),
+			Matcher: c.matcher,
+		}
+		if err := w.Manager.Create(c.policy); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := w.IsAllowed(c.request); (err == nil) != c.allowed {
+			t.Errorf("Case %d: expected allowed to be %t, got error %v", k, c.allowed, err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Package matcher contains alternative implementations of ladon's matcher, which can be plugged into
+// the warden through the Matcher field of ladon.Ladon.
+package matcher
+
+import (
+	. "github.com/ory/ladon"
+)
+
+// GlobPatternCharacters are the characters which make a value a pattern for GlobMatcher. Pass them to the
+// managers' WithPatternCharacters options, so they return policies with glob patterns as candidates.
+const GlobPatternCharacters = "*?"
+
+// GlobMatcher matches subjects, resources and actions using glob semantics instead of regular expressions.
+// A `*` matches any sequence of characters (including none) and a `?` matches exactly one character,
+// every other character is matched literally. Matching runs in O(len(pattern) * len(needle)) at worst
+// and never backtracks exponentially, so it is safe to use with patterns written by untrusted authors.
+//
+// The matcher only sees the candidates the manager returns. The Redis and SQL managers treat only values
+// containing a regular expression as patterns and look up everything else literally, so a policy for
+// `articles:*` is never a candidate for `articles:1` unless the manager is told about glob patterns with
+// GlobPatternCharacters, e.g. redis.WithPatternCharacters(matcher.GlobPatternCharacters). Even then, a
+// policy whose subjects and resources are all patterns is only found by managers returning every policy
+// as a candidate, like ladon's memory manager.
+type GlobMatcher struct{}
+
+// NewGlobMatcher initializes a new GlobMatcher.
+func NewGlobMatcher() *GlobMatcher {
+	return &GlobMatcher{}
+}
+
+// Matches a needle with an array of glob patterns and returns true if a match was found.
+func (m *GlobMatcher) Matches(p Policy, haystack []string, needle string) (bool, error) {
+	for _, h := range haystack {
+		if globMatch(h, needle) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// globMatch reports whether needle matches pattern. When a mismatch happens after a `*`, we retry from the
+// position following the last `*` only, which keeps the algorithm free of exponential backtracking.
+func globMatch(pattern, needle string) bool {
+	var (
+		ps, s         = []rune(pattern), []rune(needle)
+		p, i          int
+		star, starIdx = -1, 0
+	)
+
+	for i < len(s) {
+		switch {
+		case p < len(ps) && (ps[p] == '?' || ps[p] == s[i]):
+			p++
+			i++
+		case p < len(ps) && ps[p] == '*':
+			star = p
+			starIdx = i
+			p++
+		case star != -1:
+			// Let the last star swallow one more character and try again.
+			p = star + 1
+			starIdx++
+			i = starIdx
+		default:
+			return false
+		}
+	}
+
+	// Trailing stars match the empty string.
+	for p < len(ps) && ps[p] == '*' {
+		p++
+	}
+	return p == len(ps)
+}
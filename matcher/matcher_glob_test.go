@@ -0,0 +1,110 @@
+package matcher
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+)
+
+func TestGlobMatcher(t *testing.T) {
+	m := NewGlobMatcher()
+	p := &DefaultPolicy{}
+
+	for k, c := range []struct {
+		pattern string
+		needle  string
+		matches bool
+	}{
+		{pattern: "", needle: "", matches: true},
+		{pattern: "", needle: "a", matches: false},
+		{pattern: "*", needle: "", matches: true},
+		{pattern: "*", needle: "anything:at:all", matches: true},
+		{pattern: "resource:example", needle: "resource:example", matches: true},
+		{pattern: "resource:example", needle: "resource:example2", matches: false},
+		{pattern: "resource:*", needle: "resource:example", matches: true},
+		{pattern: "resource:*", needle: "resources:example", matches: false},
+		{pattern: "*:example", needle: "resource:example", matches: true},
+		{pattern: "resource:*:read", needle: "resource:1:2:read", matches: true},
+		{pattern: "resource:*:read", needle: "resource:1:2:write", matches: false},
+		{pattern: "user:?", needle: "user:a", matches: true},
+		{pattern: "user:?", needle: "user:ab", matches: false},
+		{pattern: "user:?", needle: "user:", matches: false},
+		{pattern: "user:??b*", needle: "user:aabzzz", matches: true},
+		{pattern: "user:ä?", needle: "user:äö", matches: true},
+		{pattern: "**a**", needle: "bab", matches: true},
+		{pattern: "<.*>", needle: "anything", matches: false},
+		{pattern: "<.*>", needle: "<.*>", matches: true},
+	} {
+		ok, err := m.Matches(p, []string{c.pattern}, c.needle)
+		if err != nil {
+			t.Fatalf("Case %d failed: %s", k, err)
+		}
+		if ok != c.matches {
+			t.Errorf("Case %d: expected pattern %q matching %q to be %t", k, c.pattern, c.needle, c.matches)
+		}
+	}
+
+	t.Run("Match any of the haystack", func(t *testing.T) {
+		ok, err := m.Matches(p, []string{"user:bob", "group:*"}, "group:admins")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Fatal("Expected group:admins to match group:*")
+		}
+	})
+
+	t.Run("Pathological patterns return promptly", func(t *testing.T) {
+		pattern := strings.Repeat("a*", 32) + "b"
+		needle := strings.Repeat("a", 256)
+		ok, err := m.Matches(p, []string{pattern}, needle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok {
+			t.Fatal("Expected pathological pattern not to match")
+		}
+	})
+}
+
+func TestGlobMatcherWithWarden(t *testing.T) {
+	w := &Ladon{
+		Manager: memory.NewMemoryManager(),
+		Matcher: NewGlobMatcher(),
+	}
+	if err := w.Manager.Create(&DefaultPolicy{
+		ID:        "glob-policy",
+		Subjects:  []string{"user:*"},
+		Resources: []string{"articles:*"},
+		Actions:   []string{"get"},
+		Effect:    AllowAccess,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.IsAllowed(&Request{Subject: "user:alice", Resource: "articles:1", Action: "get"}); err != nil {
+		t.Fatalf("Expected request to be allowed: %s", err)
+	}
+	if err := w.IsAllowed(&Request{Subject: "group:alice", Resource: "articles:1", Action: "get"}); err == nil {
+		t.Fatal("Expected request to be denied")
+	}
+}
+
+func BenchmarkGlobMatcher(b *testing.B) {
+	benchmarkMatcher(b, NewGlobMatcher(), []string{"user:bob", "articles:*:comments:*"})
+}
+
+func BenchmarkRegexpMatcher(b *testing.B) {
+	benchmarkMatcher(b, NewRegexpMatcher(512), []string{"user:bob", "articles:<.*>:comments:<.*>"})
+}
+
+func benchmarkMatcher(b *testing.B, m Matcher, haystack []string) {
+	p := &DefaultPolicy{}
+	for i := 0; i < b.N; i++ {
+		if ok, _ := m.Matches(p, haystack, "articles:1234:comments:5678"); !ok {
+			b.Fatal("Expected needle to match")
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package matcher
+
+import (
+	"strings"
+
+	. "github.com/ory/ladon"
+)
+
+// SegmentPatternCharacters are the characters which make a value a pattern for SegmentMatcher: the
+// segment separator and the characters of GlobPatternCharacters.
+const SegmentPatternCharacters = "|" + GlobPatternCharacters
+
+// SegmentMatcher matches composite values like `tenant:acme|role:admin` segment by segment. Values and
+// patterns containing a `|` are split into `key:value` segments and match if both have the same keys and
+// every value matches its pattern with glob semantics, e.g. `tenant:*|role:admin` matches any tenant with
+// the role admin. The order of the segments doesn't matter.
+//
+// Everything else, including single segment values like `articles:1`, is passed to the wrapped matcher,
+// so resources and actions keep their usual semantics. A pattern which isn't made of well formed segments
+// is passed to the wrapped matcher as well.
+//
+// Composite values can't be looked up literally, neither their globs nor their segments in another order,
+// so the Redis and SQL managers need SegmentPatternCharacters passed to their WithPatternCharacters
+// options to return such policies as candidates, like for GlobMatcher.
+type SegmentMatcher struct {
+	matcher Matcher
+}
+
+// NewSegmentMatcher initializes a new SegmentMatcher wrapping m. Passing nil wraps ladon.DefaultMatcher.
+func NewSegmentMatcher(m Matcher) *SegmentMatcher {
+	if m == nil {
+		m = DefaultMatcher
+	}
+	return &SegmentMatcher{matcher: m}
+}
+
+// Matches a needle with an array of patterns and returns true if a match was found.
+func (m *SegmentMatcher) Matches(p Policy, haystack []string, needle string) (bool, error) {
+	segments, ok := parseSegments(needle)
+	if !ok {
+		return m.matcher.Matches(p, haystack, needle)
+	}
+
+	var rest []string
+	for _, h := range haystack {
+		patterns, ok := parseSegments(h)
+		if !ok {
+			rest = append(rest, h)
+			continue
+		}
+		if matchSegments(patterns, segments) {
+			return true, nil
+		}
+	}
+
+	if len(rest) == 0 {
+		return false, nil
+	}
+	return m.matcher.Matches(p, rest, needle)
+}
+
+// parseSegments splits a composite value into its segments. It returns false if the value has a single
+// segment, or a segment has no key or a duplicate key.
+func parseSegments(value string) (map[string]string, bool) {
+	if !strings.Contains(value, "|") {
+		return nil, false
+	}
+
+	parts := strings.Split(value, "|")
+	segments := make(map[string]string, len(parts))
+	for _, part := range parts {
+		i := strings.IndexByte(part, ':')
+		if i <= 0 {
+			return nil, false
+		}
+		key := part[:i]
+		if _, ok := segments[key]; ok {
+			return nil, false
+		}
+		segments[key] = part[i+1:]
+	}
+	return segments, true
+}
+
+func matchSegments(patterns, segments map[string]string) bool {
+	if len(patterns) != len(segments) {
+		return false
+	}
+	for key, pattern := range patterns {
+		value, ok := segments[key]
+		if !ok || !globMatch(pattern, value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,74 @@
+package matcher
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+)
+
+func TestSegmentMatcher(t *testing.T) {
+	m := NewSegmentMatcher(nil)
+	p := &DefaultPolicy{}
+
+	for k, c := range []struct {
+		pattern string
+		needle  string
+		matches bool
+	}{
+		{pattern: "tenant:*|role:admin", needle: "tenant:acme|role:admin", matches: true},
+		{pattern: "tenant:*|role:admin", needle: "tenant:acme|role:user", matches: false},
+		{pattern: "tenant:*|role:admin", needle: "role:admin|tenant:acme", matches: true},
+		{pattern: "tenant:acme|role:*", needle: "tenant:initech|role:admin", matches: false},
+		{pattern: "tenant:a?me|role:admin", needle: "tenant:acme|role:admin", matches: true},
+		{pattern: "tenant:*|role:admin", needle: "tenant:acme|role:admin|team:ops", matches: false},
+		{pattern: "tenant:*|role:admin", needle: "tenant:acme|group:admin", matches: false},
+		{pattern: "tenant:*|role:admin", needle: "tenant:acme|role:admin:readonly", matches: false},
+		{pattern: "tenant:*|role:ad*", needle: "tenant:acme|role:admin:readonly", matches: true},
+		// Single segment values fall back to the wrapped matcher.
+		{pattern: "articles:<[0-9]+>", needle: "articles:1", matches: true},
+		{pattern: "articles:*", needle: "articles:1", matches: false},
+		{pattern: "<.*>", needle: "tenant:acme|role:admin", matches: true},
+	} {
+		ok, err := m.Matches(p, []string{c.pattern}, c.needle)
+		if err != nil {
+			t.Fatalf("Case %d failed: %s", k, err)
+		}
+		if ok != c.matches {
+			t.Errorf("Case %d: expected pattern %q matching %q to be %t", k, c.pattern, c.needle, c.matches)
+		}
+	}
+
+	t.Run("Match any of the haystack", func(t *testing.T) {
+		ok, err := m.Matches(p, []string{"user:bob", "tenant:*|role:admin"}, "tenant:acme|role:admin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Fatal("Expected tenant:acme|role:admin to match tenant:*|role:admin")
+		}
+	})
+}
+
+func TestSegmentMatcherWithWarden(t *testing.T) {
+	w := &Ladon{
+		Manager: memory.NewMemoryManager(),
+		Matcher: NewSegmentMatcher(nil),
+	}
+	if err := w.Manager.Create(&DefaultPolicy{
+		ID:        "segment-policy",
+		Subjects:  []string{"tenant:*|role:admin"},
+		Resources: []string{"articles:<.*>"},
+		Actions:   []string{"delete"},
+		Effect:    AllowAccess,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.IsAllowed(&Request{Subject: "tenant:acme|role:admin", Resource: "articles:1", Action: "delete"}); err != nil {
+		t.Fatalf("Expected request to be allowed: %s", err)
+	}
+	if err := w.IsAllowed(&Request{Subject: "tenant:acme|role:user", Resource: "articles:1", Action: "delete"}); err == nil {
+		t.Fatal("Expected request to be denied")
+	}
+}
@@ -0,0 +1,127 @@
+package matcher
+
+import (
+	"regexp"
+	"strings"
+
+	. "github.com/ory/ladon"
+)
+
+// TemplatePatternCharacters are the characters which make a value a template for TemplateMatcher. Pass
+// them to the managers' WithPatternCharacters options, so they return policies with templates as
+// candidates.
+const TemplatePatternCharacters = "{"
+
+// CapturesKey is the reserved context key under which the wardens of the warden package pass the
+// captures of a policy's resource template to its conditions, as a map from capture names to the
+// captured strings. A condition reads the capture `projectID` at the context path
+// `$captures.projectID`, e.g. with a condition.AttributeCondition.
+//
+// The key is reserved whenever the warden's matcher is a CapturingMatcher: requests whose context
+// already contains it are rejected with an error caused by warden.ErrInvalidRequest, so callers can't
+// forge captures.
+const CapturesKey = "$captures"
+
+// CapturingMatcher is implemented by matchers which capture values from the needle, like TemplateMatcher.
+type CapturingMatcher interface {
+	Matcher
+
+	// Captures returns the captures of the first value of the haystack matching the needle, or false if
+	// none matches. Values without captures yield an empty map.
+	Captures(p Policy, haystack []string, needle string) (map[string]string, bool, error)
+}
+
+// TemplateMatcher matches values containing templates like `projects:{projectID}:issues:{issueID}`,
+// capturing the named parts, and passes all other values to the wrapped matcher. A capture matches a
+// non-empty run of characters other than `:`, and a template using the same capture name twice never
+// matches.
+//
+// The wardens of the warden package which evaluate policies themselves, like ModeWarden, pass the captures
+// of the policy's resource to its conditions under CapturesKey. ladon.Ladon only uses Matches, so its
+// conditions never see them.
+type TemplateMatcher struct {
+	matcher Matcher
+}
+
+// NewTemplateMatcher initializes a new TemplateMatcher wrapping m. Passing nil wraps
+// ladon.DefaultMatcher.
+func NewTemplateMatcher(m Matcher) *TemplateMatcher {
+	if m == nil {
+		m = DefaultMatcher
+	}
+	return &TemplateMatcher{matcher: m}
+}
+
+// Matches returns true if a template or, through the wrapped matcher, another value matches the needle.
+func (m *TemplateMatcher) Matches(p Policy, haystack []string, needle string) (bool, error) {
+	_, ok, err := m.Captures(p, haystack, needle)
+	return ok, err
+}
+
+// Captures returns the captures of the first template matching the needle, or an empty map if only the
+// other values match it.
+func (m *TemplateMatcher) Captures(p Policy, haystack []string, needle string) (map[string]string, bool, error) {
+	var others []string
+	for _, h := range haystack {
+		if !templateCapture.MatchString(h) {
+			others = append(others, h)
+			continue
+		}
+		if captures, ok := MatchTemplate(h, needle); ok {
+			return captures, true, nil
+		}
+	}
+
+	if len(others) == 0 {
+		return nil, false, nil
+	}
+	ok, err := m.matcher.Matches(p, others, needle)
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return map[string]string{}, true, nil
+}
+
+var templateCapture = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// MatchTemplate matches value against template and returns the captured values by name.
+func MatchTemplate(template, value string) (map[string]string, bool) {
+	var (
+		pattern strings.Builder
+		last    int
+		seen    = map[string]bool{}
+	)
+	pattern.WriteString("^")
+	for _, loc := range templateCapture.FindAllStringSubmatchIndex(template, -1) {
+		name := template[loc[2]:loc[3]]
+		if seen[name] {
+			// Captures must be unambiguous.
+			return nil, false
+		}
+		seen[name] = true
+
+		pattern.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		pattern.WriteString("(?P<" + name + ">[^:]+)")
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(template[last:]))
+	pattern.WriteString("$")
+
+	re, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, false
+	}
+
+	match := re.FindStringSubmatch(value)
+	if match == nil {
+		return nil, false
+	}
+
+	captures := map[string]string{}
+	for i, name := range re.SubexpNames() {
+		if name != "" {
+			captures[name] = match[i]
+		}
+	}
+	return captures, true
+}
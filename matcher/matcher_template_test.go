@@ -0,0 +1,43 @@
+package matcher
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+)
+
+func TestTemplateMatcher(t *testing.T) {
+	m := NewTemplateMatcher(nil)
+	p := &DefaultPolicy{}
+
+	for k, c := range []struct {
+		haystack []string
+		needle   string
+		captures map[string]string
+		matches  bool
+	}{
+		{haystack: []string{"projects:{projectID}:issues:{issueID}"}, needle: "projects:p1:issues:42", captures: map[string]string{"projectID": "p1", "issueID": "42"}, matches: true},
+		{haystack: []string{"projects:{projectID}:issues:{issueID}"}, needle: "projects:p1:issues:42:comments:1"},
+		{haystack: []string{"projects:{projectID}:issues:{issueID}"}, needle: "projects:p1:issues:"},
+		{haystack: []string{"projects:{id}:issues:{id}"}, needle: "projects:p1:issues:p1"},
+		{haystack: []string{"files:{name}.txt"}, needle: "files:notes.txt", captures: map[string]string{"name": "notes"}, matches: true},
+		{haystack: []string{"files:{name}.txt"}, needle: "files:notesXtxt"},
+		// Other values are passed to the wrapped matcher and capture nothing.
+		{haystack: []string{"users:<.*>"}, needle: "users:1", captures: map[string]string{}, matches: true},
+		{haystack: []string{"projects:{projectID}", "projects:<.*>"}, needle: "projects:p1:issues:1", captures: map[string]string{}, matches: true},
+		// The first matching template wins.
+		{haystack: []string{"projects:{a}", "projects:{b}"}, needle: "projects:p1", captures: map[string]string{"a": "p1"}, matches: true},
+	} {
+		captures, ok, err := m.Captures(p, c.haystack, c.needle)
+		if err != nil {
+			t.Fatalf("Case %d failed: %s", k, err)
+		}
+		if ok != c.matches || !cmp.Equal(c.captures, captures) {
+			t.Errorf("Case %d: expected %t with captures %v, got %t with %v", k, c.matches, c.captures, ok, captures)
+		}
+		if ok, err := m.Matches(p, c.haystack, c.needle); err != nil || ok != c.matches {
+			t.Errorf("Case %d: expected Matches to return %t, got %t and %v", k, c.matches, ok, err)
+		}
+	}
+}
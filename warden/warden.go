@@ -0,0 +1,52 @@
+// Package warden contains helpers built around ladon's warden.
+package warden
+
+import (
+	"context"
+
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// Decision is the outcome of evaluating a single policy against a request.
+type Decision struct {
+	// Applies is true if the policy's subjects, resources, actions and conditions match the request.
+	Applies bool
+	// Allowed is true if the policy applies and grants access.
+	Allowed bool
+	// Flag is the flag of the policy if it applies and is flagged, e.g. AuditAccess. Flagged policies
+	// are never Allowed.
+	Flag string
+}
+
+// TestPolicy evaluates policy against r without storing it, which lets authors check what a policy
+// would do before committing it. The policy is evaluated like ModeWarden does using ladon.DefaultMatcher,
+// so the result is exactly what IsAllowed would return if policy was the only stored policy.
+func TestPolicy(policy Policy, r *Request) (*Decision, error) {
+	logger := &flagCapturingAuditLogger{}
+	switch err := newEvaluator(DefaultMatcher, logger).decide(context.Background(), r, Policies{policy}); errors.Cause(err) {
+	case nil:
+		return &Decision{Applies: true, Allowed: true}, nil
+	case ErrRequestForcefullyDenied:
+		return &Decision{Applies: true}, nil
+	case ErrRequestDenied:
+		if len(logger.flagged) > 0 {
+			return &Decision{Applies: true, Flag: Flag(policy)}, nil
+		}
+		return &Decision{}, nil
+	default:
+		return nil, err
+	}
+}
+
+// flagCapturingAuditLogger records the flagged policies of a request.
+type flagCapturingAuditLogger struct {
+	flagged Policies
+}
+
+func (a *flagCapturingAuditLogger) LogFlaggedAccessRequest(_ *Request, flagged Policies) {
+	a.flagged = flagged
+}
+
+func (a *flagCapturingAuditLogger) LogRejectedAccessRequest(*Request, Policies, Policies) {}
+func (a *flagCapturingAuditLogger) LogGrantedAccessRequest(*Request, Policies, Policies)  {}
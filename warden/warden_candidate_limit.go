@@ -0,0 +1,67 @@
+package warden
+
+import (
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// ErrTooManyCandidates is the cause of the error CandidateLimitWarden denies requests with if the manager
+// returned more candidate policies than allowed.
+var ErrTooManyCandidates = errors.New("Request has too many candidate policies")
+
+// CandidateObserver is told the number of candidate policies of every request, e.g. to record it in a
+// histogram.
+type CandidateObserver interface {
+	ObserveCandidates(r *Request, n int)
+}
+
+// CandidateObserverFunc adapts a function to the CandidateObserver interface.
+type CandidateObserverFunc func(r *Request, n int)
+
+// ObserveCandidates calls f(r, n).
+func (f CandidateObserverFunc) ObserveCandidates(r *Request, n int) {
+	f(r, n)
+}
+
+// CandidateLimitWarden is a ladon.Ladon which denies requests whose candidate set is larger than
+// MaxCandidates, so a misconfigured broad policy can't silently blow up latency and memory. It fails
+// closed: such a request is denied with an error caused by ErrTooManyCandidates, even if its policies
+// would allow it, and passed to the audit logger as rejected with all candidates and no deciding policy,
+// so runaway policies can be found. A MaxCandidates of zero or less disables the limit.
+//
+// Observer, if set, is told the number of candidates of every request, including those over the limit.
+type CandidateLimitWarden struct {
+	*Ladon
+	MaxCandidates int
+	Observer      CandidateObserver
+}
+
+// NewCandidateLimitWarden initializes a new CandidateLimitWarden.
+func NewCandidateLimitWarden(l *Ladon, maxCandidates int) *CandidateLimitWarden {
+	return &CandidateLimitWarden{Ladon: l, MaxCandidates: maxCandidates}
+}
+
+// IsAllowed returns nil if the candidate policies of r allow it and an error otherwise, like
+// ladon.Ladon's IsAllowed, unless there are more than MaxCandidates candidates.
+func (w *CandidateLimitWarden) IsAllowed(r *Request) error {
+	candidates, err := w.Manager.FindRequestCandidates(r)
+	if err != nil {
+		return err
+	}
+
+	if w.Observer != nil {
+		w.Observer.ObserveCandidates(r, len(candidates))
+	}
+	if w.MaxCandidates > 0 && len(candidates) > w.MaxCandidates {
+		w.auditLogger().LogRejectedAccessRequest(r, candidates, Policies{})
+		return errors.Wrapf(ErrTooManyCandidates, "%d candidates exceed the limit of %d", len(candidates), w.MaxCandidates)
+	}
+	return w.DoPoliciesAllow(r, candidates)
+}
+
+func (w *CandidateLimitWarden) auditLogger() AuditLogger {
+	if w.AuditLogger == nil {
+		return DefaultAuditLogger
+	}
+	return w.AuditLogger
+}
@@ -0,0 +1,54 @@
+package warden
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+func TestCandidateLimitWarden(t *testing.T) {
+	m := memory.NewMemoryManager()
+	for i := 0; i < 5; i++ {
+		if err := m.Create(&DefaultPolicy{
+			ID:        fmt.Sprintf("policy-%d", i),
+			Subjects:  []string{"alice"},
+			Resources: []string{"articles:<.*>"},
+			Actions:   []string{"get"},
+			Effect:    AllowAccess,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := &Request{Subject: "alice", Resource: "articles:1", Action: "get"}
+
+	for k, c := range []struct {
+		max      int
+		expected error
+		rejected int
+	}{
+		{max: 0},
+		{max: 5},
+		{max: 4, expected: ErrTooManyCandidates, rejected: 1},
+		{max: 1, expected: ErrTooManyCandidates, rejected: 1},
+	} {
+		logger := &countingAuditLogger{}
+		var observed []int
+		w := NewCandidateLimitWarden(&Ladon{Manager: m, Matcher: DefaultMatcher, AuditLogger: logger}, c.max)
+		w.Observer = CandidateObserverFunc(func(_ *Request, n int) {
+			observed = append(observed, n)
+		})
+
+		if err := w.IsAllowed(r); errors.Cause(err) != c.expected {
+			t.Errorf("Case %d: expected %v, got %v", k, c.expected, err)
+		}
+		if logger.rejected != c.rejected {
+			t.Errorf("Case %d: expected %d rejected decisions to be logged, got %d", k, c.rejected, logger.rejected)
+		}
+		if len(observed) != 1 || observed[0] != 5 {
+			t.Errorf("Case %d: expected 5 candidates to be observed once, got %v", k, observed)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package warden
+
+import (
+	"context"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/matcher"
+)
+
+// ConcurrentWarden decides requests like ladon.Ladon but evaluates candidate policies in parallel on a
+// bounded number of workers, which pays off if policies carry slow conditions, e.g. remote lookups.
+//
+// An explicit deny still wins over any allow: evaluation stops at the first policy denying the request.
+// Which allowing policies are reported to the audit logger depends on scheduling, as does their order.
+type ConcurrentWarden struct {
+	Manager     Manager
+	Matcher     matcher.Matcher
+	AuditLogger AuditLogger
+
+	// Workers is the number of policies evaluated in parallel. It defaults to GOMAXPROCS.
+	Workers int
+}
+
+// NewConcurrentWarden initializes a new ConcurrentWarden using ladon.DefaultMatcher.
+func NewConcurrentWarden(manager Manager, workers int) *ConcurrentWarden {
+	return &ConcurrentWarden{Manager: manager, Matcher: DefaultMatcher, Workers: workers}
+}
+
+// IsAllowed returns nil if subject s has the permission p on resource r with context c or an error otherwise.
+func (w *ConcurrentWarden) IsAllowed(r *Request) error {
+	return w.IsAllowedContext(context.Background(), r)
+}
+
+// IsAllowedContext works like IsAllowed, but stops evaluating policies once ctx is done and returns
+// ctx.Err() instead.
+func (w *ConcurrentWarden) IsAllowedContext(ctx context.Context, r *Request) error {
+	return isAllowed(ctx, w.Manager, w.evaluator(), r)
+}
+
+// DoPoliciesAllow returns nil if the policies allow the request and an error otherwise.
+func (w *ConcurrentWarden) DoPoliciesAllow(r *Request, policies []Policy) error {
+	return w.evaluator().decide(context.Background(), r, policies)
+}
+
+func (w *ConcurrentWarden) evaluator() *evaluator {
+	e := newEvaluator(w.Matcher, w.AuditLogger)
+	e.workers = w.Workers
+	if e.workers <= 0 {
+		e.workers = -1
+	}
+	return e
+}
@@ -0,0 +1,180 @@
+package warden
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+// slowCondition is fulfilled after a delay, simulating a remote lookup.
+type slowCondition struct {
+	delay time.Duration
+	calls *int32
+}
+
+func (c *slowCondition) Fulfills(interface{}, *Request) bool {
+	if c.calls != nil {
+		atomic.AddInt32(c.calls, 1)
+	}
+	time.Sleep(c.delay)
+	return true
+}
+
+func (c *slowCondition) GetName() string {
+	return "slowCondition"
+}
+
+func slowPolicies(t testing.TB, n int, delay time.Duration, calls *int32) *memory.MemoryManager {
+	m := memory.NewMemoryManager()
+	for i := 0; i < n; i++ {
+		if err := m.Create(&DefaultPolicy{
+			ID:         fmt.Sprintf("allow-%d", i),
+			Subjects:   []string{"<.*>"},
+			Resources:  []string{"articles:<.*>"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{"slow": &slowCondition{delay: delay, calls: calls}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+func TestConcurrentWarden(t *testing.T) {
+	m := slowPolicies(t, 20, time.Millisecond, nil)
+	for _, p := range []*DefaultPolicy{
+		{
+			ID:        "deny-bob",
+			Subjects:  []string{"bob"},
+			Resources: []string{"<.*>"},
+			Actions:   []string{"<.*>"},
+			Effect:    DenyAccess,
+		},
+		{
+			ID:         "allow-users-from-internal-network",
+			Subjects:   []string{"<.*>"},
+			Resources:  []string{"users:<.*>"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{"ip": &CIDRCondition{CIDR: "10.0.0.0/8"}},
+		},
+	} {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logger := &countingAuditLogger{}
+	w := NewConcurrentWarden(m, 4)
+	w.AuditLogger = logger
+
+	for k, c := range []struct {
+		request  *Request
+		expected error
+	}{
+		{request: &Request{Subject: "alice", Resource: "articles:1", Action: "get"}},
+		{request: &Request{Subject: "bob", Resource: "articles:1", Action: "get"}, expected: ErrRequestForcefullyDenied},
+		{request: &Request{Subject: "alice", Resource: "articles:1", Action: "delete"}, expected: ErrRequestDenied},
+		{request: &Request{Subject: "alice", Resource: "users:1", Action: "get", Context: Context{"ip": "10.1.2.3"}}},
+		{request: &Request{Subject: "alice", Resource: "users:1", Action: "get", Context: Context{"ip": "192.168.1.1"}}, expected: ErrRequestDenied},
+	} {
+		sequential := (&Ladon{Manager: m, Matcher: DefaultMatcher}).IsAllowed(c.request)
+		if errors.Cause(sequential) != c.expected {
+			t.Fatalf("Case %d: expected ladon to return %v, got %v", k, c.expected, sequential)
+		}
+		if err := w.IsAllowed(c.request); errors.Cause(err) != c.expected {
+			t.Errorf("Case %d: expected %v, got %v", k, c.expected, err)
+		}
+	}
+
+	if logger.granted != 2 || logger.rejected != 3 {
+		t.Fatalf("Expected the audit logger to be called for every decision, got %+v", logger)
+	}
+
+	t.Run("Stop at the first explicit deny", func(t *testing.T) {
+		var calls int32
+		m := slowPolicies(t, 100, time.Millisecond, &calls)
+		deny := &DefaultPolicy{
+			ID:        "deny-bob",
+			Subjects:  []string{"bob"},
+			Resources: []string{"<.*>"},
+			Actions:   []string{"<.*>"},
+			Effect:    DenyAccess,
+		}
+
+		// Put the deny first, so the remaining policies are skipped.
+		policies, err := m.GetAll(100, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		policies = append(Policies{deny}, policies...)
+
+		w := NewConcurrentWarden(m, 2)
+		if err := w.DoPoliciesAllow(&Request{Subject: "bob", Resource: "articles:1", Action: "get"}, policies); errors.Cause(err) != ErrRequestForcefullyDenied {
+			t.Fatalf("Expected %v, got %v", ErrRequestForcefullyDenied, err)
+		}
+		if n := atomic.LoadInt32(&calls); n > 10 {
+			t.Fatalf("Expected evaluation to stop early, %d conditions were evaluated", n)
+		}
+	})
+
+	t.Run("Handle no candidates", func(t *testing.T) {
+		w := NewConcurrentWarden(memory.NewMemoryManager(), 0)
+		if err := w.IsAllowed(&Request{Subject: "alice", Resource: "articles:1", Action: "get"}); errors.Cause(err) != ErrRequestDenied {
+			t.Fatalf("Expected %v, got %v", ErrRequestDenied, err)
+		}
+	})
+}
+
+func BenchmarkSequentialWarden(b *testing.B) {
+	w := &Ladon{Manager: slowPolicies(b, 32, 100*time.Microsecond, nil), Matcher: DefaultMatcher}
+	benchmarkWarden(b, w)
+}
+
+func BenchmarkConcurrentWarden(b *testing.B) {
+	benchmarkWarden(b, NewConcurrentWarden(slowPolicies(b, 32, 100*time.Microsecond, nil), 8))
+}
+
+func benchmarkWarden(b *testing.B, w Warden) {
+	r := &Request{Subject: "alice", Resource: "articles:1", Action: "get"}
+	for i := 0; i < b.N; i++ {
+		if err := w.IsAllowed(r); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestConditionsOnlyForMatchingPolicies(t *testing.T) {
+	var calls int32
+	m := memory.NewMemoryManager()
+	for _, p := range []*DefaultPolicy{
+		{ID: "other-action", Subjects: []string{"alice"}, Resources: []string{"articles:1"}, Actions: []string{"delete"}},
+		{ID: "other-subject", Subjects: []string{"bob"}, Resources: []string{"articles:1"}, Actions: []string{"get"}},
+		{ID: "other-resource", Subjects: []string{"alice"}, Resources: []string{"articles:2"}, Actions: []string{"get"}},
+	} {
+		p.Effect = AllowAccess
+		p.Conditions = Conditions{"remote": &slowCondition{calls: &calls}}
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := &Request{Subject: "alice", Resource: "articles:1", Action: "get"}
+	for k, w := range []Warden{
+		&Ladon{Manager: m},
+		NewConcurrentWarden(m, 4),
+	} {
+		if err := w.IsAllowed(r); errors.Cause(err) != ErrRequestDenied {
+			t.Errorf("Case %d: expected %s, got %v", k, ErrRequestDenied, err)
+		}
+		if n := atomic.LoadInt32(&calls); n != 0 {
+			t.Errorf("Case %d: expected conditions of non-matching policies not to run, ran %d times", k, n)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package warden
+
+import (
+	"context"
+
+	. "github.com/ory/ladon"
+)
+
+// ContextWarden is a ladon.Warden which stops evaluating policies once a context is done, like
+// ConcurrentWarden, ModeWarden and TracingWarden.
+type ContextWarden interface {
+	Warden
+	IsAllowedContext(ctx context.Context, r *Request) error
+}
+
+// IsAllowedWithContext returns nil if w allows r and an error otherwise, or ctx.Err() once ctx is done,
+// so callers can bound how long they wait for a verdict.
+//
+// ctx is checked before each candidate policy is evaluated if w is a ContextWarden or a *ladon.Ladon, so
+// evaluation stops soon after ctx is done. ladon's managers don't accept a context, though, so a
+// candidate lookup which has begun runs to completion; managers honoring deadlines on their own, e.g.
+// through client timeouts, keep it bounded. Other wardens can't be interrupted at all: they decide r
+// synchronously, and ctx.Err() is returned instead of their verdict if ctx is done by then.
+func IsAllowedWithContext(ctx context.Context, w Warden, r *Request) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	switch w := w.(type) {
+	case ContextWarden:
+		return w.IsAllowedContext(ctx, r)
+	case *Ladon:
+		return isAllowed(ctx, w.Manager, newEvaluator(w.Matcher, w.AuditLogger), r)
+	}
+
+	err := w.IsAllowed(r)
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
@@ -0,0 +1,91 @@
+package warden
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+)
+
+// slowManager delays candidate lookups until release is closed.
+type slowManager struct {
+	Manager
+	release chan struct{}
+}
+
+func (m *slowManager) FindRequestCandidates(r *Request) (Policies, error) {
+	<-m.release
+	return m.Manager.FindRequestCandidates(r)
+}
+
+// opaqueWarden hides the type of the wrapped warden from IsAllowedWithContext.
+type opaqueWarden struct {
+	Warden
+}
+
+func TestIsAllowedWithContext(t *testing.T) {
+	m := memory.NewMemoryManager()
+	if err := m.Create(&DefaultPolicy{
+		ID:        "allow-articles",
+		Subjects:  []string{"<.*>"},
+		Resources: []string{"articles:<.*>"},
+		Actions:   []string{"get"},
+		Effect:    AllowAccess,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	r := &Request{Subject: "alice", Resource: "articles:1", Action: "get"}
+
+	t.Run("Return the verdict", func(t *testing.T) {
+		w := &Ladon{Manager: m, Matcher: DefaultMatcher}
+		if err := IsAllowedWithContext(context.Background(), w, r); err != nil {
+			t.Fatalf("Expected request to be allowed: %s", err)
+		}
+	})
+
+	t.Run("Stop evaluating policies when cancelled", func(t *testing.T) {
+		var calls int32
+		for k, w := range []Warden{
+			&Ladon{Manager: slowPolicies(t, 20, 5*time.Millisecond, &calls), Matcher: DefaultMatcher},
+			&ModeWarden{Manager: slowPolicies(t, 20, 5*time.Millisecond, &calls), Mode: AllAllowsRequired},
+			&ConcurrentWarden{Manager: slowPolicies(t, 20, 5*time.Millisecond, &calls), Workers: 2},
+		} {
+			atomic.StoreInt32(&calls, 0)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			err := IsAllowedWithContext(ctx, w, r)
+			cancel()
+			if err != context.DeadlineExceeded {
+				t.Fatalf("Case %d: expected %s, got %v", k, context.DeadlineExceeded, err)
+			}
+			if n := atomic.LoadInt32(&calls); n >= 20 {
+				t.Errorf("Case %d: expected evaluation to stop early, but all %d conditions were evaluated", k, n)
+			}
+		}
+	})
+
+	t.Run("Return the context error of wardens which can't be interrupted", func(t *testing.T) {
+		slow := &slowManager{Manager: m, release: make(chan struct{})}
+		w := &opaqueWarden{Warden: &Ladon{Manager: slow, Matcher: DefaultMatcher}}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		time.AfterFunc(20*time.Millisecond, func() { close(slow.release) })
+		if err := IsAllowedWithContext(ctx, w, r); err != context.DeadlineExceeded {
+			t.Fatalf("Expected %s, got %v", context.DeadlineExceeded, err)
+		}
+	})
+
+	t.Run("Don't evaluate requests with a done context", func(t *testing.T) {
+		slow := &slowManager{Manager: m}
+		w := &Ladon{Manager: slow, Matcher: DefaultMatcher}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := IsAllowedWithContext(ctx, w, r); err != context.Canceled {
+			t.Fatalf("Expected %s, got %v", context.Canceled, err)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package warden
+
+import (
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// ErrDecisionContextMismatch is returned by EvaluateLocal if the request's subject or resource differs from
+// the request the decision context was fetched for.
+var ErrDecisionContextMismatch = errors.New("Decision context was fetched for another subject or resource")
+
+// DecisionContext holds the candidate policies of a subject and resource, which callers may cache, e.g. in
+// an edge proxy, to decide further requests for the same pair without asking the manager again.
+type DecisionContext struct {
+	// Subject and Resource are those of the request the candidates were fetched for.
+	Subject  string
+	Resource string
+
+	// Candidates are the policies the manager returned for Subject and Resource.
+	Candidates Policies
+
+	// Verdict is the result of IsAllowed for the request the context was fetched for: nil if it was
+	// allowed and the error IsAllowed returned otherwise.
+	Verdict error
+}
+
+// DecisionContextWarden is a ladon.Ladon which can split a decision into fetching the candidate policies
+// and evaluating them, so the candidates can be cached and evaluated locally against fresh requests.
+type DecisionContextWarden struct {
+	*Ladon
+}
+
+// NewDecisionContextWarden initializes a new DecisionContextWarden.
+func NewDecisionContextWarden(l *Ladon) *DecisionContextWarden {
+	return &DecisionContextWarden{Ladon: l}
+}
+
+// FetchDecisionContext looks up the candidate policies of r and decides r like IsAllowed does. It returns
+// an error only if the candidates can't be fetched; whether r is allowed is reported in the Verdict.
+func (w *DecisionContextWarden) FetchDecisionContext(r *Request) (*DecisionContext, error) {
+	candidates, err := w.Manager.FindRequestCandidates(r)
+	if err != nil {
+		return nil, err
+	}
+	return &DecisionContext{
+		Subject:    r.Subject,
+		Resource:   r.Resource,
+		Candidates: candidates,
+		Verdict:    w.DoPoliciesAllow(r, candidates),
+	}, nil
+}
+
+// EvaluateLocal decides r against the candidates of dc without asking the manager, returning what
+// IsAllowed would return if the candidates were still stored. r may differ from the request dc was
+// fetched for in its action and context, but not in its subject and resource, otherwise
+// ErrDecisionContextMismatch is returned. Decisions are passed to the audit logger like those of
+// IsAllowed.
+func (w *DecisionContextWarden) EvaluateLocal(dc *DecisionContext, r *Request) error {
+	if dc.Subject != r.Subject || dc.Resource != r.Resource {
+		return errors.WithStack(ErrDecisionContextMismatch)
+	}
+	return w.DoPoliciesAllow(r, dc.Candidates)
+}
@@ -0,0 +1,83 @@
+package warden
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+func TestDecisionContextWarden(t *testing.T) {
+	m := memory.NewMemoryManager()
+	for _, p := range []Policy{
+		&DefaultPolicy{
+			ID:         "read",
+			Subjects:   []string{"alice"},
+			Resources:  []string{"articles:<.*>"},
+			Actions:    []string{"get", "update"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		},
+		&DefaultPolicy{
+			ID:         "office",
+			Subjects:   []string{"alice"},
+			Resources:  []string{"articles:1"},
+			Actions:    []string{"delete"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{"remoteIP": &CIDRCondition{CIDR: "10.0.0.0/8"}},
+		},
+		&DefaultPolicy{
+			ID:         "frozen",
+			Subjects:   []string{"<.*>"},
+			Resources:  []string{"articles:1"},
+			Actions:    []string{"update"},
+			Effect:     DenyAccess,
+			Conditions: Conditions{},
+		},
+	} {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w := NewDecisionContextWarden(&Ladon{Manager: m, Matcher: DefaultMatcher})
+
+	dc, err := w.FetchDecisionContext(&Request{Subject: "alice", Resource: "articles:1", Action: "get"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dc.Verdict != nil {
+		t.Fatalf("Expected the fetched request to be allowed, got %v", dc.Verdict)
+	}
+	if len(dc.Candidates) == 0 {
+		t.Fatal("Expected candidates to be fetched")
+	}
+
+	for k, r := range []*Request{
+		{Subject: "alice", Resource: "articles:1", Action: "get"},
+		{Subject: "alice", Resource: "articles:1", Action: "update"},
+		{Subject: "alice", Resource: "articles:1", Action: "delete", Context: Context{"remoteIP": "10.1.2.3"}},
+		{Subject: "alice", Resource: "articles:1", Action: "delete", Context: Context{"remoteIP": "192.168.0.1"}},
+		{Subject: "alice", Resource: "articles:1", Action: "create"},
+	} {
+		expected := w.IsAllowed(r)
+		if err := w.EvaluateLocal(dc, r); errors.Cause(err) != errors.Cause(expected) {
+			t.Errorf("Case %d: expected %v like IsAllowed, got %v", k, expected, err)
+		}
+	}
+
+	if err := w.EvaluateLocal(dc, &Request{Subject: "alice", Resource: "articles:2", Action: "get"}); errors.Cause(err) != ErrDecisionContextMismatch {
+		t.Errorf("Expected %v for another resource, got %v", ErrDecisionContextMismatch, err)
+	}
+	if err := w.EvaluateLocal(dc, &Request{Subject: "bob", Resource: "articles:1", Action: "get"}); errors.Cause(err) != ErrDecisionContextMismatch {
+		t.Errorf("Expected %v for another subject, got %v", ErrDecisionContextMismatch, err)
+	}
+
+	dc, err = w.FetchDecisionContext(&Request{Subject: "alice", Resource: "articles:1", Action: "update"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errors.Cause(dc.Verdict) != ErrRequestForcefullyDenied {
+		t.Errorf("Expected the fetched request to be denied, got %v", dc.Verdict)
+	}
+}
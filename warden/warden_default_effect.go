@@ -0,0 +1,76 @@
+package warden
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/matcher"
+)
+
+// DefaultEffectWarden decides requests like ladon.Ladon, but decides requests which no policy matches
+// using a default effect. A policy matches a request if its action, subject and resource match, whether or
+// not its conditions are fulfilled, so a request failing the conditions of a matching allowing policy is
+// denied as usual and never falls back to the default. Requests which are denied by a policy stay denied.
+type DefaultEffectWarden struct {
+	Manager     Manager
+	Matcher     matcher.Matcher
+	AuditLogger AuditLogger
+
+	// DefaultEffect is the effect for requests no policy matches. It defaults to ladon.DenyAccess.
+	DefaultEffect string
+
+	// NamespaceEffects overrides DefaultEffect for the requests whose resource is in one of its
+	// namespaces, e.g. {"articles": ladon.AllowAccess} allows unmatched requests for the resources
+	// "articles" and "articles:1" only. The namespace of a resource is the part before its first colon.
+	NamespaceEffects map[string]string
+
+	// OnNoMatch, if set, is called for every request no policy matches, before the default effect is
+	// applied. Use it to log or alert on requests which aren't covered by any policy.
+	OnNoMatch func(r *Request)
+}
+
+// NewDefaultEffectWarden initializes a new DefaultEffectWarden using ladon.DefaultMatcher.
+func NewDefaultEffectWarden(manager Manager, defaultEffect string, onNoMatch func(r *Request)) *DefaultEffectWarden {
+	return &DefaultEffectWarden{Manager: manager, Matcher: DefaultMatcher, DefaultEffect: defaultEffect, OnNoMatch: onNoMatch}
+}
+
+// IsAllowed returns nil if the policies allow r, or if no policy matches r and its default effect is
+// ladon.AllowAccess.
+func (w *DefaultEffectWarden) IsAllowed(r *Request) error {
+	return w.IsAllowedContext(context.Background(), r)
+}
+
+// IsAllowedContext works like IsAllowed, but stops evaluating policies once ctx is done and returns
+// ctx.Err() instead.
+func (w *DefaultEffectWarden) IsAllowedContext(ctx context.Context, r *Request) error {
+	return isAllowed(ctx, w.Manager, w.evaluator(), r)
+}
+
+// DoPoliciesAllow returns nil if the policies allow the request and an error otherwise.
+func (w *DefaultEffectWarden) DoPoliciesAllow(r *Request, policies []Policy) error {
+	return w.evaluator().decide(context.Background(), r, policies)
+}
+
+// Effect returns the effect applied to r if no policy matches it.
+func (w *DefaultEffectWarden) Effect(r *Request) string {
+	namespace := r.Resource
+	if i := strings.IndexByte(namespace, ':'); i >= 0 {
+		namespace = namespace[:i]
+	}
+	if effect, ok := w.NamespaceEffects[namespace]; ok {
+		return effect
+	}
+	return w.DefaultEffect
+}
+
+func (w *DefaultEffectWarden) evaluator() *evaluator {
+	e := newEvaluator(w.Matcher, w.AuditLogger)
+	e.onNoMatch = func(r *Request) bool {
+		if w.OnNoMatch != nil {
+			w.OnNoMatch(r)
+		}
+		return w.Effect(r) == AllowAccess
+	}
+	return e
+}
@@ -0,0 +1,122 @@
+package warden
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+func TestDefaultEffectWarden(t *testing.T) {
+	m := memory.NewMemoryManager()
+	for _, p := range []*DefaultPolicy{
+		{
+			ID:        "allow-articles",
+			Subjects:  []string{"<.*>"},
+			Resources: []string{"articles:<.*>"},
+			Actions:   []string{"get"},
+			Effect:    AllowAccess,
+		},
+		{
+			ID:         "allow-reports-internally",
+			Subjects:   []string{"<.*>"},
+			Resources:  []string{"reports:<.*>"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{"ip": &CIDRCondition{CIDR: "10.0.0.0/8"}},
+		},
+		{
+			ID:        "deny-bob",
+			Subjects:  []string{"bob"},
+			Resources: []string{"<.*>"},
+			Actions:   []string{"<.*>"},
+			Effect:    DenyAccess,
+		},
+	} {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		allowed   = &Request{Subject: "alice", Resource: "articles:1", Action: "get"}
+		denied    = &Request{Subject: "bob", Resource: "users:1", Action: "get"}
+		unmatched = &Request{Subject: "alice", Resource: "users:1", Action: "get"}
+		external  = &Request{Subject: "alice", Resource: "reports:1", Action: "get", Context: Context{"ip": "192.168.0.1"}}
+		newWarden = func(effect string, unmatchedRequests *[]*Request) *DefaultEffectWarden {
+			return NewDefaultEffectWarden(m, effect, func(r *Request) {
+				*unmatchedRequests = append(*unmatchedRequests, r)
+			})
+		}
+	)
+
+	t.Run("Deny unmatched requests by default", func(t *testing.T) {
+		var unmatchedRequests []*Request
+		w := newWarden("", &unmatchedRequests)
+
+		if err := w.IsAllowed(allowed); err != nil {
+			t.Fatalf("Expected request to be allowed: %s", err)
+		}
+		if err := w.IsAllowed(denied); errors.Cause(err) != ErrRequestForcefullyDenied {
+			t.Fatalf("Expected request to be forcefully denied, got %v", err)
+		}
+		if err := w.IsAllowed(unmatched); errors.Cause(err) != ErrRequestDenied {
+			t.Fatalf("Expected request to be denied, got %v", err)
+		}
+		if len(unmatchedRequests) != 1 || unmatchedRequests[0] != unmatched {
+			t.Fatalf("Expected the hook to be called for the unmatched request only, got %+v", unmatchedRequests)
+		}
+	})
+
+	t.Run("Allow unmatched requests if opted in", func(t *testing.T) {
+		var unmatchedRequests []*Request
+		w := newWarden(AllowAccess, &unmatchedRequests)
+
+		if err := w.IsAllowed(unmatched); err != nil {
+			t.Fatalf("Expected request to be allowed: %s", err)
+		}
+		if err := w.IsAllowed(denied); errors.Cause(err) != ErrRequestForcefullyDenied {
+			t.Fatalf("Expected explicit denials to be kept, got %v", err)
+		}
+		if len(unmatchedRequests) != 1 {
+			t.Fatalf("Expected the hook to be called once, got %d", len(unmatchedRequests))
+		}
+	})
+
+	t.Run("Deny requests failing the conditions of a matching policy", func(t *testing.T) {
+		var unmatchedRequests []*Request
+		w := newWarden(AllowAccess, &unmatchedRequests)
+
+		if err := w.IsAllowed(external); errors.Cause(err) != ErrRequestDenied {
+			t.Fatalf("Expected request to be denied, got %v", err)
+		}
+		if len(unmatchedRequests) != 0 {
+			t.Fatalf("Expected the hook not to be called for a matching policy, got %+v", unmatchedRequests)
+		}
+	})
+
+	t.Run("Allow unmatched requests in some namespaces", func(t *testing.T) {
+		var unmatchedRequests []*Request
+		w := newWarden("", &unmatchedRequests)
+		w.NamespaceEffects = map[string]string{"users": AllowAccess}
+
+		for k, c := range []struct {
+			request  *Request
+			expected error
+		}{
+			{request: unmatched, expected: nil},
+			{request: &Request{Subject: "alice", Resource: "users", Action: "get"}, expected: nil},
+			{request: &Request{Subject: "alice", Resource: "groups:1", Action: "get"}, expected: ErrRequestDenied},
+			{request: &Request{Subject: "bob", Resource: "users:1", Action: "get"}, expected: ErrRequestForcefullyDenied},
+			{request: external, expected: ErrRequestDenied},
+		} {
+			if err := w.IsAllowed(c.request); errors.Cause(err) != c.expected {
+				t.Errorf("Case %d: expected %v, got %v", k, c.expected, err)
+			}
+		}
+		if len(unmatchedRequests) != 3 {
+			t.Fatalf("Expected the hook to be called for the 3 unmatched requests, got %d", len(unmatchedRequests))
+		}
+	})
+}
@@ -0,0 +1,93 @@
+package warden
+
+import (
+	"encoding/json"
+
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// DefaultDenyMessage is the message of a DeniedError if the deciding policy has no deny message.
+const DefaultDenyMessage = "The request was denied by a policy"
+
+// denyMessageKey is the key of the deny message in a policy's meta data.
+const denyMessageKey = "denyMessage"
+
+// DeniedError is returned by IsAllowedExplained if a policy explicitly denied the request. It unwraps to
+// ladon.ErrRequestForcefullyDenied, so errors.Cause and errors.Is keep recognizing it.
+type DeniedError struct {
+	// PolicyID is the ID of the denying policy.
+	PolicyID string
+
+	// Message is the policy's deny message, or DefaultDenyMessage if it has none.
+	Message string
+}
+
+func (e *DeniedError) Error() string {
+	return e.Message
+}
+
+// Unwrap returns ladon.ErrRequestForcefullyDenied for errors.Is.
+func (e *DeniedError) Unwrap() error {
+	return ErrRequestForcefullyDenied
+}
+
+// Cause returns ladon.ErrRequestForcefullyDenied for errors.Cause of github.com/pkg/errors.
+func (e *DeniedError) Cause() error {
+	return ErrRequestForcefullyDenied
+}
+
+// DenyMessage returns the message to show when p denies a request, e.g. "blocked by data-residency
+// policy". ladon's DefaultPolicy has no field for it, so it is stored as `denyMessage` in the policy's
+// meta data, which must be a JSON object. It returns an empty string if p has no deny message.
+func DenyMessage(p Policy) string {
+	var meta map[string]interface{}
+	if err := json.Unmarshal(p.GetMeta(), &meta); err != nil {
+		return ""
+	}
+	message, _ := meta[denyMessageKey].(string)
+	return message
+}
+
+// SetDenyMessage stores message as the deny message of p, keeping the rest of its meta data. The meta
+// data must be empty or a JSON object. An empty message removes the deny message.
+func SetDenyMessage(p *DefaultPolicy, message string) error {
+	meta := map[string]interface{}{}
+	if len(p.Meta) > 0 {
+		if err := json.Unmarshal(p.Meta, &meta); err != nil {
+			return errors.Wrapf(err, "meta data of policy %s is not a JSON object", p.ID)
+		}
+	}
+
+	if message == "" {
+		delete(meta, denyMessageKey)
+	} else {
+		meta[denyMessageKey] = message
+	}
+	if len(meta) == 0 {
+		p.Meta = nil
+		return nil
+	}
+
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	p.Meta = b
+	return nil
+}
+
+// IsAllowedExplained works like l.IsAllowed(r), but returns a DeniedError carrying the deny message of the
+// deciding policy if a policy explicitly denied the request. All other verdicts are returned unchanged.
+func IsAllowedExplained(l *Ladon, r *Request) error {
+	decider, err := IsAllowedReturning(l, r)
+	if errors.Cause(err) != ErrRequestForcefullyDenied || decider == nil {
+		return err
+	}
+
+	message := DenyMessage(decider)
+	if message == "" {
+		message = DefaultDenyMessage
+	}
+	return &DeniedError{PolicyID: decider.GetID(), Message: message}
+}
@@ -0,0 +1,93 @@
+package warden
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+func TestIsAllowedExplained(t *testing.T) {
+	residency := &DefaultPolicy{
+		ID:         "data-residency",
+		Subjects:   []string{"<.*>"},
+		Resources:  []string{"eu:<.*>"},
+		Actions:    []string{"export"},
+		Effect:     DenyAccess,
+		Conditions: Conditions{},
+		Meta:       []byte(`{"team":"legal"}`),
+	}
+	if err := SetDenyMessage(residency, "blocked by data-residency policy"); err != nil {
+		t.Fatal(err)
+	}
+
+	m := memory.NewMemoryManager()
+	for _, p := range []*DefaultPolicy{
+		{
+			ID:         "allow-all",
+			Subjects:   []string{"<.*>"},
+			Resources:  []string{"<.*>"},
+			Actions:    []string{"<.*>"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		},
+		residency,
+		{
+			ID:         "anonymous-deny",
+			Subjects:   []string{"mallory"},
+			Resources:  []string{"<.*>"},
+			Actions:    []string{"<.*>"},
+			Effect:     DenyAccess,
+			Conditions: Conditions{},
+		},
+	} {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w := &Ladon{Manager: m}
+
+	for k, c := range []struct {
+		request *Request
+		message string
+		policy  string
+	}{
+		{request: &Request{Subject: "alice", Resource: "eu:customers", Action: "export"}, message: "blocked by data-residency policy", policy: "data-residency"},
+		{request: &Request{Subject: "mallory", Resource: "us:customers", Action: "get"}, message: DefaultDenyMessage, policy: "anonymous-deny"},
+	} {
+		err := IsAllowedExplained(w, c.request)
+		if errors.Cause(err) != ErrRequestForcefullyDenied {
+			t.Fatalf("Case %d: expected %v, got %v", k, ErrRequestForcefullyDenied, err)
+		}
+		denied, ok := err.(*DeniedError)
+		if !ok {
+			t.Fatalf("Case %d: expected a DeniedError, got %T", k, err)
+		}
+		if err.Error() != c.message || denied.PolicyID != c.policy {
+			t.Errorf("Case %d: expected %q by %s, got %q by %s", k, c.message, c.policy, err, denied.PolicyID)
+		}
+	}
+
+	if err := IsAllowedExplained(w, &Request{Subject: "alice", Resource: "us:customers", Action: "get"}); err != nil {
+		t.Fatalf("Expected the request to be allowed, got %v", err)
+	}
+	if err := IsAllowedExplained(&Ladon{Manager: memory.NewMemoryManager()}, &Request{Subject: "alice"}); errors.Cause(err) != ErrRequestDenied {
+		t.Fatalf("Expected %v, got %v", ErrRequestDenied, err)
+	}
+
+	t.Run("Keep other meta data", func(t *testing.T) {
+		if DenyMessage(residency) != "blocked by data-residency policy" || string(residency.Meta) != `{"denyMessage":"blocked by data-residency policy","team":"legal"}` {
+			t.Fatalf("Unexpected meta data %s", residency.Meta)
+		}
+		if err := SetDenyMessage(residency, ""); err != nil {
+			t.Fatal(err)
+		}
+		if string(residency.Meta) != `{"team":"legal"}` {
+			t.Fatalf("Expected the deny message to be removed, got %s", residency.Meta)
+		}
+		if err := SetDenyMessage(&DefaultPolicy{ID: "broken", Meta: []byte(`[1]`)}, "nope"); err == nil {
+			t.Fatal("Expected meta data which isn't an object to be rejected")
+		}
+	})
+}
@@ -0,0 +1,395 @@
+package warden
+
+import (
+	"context"
+	"runtime"
+	"sync"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/matcher"
+	"github.com/pkg/errors"
+)
+
+// outcome is the result of evaluating a single policy against a request.
+type outcome int
+
+const (
+	// notApplicable policies don't match the request's action, subject or resource.
+	notApplicable outcome = iota
+	// allows means the policy applies and grants access.
+	allows
+	// denies means the policy applies and denies access.
+	denies
+	// unfulfilledAllow means the allowing policy matches the request but its conditions aren't fulfilled.
+	unfulfilledAllow
+	// unfulfilledDeny means the denying policy matches the request but its conditions aren't fulfilled, so
+	// it doesn't apply.
+	unfulfilledDeny
+	// flagged means the policy applies and flags the request without granting or denying access.
+	flagged
+)
+
+// evaluator decides requests against their candidate policies. It is the evaluation loop of
+// ConcurrentWarden, ModeWarden, TracingWarden, StreamingWarden and IsAllowedWithContext, which differ only
+// in how they configure it, so their features can be combined.
+type evaluator struct {
+	matcher     matcher.Matcher
+	auditLogger AuditLogger
+	mode        EvaluationMode
+
+	// workers is the number of policies evaluated in parallel. Policies are evaluated in order if it is
+	// zero, and a negative number evaluates them on GOMAXPROCS goroutines.
+	workers int
+
+	// tracer, if set, traces every evaluated condition with a SpanCondition span.
+	tracer Tracer
+
+	// onNoMatch, if set, is called for requests whose action, subject and resource no policy matches. The
+	// request is granted rather than denied if it returns true.
+	onNoMatch func(r *Request) bool
+}
+
+// newEvaluator initializes a new evaluator with the defaults of ladon.Ladon: ladon.DefaultMatcher and
+// ladon.DefaultAuditLogger.
+func newEvaluator(m matcher.Matcher, logger AuditLogger) *evaluator {
+	if m == nil {
+		m = DefaultMatcher
+	}
+	if logger == nil {
+		logger = DefaultAuditLogger
+	}
+	return &evaluator{matcher: m, auditLogger: logger}
+}
+
+// isAllowed looks up the candidates of r in m and decides r with e. ctx is checked before the lookup,
+// which can't be interrupted, and before each policy.
+func isAllowed(ctx context.Context, m Manager, e *evaluator, r *Request) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	policies, err := m.FindRequestCandidates(r)
+	if err != nil {
+		return err
+	}
+	return e.decide(ctx, r, policies)
+}
+
+// decide returns nil if the policies allow r and an error otherwise, logging the decision. ctx is checked
+// before each policy is evaluated, and its error is returned once it is done.
+func (e *evaluator) decide(ctx context.Context, r *Request, policies Policies) error {
+	if err := e.validate(r); err != nil {
+		return err
+	}
+
+	t := e.tally()
+	var err error
+	if e.workers != 0 {
+		err = e.parallel(ctx, r, policies, t)
+	} else {
+		err = e.sequential(ctx, r, policies, t)
+	}
+	if err != nil {
+		return err
+	}
+	return e.verdict(r, policies, t)
+}
+
+// sequential evaluates the policies in order until the verdict is settled.
+func (e *evaluator) sequential(ctx context.Context, r *Request, policies Policies, t *tally) error {
+	for _, p := range policies {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		o, err := e.evaluate(ctx, p, r)
+		if err != nil {
+			return err
+		}
+		if t.add(p, o) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// parallel evaluates the policies on e.workers goroutines until the verdict is settled. Which policies are
+// evaluated before it is settled depends on scheduling, as does the order of the deciders.
+func (e *evaluator) parallel(ctx context.Context, r *Request, policies Policies, t *tally) error {
+	workers := e.workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+
+		wg   sync.WaitGroup
+		jobs = make(chan Policy)
+		done = make(chan struct{})
+		once sync.Once
+	)
+	stop := func() {
+		once.Do(func() { close(done) })
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range jobs {
+				select {
+				case <-done:
+					continue
+				default:
+				}
+
+				var o outcome
+				err := ctx.Err()
+				if err == nil {
+					o, err = e.evaluate(ctx, p, r)
+				}
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+					stop()
+				} else if t.add(p, o) {
+					stop()
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, p := range policies {
+		select {
+		case jobs <- p:
+		case <-done:
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return firstErr
+}
+
+// evaluate returns the outcome of p for r. Its actions, subjects, resources and conditions are checked in
+// the same order ladon.Ladon checks them.
+func (e *evaluator) evaluate(ctx context.Context, p Policy, r *Request) (outcome, error) {
+	if ok, err := matches(e.matcher, p, r); err != nil || !ok {
+		return notApplicable, err
+	}
+	r, err := e.withCaptures(p, r)
+	if err != nil {
+		return notApplicable, err
+	}
+	if Flag(p) != "" {
+		// Flagged policies are neutral, so they never count as matching the request.
+		if !e.fulfills(ctx, p, r) {
+			return notApplicable, nil
+		}
+		return flagged, nil
+	}
+	switch {
+	case !e.fulfills(ctx, p, r):
+		// Unlike allowing policies, denying policies only count if their conditions are fulfilled.
+		if p.AllowAccess() {
+			return unfulfilledAllow, nil
+		}
+		return unfulfilledDeny, nil
+	case !p.AllowAccess():
+		return denies, nil
+	}
+	return allows, nil
+}
+
+// validate returns an error caused by ErrInvalidRequest if r's context contains matcher.CapturesKey,
+// which is reserved if e's matcher captures values.
+func (e *evaluator) validate(r *Request) error {
+	if _, ok := e.matcher.(matcher.CapturingMatcher); !ok {
+		return nil
+	}
+	if _, ok := r.Context[matcher.CapturesKey]; ok {
+		return errors.Wrapf(ErrInvalidRequest, "context key %s is reserved", matcher.CapturesKey)
+	}
+	return nil
+}
+
+// withCaptures returns r with the captures of p's resources stored in its context under
+// matcher.CapturesKey if e's matcher captures values, and r itself otherwise.
+func (e *evaluator) withCaptures(p Policy, r *Request) (*Request, error) {
+	m, ok := e.matcher.(matcher.CapturingMatcher)
+	if !ok {
+		return r, nil
+	}
+	captures, _, err := m.Captures(p, p.GetResources(), r.Resource)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	values := make(map[string]interface{}, len(captures))
+	for name, v := range captures {
+		values[name] = v
+	}
+	c := *r
+	c.Context = MergeContext(r.Context, Context{matcher.CapturesKey: values})
+	return &c, nil
+}
+
+// fulfills returns true if all of p's conditions are fulfilled by r, tracing each of them if e has a
+// tracer.
+func (e *evaluator) fulfills(ctx context.Context, p Policy, r *Request) bool {
+	if e.tracer == nil {
+		return fulfills(p, r)
+	}
+
+	for key, condition := range p.GetConditions() {
+		_, span := e.tracer.Start(ctx, SpanCondition)
+		span.SetAttribute(AttributePolicyID, p.GetID())
+		span.SetAttribute(AttributeKey, key)
+		span.SetAttribute(AttributeType, condition.GetName())
+
+		ok := condition.Fulfills(r.Context[key], r)
+		span.SetAttribute(AttributeFulfilled, ok)
+		span.End()
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// verdict returns the verdict of the tallied outcomes and passes it to the audit logger, together with
+// the policies which were considered.
+func (e *evaluator) verdict(r *Request, policies Policies, t *tally) error {
+	if l, ok := e.auditLogger.(FlagAuditLogger); ok && len(t.flagged) > 0 {
+		l.LogFlaggedAccessRequest(r, t.flagged)
+	}
+
+	switch {
+	case t.denier != nil:
+		e.auditLogger.LogRejectedAccessRequest(r, policies, append(t.deciders, t.denier))
+		return errors.WithStack(ErrRequestForcefullyDenied)
+	case len(t.deciders) == 0 && !t.matched && e.onNoMatch != nil && e.onNoMatch(r):
+		e.auditLogger.LogGrantedAccessRequest(r, policies, t.deciders)
+		return nil
+	case t.unfulfilled || len(t.deciders) == 0:
+		e.auditLogger.LogRejectedAccessRequest(r, policies, t.deciders)
+		return errors.WithStack(ErrRequestDenied)
+	}
+
+	e.auditLogger.LogGrantedAccessRequest(r, policies, t.deciders)
+	return nil
+}
+
+// tally returns an empty tally of e's mode.
+func (e *evaluator) tally() *tally {
+	return &tally{mode: e.mode, deciders: Policies{}}
+}
+
+// tally collects the outcomes of the policies evaluated for a request.
+type tally struct {
+	mode EvaluationMode
+
+	deciders    Policies
+	denier      Policy
+	unfulfilled bool
+
+	// matched is true if any policy matched the action, subject and resource of the request, whether or
+	// not its conditions were fulfilled.
+	matched bool
+
+	// flagged are the flagged policies which apply to the request.
+	flagged Policies
+
+	// priority is the highest priority of the applying policies in the PriorityWins mode, which only
+	// keeps the deciders and the denier of that priority.
+	priority int
+}
+
+// add records the outcome of p and returns true if the verdict is settled, so no further policies need
+// to be evaluated.
+func (t *tally) add(p Policy, o outcome) bool {
+	if o == flagged {
+		t.flagged = append(t.flagged, p)
+		return false
+	}
+	if o != notApplicable {
+		t.matched = true
+	}
+	if t.mode == PriorityWins {
+		return t.prioritize(p, o)
+	}
+
+	switch o {
+	case allows:
+		t.deciders = append(t.deciders, p)
+	case denies:
+		if t.denier == nil {
+			t.denier = p
+		}
+		return true
+	case unfulfilledAllow:
+		if t.mode == AllAllowsRequired {
+			t.unfulfilled = true
+		}
+	}
+	return false
+}
+
+// prioritize records the outcome of p in the PriorityWins mode. A higher priority policy could still
+// override any outcome, so the verdict is never settled early.
+func (t *tally) prioritize(p Policy, o outcome) bool {
+	if o != allows && o != denies {
+		return false
+	}
+
+	priority := Priority(p)
+	if len(t.deciders) > 0 || t.denier != nil {
+		if priority < t.priority {
+			return false
+		} else if priority > t.priority {
+			t.deciders, t.denier = Policies{}, nil
+		}
+	}
+	t.priority = priority
+
+	if o == allows {
+		t.deciders = append(t.deciders, p)
+	} else if t.denier == nil {
+		t.denier = p
+	}
+	return false
+}
+
+// matches returns true if p's actions, subjects and resources match r.
+func matches(m matcher.Matcher, p Policy, r *Request) (bool, error) {
+	for _, c := range []struct {
+		haystack []string
+		needle   string
+	}{
+		{haystack: p.GetActions(), needle: r.Action},
+		{haystack: p.GetSubjects(), needle: r.Subject},
+		{haystack: p.GetResources(), needle: r.Resource},
+	} {
+		if ok, err := m.Matches(p, c.haystack, c.needle); err != nil {
+			return false, errors.WithStack(err)
+		} else if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// fulfills returns true if all of p's conditions are fulfilled by r.
+func fulfills(p Policy, r *Request) bool {
+	for key, condition := range p.GetConditions() {
+		if !condition.Fulfills(r.Context[key], r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,106 @@
+package warden
+
+import (
+	"context"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/condition"
+	"github.com/ory/ladon-community/manager/redis"
+	"github.com/ory/ladon-community/manager/sqlmanager"
+	"github.com/ory/ladon-community/matcher"
+	"github.com/pkg/errors"
+)
+
+// The IDs of the policies FailModeWarden reports as deciding a request it decided because the manager
+// failed.
+const (
+	FailOpenPolicyID   = "fail-open"
+	FailClosedPolicyID = "fail-closed"
+)
+
+// FailModeWarden decides requests like ladon.Ladon, but decides how failures of the manager to look up
+// the candidates of a request are handled, e.g. a transient Redis error returned by
+// FindRequestCandidates. By default it fails closed and returns the error, like ladon.Ladon. Requests
+// whose action is listed in FailOpenActions, or for which FailOpen returns true, fail open instead: they
+// are allowed despite the error.
+//
+// Only errors of the lookup itself fail open. Invalid requests, which fail with an error caused by
+// ErrInvalidRequest, stored policies which can't be decoded, errors of the matcher and verdicts, i.e.
+// errors caused by ladon.ErrRequestDenied, ladon.ErrRequestForcefullyDenied or ErrTooManyCandidates, are
+// returned as is and never fail open.
+type FailModeWarden struct {
+	Manager Manager
+	Matcher matcher.Matcher
+
+	// AuditLogger receives the decisions, including the degraded ones. These are logged as granted or
+	// rejected with a single deciding policy whose ID is FailOpenPolicyID or FailClosedPolicyID and whose
+	// description is the error. Other decisions are dropped if AuditLogger is nil, like with ladon.Ladon,
+	// but because degraded decisions must never be silent, they are written to stderr by
+	// ladon.AuditLoggerInfo then.
+	AuditLogger AuditLogger
+
+	// FailOpenActions are the actions of requests which are allowed if the manager fails.
+	FailOpenActions []string
+
+	// FailOpen, if set, returns true if r is allowed although the manager failed with err.
+	FailOpen func(r *Request, err error) bool
+}
+
+// NewFailModeWarden initializes a new FailModeWarden using ladon.DefaultMatcher and failing open for the
+// given actions.
+func NewFailModeWarden(manager Manager, logger AuditLogger, failOpenActions ...string) *FailModeWarden {
+	return &FailModeWarden{Manager: manager, Matcher: DefaultMatcher, AuditLogger: logger, FailOpenActions: failOpenActions}
+}
+
+// IsAllowed returns nil if the policies allow r, or if the manager failed and r fails open.
+func (w *FailModeWarden) IsAllowed(r *Request) error {
+	if err := Validate(r); err != nil {
+		return err
+	}
+
+	policies, err := w.Manager.FindRequestCandidates(r)
+	if err != nil {
+		if !degradable(err) {
+			return err
+		}
+		if w.failsOpen(r, err) {
+			w.degradedLogger().LogGrantedAccessRequest(r, Policies{}, Policies{failModePolicy(FailOpenPolicyID, AllowAccess, err)})
+			return nil
+		}
+		w.degradedLogger().LogRejectedAccessRequest(r, Policies{}, Policies{failModePolicy(FailClosedPolicyID, DenyAccess, err)})
+		return err
+	}
+	return newEvaluator(w.Matcher, w.AuditLogger).decide(context.Background(), r, policies)
+}
+
+func (w *FailModeWarden) failsOpen(r *Request, err error) bool {
+	for _, a := range w.FailOpenActions {
+		if a == r.Action {
+			return true
+		}
+	}
+	return w.FailOpen != nil && w.FailOpen(r, err)
+}
+
+func (w *FailModeWarden) degradedLogger() AuditLogger {
+	if w.AuditLogger == nil {
+		return &AuditLoggerInfo{}
+	}
+	return w.AuditLogger
+}
+
+// degradable returns true if err is a failure of the manager to look up candidates which a request may
+// fail open on, rather than a verdict or a stored policy which can't be decoded.
+func degradable(err error) bool {
+	switch errors.Cause(err) {
+	case ErrRequestDenied, ErrRequestForcefullyDenied, ErrTooManyCandidates, ErrInvalidRequest,
+		redis.ErrBadConversion, sqlmanager.ErrBadConversion, condition.ErrMalformedPolicy:
+		return false
+	}
+	return true
+}
+
+// failModePolicy returns the policy passed to the audit logger as the decider of a degraded decision.
+func failModePolicy(id, effect string, err error) Policy {
+	return &DefaultPolicy{ID: id, Description: err.Error(), Effect: effect}
+}
@@ -0,0 +1,84 @@
+package warden
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/ladontest"
+	"github.com/ory/ladon-community/manager/redis"
+	"github.com/pkg/errors"
+)
+
+func TestFailModeWarden(t *testing.T) {
+	unavailable := errors.New("redis: connection refused")
+	failing := ladontest.NewMockManager(nil, unavailable)
+	broken := ladontest.NewMockManager(nil, &redis.PolicyError{ID: "broken", Err: errors.Wrap(redis.ErrBadConversion, "invalid character")})
+
+	allowGet := &DefaultPolicy{
+		ID:        "allow-get",
+		Subjects:  []string{"<.*>"},
+		Resources: []string{"<.*>"},
+		Actions:   []string{"get"},
+		Effect:    AllowAccess,
+	}
+	healthy := ladontest.NewMockManager(Policies{allowGet}, nil)
+	badPattern := ladontest.NewMockManager(Policies{&DefaultPolicy{
+		ID:        "bad-pattern",
+		Subjects:  []string{"<[>"},
+		Resources: []string{"<.*>"},
+		Actions:   []string{"get"},
+		Effect:    AllowAccess,
+	}}, nil)
+
+	for k, c := range []struct {
+		manager  Manager
+		failOpen func(r *Request, err error) bool
+		request  *Request
+		expected error
+		granted  int
+		rejected int
+		decider  string
+	}{
+		// Fail closed by default.
+		{manager: failing, request: &Request{Subject: "alice", Resource: "articles:1", Action: "update"}, expected: unavailable, rejected: 1},
+		// Fail open for the configured actions.
+		{manager: failing, request: &Request{Subject: "alice", Resource: "articles:1", Action: "get"}, expected: nil, granted: 1, decider: FailOpenPolicyID},
+		// Fail open for the requests FailOpen selects.
+		{
+			manager:  failing,
+			failOpen: func(r *Request, err error) bool { return r.Resource == "health" && errors.Cause(err) == unavailable },
+			request:  &Request{Subject: "alice", Resource: "health", Action: "update"},
+			expected: nil,
+			granted:  1,
+			decider:  FailOpenPolicyID,
+		},
+		// Verdicts are never degraded.
+		{manager: healthy, request: &Request{Subject: "alice", Resource: "articles:1", Action: "get"}, expected: nil, granted: 1, decider: "allow-get"},
+		{manager: healthy, request: &Request{Subject: "alice", Resource: "articles:1", Action: "update"}, expected: ErrRequestDenied, rejected: 1},
+		{manager: healthy, failOpen: func(*Request, error) bool { return true }, request: &Request{Subject: "alice", Resource: "articles:1", Action: "update"}, expected: ErrRequestDenied, rejected: 1},
+		// Invalid requests and broken stored policies stay denied.
+		{manager: failing, request: &Request{Subject: "alice", Action: "get"}, expected: ErrInvalidRequest},
+		{manager: broken, request: &Request{Subject: "alice", Resource: "articles:1", Action: "get"}, expected: redis.ErrBadConversion},
+	} {
+		logger := &decidersAuditLogger{}
+		w := NewFailModeWarden(c.manager, logger, "get")
+		w.FailOpen = c.failOpen
+
+		if err := w.IsAllowed(c.request); errors.Cause(err) != c.expected {
+			t.Errorf("Case %d: expected %v, got %v", k, c.expected, err)
+		}
+		if logger.granted != c.granted || logger.rejected != c.rejected {
+			t.Errorf("Case %d: expected %d granted and %d rejected decisions, got %d and %d", k, c.granted, c.rejected, logger.granted, logger.rejected)
+		}
+		if c.decider != "" && (len(logger.deciders) != 1 || logger.deciders[0] != c.decider) {
+			t.Errorf("Case %d: expected the decision to be logged as decided by %s, got %v", k, c.decider, logger.deciders)
+		}
+	}
+
+	t.Run("Keep matcher errors denied", func(t *testing.T) {
+		w := NewFailModeWarden(badPattern, nil, "get")
+		if err := w.IsAllowed(&Request{Subject: "alice", Resource: "articles:1", Action: "get"}); err == nil {
+			t.Fatal("Expected a matcher error to deny the request")
+		}
+	})
+}
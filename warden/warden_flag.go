@@ -0,0 +1,60 @@
+package warden
+
+import (
+	"encoding/json"
+
+	. "github.com/ory/ladon"
+)
+
+// The flags of policies which neither grant nor deny requests, but flag them.
+const (
+	// AuditAccess flags a request for heightened logging.
+	AuditAccess = "audit"
+
+	// ChallengeAccess flags a request for step-up authentication.
+	ChallengeAccess = "challenge"
+)
+
+// flagKey is the key of the flag in a policy's meta data.
+const flagKey = "flag"
+
+// FlagAuditLogger is implemented by audit loggers which want to know about the flagged policies applying
+// to a request. The wardens of this package evaluating policies themselves, like ModeWarden, call
+// LogFlaggedAccessRequest before logging the verdict.
+type FlagAuditLogger interface {
+	LogFlaggedAccessRequest(r *Request, flagged Policies)
+}
+
+// Flag returns the flag of p, e.g. AuditAccess, or an empty string if p isn't flagged. ladon's
+// DefaultPolicy has no field for it, so it is stored as the string `flag` in the policy's meta data, e.g.
+//
+//	{"flag": "audit"}
+//
+// A flagged policy neither grants nor denies a request, whatever its effect: if its action, subject,
+// resource and conditions match, it is reported to the audit logger through FlagAuditLogger and in the
+// Decision of TestPolicy, and the verdict is left to the other policies. Flagged policies don't count as
+// matching a request for DefaultEffectWarden. Only the wardens of this package evaluating policies
+// themselves know about flags; ladon.Ladon applies a flagged policy's effect like any other.
+//
+// Flagged policies evaluated after a denying policy settled the verdict aren't reported.
+func Flag(p Policy) string {
+	if len(p.GetMeta()) == 0 {
+		return ""
+	}
+	var meta struct {
+		Flag string `json:"flag"`
+	}
+	if err := json.Unmarshal(p.GetMeta(), &meta); err != nil {
+		return ""
+	}
+	return meta.Flag
+}
+
+// SetFlag stores flag as the flag of p, keeping the rest of its meta data. The meta data must be empty or
+// a JSON object. An empty flag removes the flag.
+func SetFlag(p *DefaultPolicy, flag string) error {
+	if flag == "" {
+		return setMeta(p, flagKey, nil)
+	}
+	return setMeta(p, flagKey, flag)
+}
@@ -0,0 +1,125 @@
+package warden
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+// flagsAuditLogger records the IDs of the flagged policies reported for the last request.
+type flagsAuditLogger struct {
+	countingAuditLogger
+	flagged []string
+}
+
+func (a *flagsAuditLogger) LogFlaggedAccessRequest(_ *Request, flagged Policies) {
+	for _, p := range flagged {
+		a.flagged = append(a.flagged, p.GetID())
+	}
+}
+
+func TestFlag(t *testing.T) {
+	flag := func(flag string, p *DefaultPolicy) *DefaultPolicy {
+		if err := SetFlag(p, flag); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	auditArticles := flag(AuditAccess, &DefaultPolicy{
+		ID:        "audit-articles",
+		Subjects:  []string{"<.*>"},
+		Resources: []string{"articles:<.*>"},
+		Actions:   []string{"<.*>"},
+		Effect:    DenyAccess,
+	})
+	m := memory.NewMemoryManager()
+	for _, p := range []*DefaultPolicy{
+		{
+			ID:        "allow-articles",
+			Subjects:  []string{"<.*>"},
+			Resources: []string{"articles:<.*>"},
+			Actions:   []string{"get"},
+			Effect:    AllowAccess,
+		},
+		auditArticles,
+		flag(ChallengeAccess, &DefaultPolicy{
+			ID:         "challenge-external",
+			Subjects:   []string{"<.*>"},
+			Resources:  []string{"<.*>"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{"ip": &CIDRCondition{CIDR: "192.168.0.0/16"}},
+		}),
+	} {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for k, c := range []struct {
+		request  *Request
+		expected error
+		flagged  []string
+	}{
+		// Flagged policies don't change the verdict, whatever their effect.
+		{request: &Request{Subject: "alice", Resource: "articles:1", Action: "get", Context: Context{"ip": "10.0.0.1"}}, expected: nil, flagged: []string{"audit-articles"}},
+		{request: &Request{Subject: "alice", Resource: "articles:1", Action: "update", Context: Context{"ip": "10.0.0.1"}}, expected: ErrRequestDenied, flagged: []string{"audit-articles"}},
+		{request: &Request{Subject: "alice", Resource: "articles:1", Action: "get", Context: Context{"ip": "192.168.0.1"}}, expected: nil, flagged: []string{"audit-articles", "challenge-external"}},
+		{request: &Request{Subject: "alice", Resource: "users:1", Action: "get", Context: Context{"ip": "192.168.0.1"}}, expected: ErrRequestDenied, flagged: []string{"challenge-external"}},
+		// Flagged policies are only reported if they apply.
+		{request: &Request{Subject: "alice", Resource: "users:1", Action: "get", Context: Context{"ip": "10.0.0.1"}}, expected: ErrRequestDenied},
+	} {
+		for _, newWarden := range []func(logger AuditLogger) Warden{
+			func(logger AuditLogger) Warden {
+				return &ModeWarden{Manager: m, Matcher: DefaultMatcher, AuditLogger: logger}
+			},
+			func(logger AuditLogger) Warden {
+				return &TracingWarden{Manager: m, Matcher: DefaultMatcher, AuditLogger: logger, Workers: 2}
+			},
+		} {
+			logger := &flagsAuditLogger{}
+			w := newWarden(logger)
+			if err := w.IsAllowed(c.request); errors.Cause(err) != c.expected {
+				t.Errorf("Case %d: expected %v from %T, got %v", k, c.expected, w, err)
+			}
+			sort.Strings(logger.flagged)
+			if !cmp.Equal(c.flagged, logger.flagged) {
+				t.Errorf("Case %d: unexpected flagged policies from %T\n%s", k, w, cmp.Diff(c.flagged, logger.flagged))
+			}
+			if logger.granted+logger.rejected != 1 {
+				t.Errorf("Case %d: expected the verdict of %T to be logged once, got %d times", k, w, logger.granted+logger.rejected)
+			}
+		}
+	}
+
+	t.Run("Report the flag in the decision", func(t *testing.T) {
+		d, err := TestPolicy(auditArticles, &Request{Subject: "alice", Resource: "articles:1", Action: "get"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := (&Decision{Applies: true, Flag: AuditAccess}); !cmp.Equal(expected, d) {
+			t.Fatalf("Unexpected decision\n%s", cmp.Diff(expected, d))
+		}
+	})
+
+	t.Run("Keep the rest of the meta data", func(t *testing.T) {
+		p := &DefaultPolicy{ID: "1", Meta: []byte(`{"priority":5}`)}
+		if err := SetFlag(p, ChallengeAccess); err != nil {
+			t.Fatal(err)
+		}
+		if Flag(p) != ChallengeAccess || Priority(p) != 5 {
+			t.Fatalf("Unexpected meta data %s", p.Meta)
+		}
+		if err := SetFlag(p, ""); err != nil {
+			t.Fatal(err)
+		}
+		if string(p.Meta) != `{"priority":5}` {
+			t.Fatalf("Expected the flag to be removed, got %s", p.Meta)
+		}
+	})
+}
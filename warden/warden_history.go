@@ -0,0 +1,31 @@
+package warden
+
+import (
+	"time"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/manager"
+)
+
+// HistoryWarden is a ladon.Ladon which can also decide requests against the policies stored at an earlier
+// time, e.g. to reconstruct whether a request would have been allowed last week. Its manager has to keep a
+// history, see manager.HistoryReader.
+type HistoryWarden struct {
+	*Ladon
+}
+
+// NewHistoryWarden initializes a new HistoryWarden.
+func NewHistoryWarden(l *Ladon) *HistoryWarden {
+	return &HistoryWarden{Ladon: l}
+}
+
+// IsAllowedAsOf returns nil if the policies stored at the given time allow r and an error otherwise, like
+// IsAllowed does for the current policies. It returns manager.ErrHistoryUnsupported if the manager doesn't
+// keep a history. Decisions are passed to the audit logger like those of IsAllowed.
+func (w *HistoryWarden) IsAllowedAsOf(r *Request, at time.Time) error {
+	policies, err := manager.GetAllAsOf(w.Manager, at)
+	if err != nil {
+		return err
+	}
+	return w.DoPoliciesAllow(r, policies)
+}
@@ -0,0 +1,65 @@
+package warden
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/manager"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+// historyManager is a memory manager which returns the policies of versions as of a given time.
+type historyManager struct {
+	*memory.MemoryManager
+	versions map[time.Time]Policies
+}
+
+func (m *historyManager) GetAllAsOf(at time.Time) (Policies, error) {
+	var (
+		latest   time.Time
+		policies = Policies{}
+	)
+	for t, p := range m.versions {
+		if !t.After(at) && !t.Before(latest) {
+			latest, policies = t, p
+		}
+	}
+	return policies, nil
+}
+
+func TestHistoryWarden(t *testing.T) {
+	var (
+		created = time.Date(2020, 1, 7, 0, 0, 0, 0, time.UTC)
+		revoked = created.Add(24 * time.Hour)
+		policy  = &DefaultPolicy{
+			ID:        "articles",
+			Subjects:  []string{"alice"},
+			Resources: []string{"articles:<.*>"},
+			Actions:   []string{"get"},
+			Effect:    AllowAccess,
+		}
+		r = &Request{Subject: "alice", Resource: "articles:1", Action: "get"}
+	)
+	m := &historyManager{
+		MemoryManager: memory.NewMemoryManager(),
+		versions:      map[time.Time]Policies{created: {policy}, revoked: {}},
+	}
+	w := NewHistoryWarden(&Ladon{Manager: m, Matcher: DefaultMatcher})
+
+	if err := w.IsAllowedAsOf(r, created.Add(time.Hour)); err != nil {
+		t.Errorf("Expected the request to be allowed before the policy was revoked: %s", err)
+	}
+	if err := w.IsAllowedAsOf(r, revoked.Add(time.Hour)); errors.Cause(err) != ErrRequestDenied {
+		t.Errorf("Expected the request to be denied after the policy was revoked, got %v", err)
+	}
+	if err := w.IsAllowed(r); errors.Cause(err) != ErrRequestDenied {
+		t.Errorf("Expected IsAllowed to use the current policies, got %v", err)
+	}
+
+	w = NewHistoryWarden(&Ladon{Manager: memory.NewMemoryManager(), Matcher: DefaultMatcher})
+	if err := w.IsAllowedAsOf(r, created); errors.Cause(err) != manager.ErrHistoryUnsupported {
+		t.Errorf("Expected ErrHistoryUnsupported, got %v", err)
+	}
+}
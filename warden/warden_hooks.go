@@ -0,0 +1,72 @@
+package warden
+
+import (
+	. "github.com/ory/ladon"
+)
+
+// BeforeDecision is called before a request is evaluated. Returning an error denies the request with
+// that error without evaluating it; hooks can't allow a request before it is evaluated.
+type BeforeDecision interface {
+	BeforeDecision(r *Request) error
+}
+
+// AfterDecision is called with the verdict of the wrapped warden, nil meaning the request is allowed.
+// Returning nil keeps the verdict; a hook has to return an Override to change it.
+type AfterDecision interface {
+	AfterDecision(r *Request, verdict error) *Override
+}
+
+// Override replaces the verdict of a request. An Override with a nil Err allows the request.
+type Override struct {
+	Err error
+}
+
+// BeforeDecisionFunc adapts a function to the BeforeDecision interface.
+type BeforeDecisionFunc func(r *Request) error
+
+// BeforeDecision calls f(r).
+func (f BeforeDecisionFunc) BeforeDecision(r *Request) error {
+	return f(r)
+}
+
+// AfterDecisionFunc adapts a function to the AfterDecision interface.
+type AfterDecisionFunc func(r *Request, verdict error) *Override
+
+// AfterDecision calls f(r, verdict).
+func (f AfterDecisionFunc) AfterDecision(r *Request, verdict error) *Override {
+	return f(r, verdict)
+}
+
+// HookedWarden wraps a warden and runs hooks before and after each decision, e.g. for tenant isolation
+// checks or for comparing verdicts with another policy engine in shadow mode. Before hooks run in order
+// and the first error stops evaluation. After hooks run in order as well, each seeing the verdict as
+// overridden by the previous ones; they also run for requests denied by a before hook.
+type HookedWarden struct {
+	Warden Warden
+	Before []BeforeDecision
+	After  []AfterDecision
+}
+
+// IsAllowed runs the before hooks, the wrapped warden and the after hooks and returns the final verdict.
+func (w *HookedWarden) IsAllowed(r *Request) error {
+	verdict := w.before(r)
+	if verdict == nil {
+		verdict = w.Warden.IsAllowed(r)
+	}
+
+	for _, h := range w.After {
+		if o := h.AfterDecision(r, verdict); o != nil {
+			verdict = o.Err
+		}
+	}
+	return verdict
+}
+
+func (w *HookedWarden) before(r *Request) error {
+	for _, h := range w.Before {
+		if err := h.BeforeDecision(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
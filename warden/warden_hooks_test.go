@@ -0,0 +1,106 @@
+package warden
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+func TestHookedWarden(t *testing.T) {
+	m := memory.NewMemoryManager()
+	if err := m.Create(&DefaultPolicy{
+		ID:        "allow-articles",
+		Subjects:  []string{"<.*>"},
+		Resources: []string{"articles:<.*>"},
+		Actions:   []string{"get"},
+		Effect:    AllowAccess,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	l := &Ladon{Manager: m, Matcher: DefaultMatcher}
+
+	var (
+		allowed = &Request{Subject: "alice", Resource: "articles:1", Action: "get"}
+		denied  = &Request{Subject: "alice", Resource: "users:1", Action: "get"}
+	)
+
+	t.Run("Run both hooks and observe the verdict", func(t *testing.T) {
+		var (
+			before   []*Request
+			verdicts []error
+		)
+		w := &HookedWarden{
+			Warden: l,
+			Before: []BeforeDecision{BeforeDecisionFunc(func(r *Request) error {
+				before = append(before, r)
+				return nil
+			})},
+			After: []AfterDecision{AfterDecisionFunc(func(r *Request, verdict error) *Override {
+				verdicts = append(verdicts, verdict)
+				return nil
+			})},
+		}
+
+		if err := w.IsAllowed(allowed); err != nil {
+			t.Fatalf("Expected request to be allowed: %s", err)
+		}
+		if err := w.IsAllowed(denied); errors.Cause(err) != ErrRequestDenied {
+			t.Fatalf("Expected %v, got %v", ErrRequestDenied, err)
+		}
+
+		if len(before) != 2 || before[0] != allowed || before[1] != denied {
+			t.Fatalf("Expected the before hook to see both requests, got %+v", before)
+		}
+		if len(verdicts) != 2 || verdicts[0] != nil || errors.Cause(verdicts[1]) != ErrRequestDenied {
+			t.Fatalf("Expected the after hook to observe both verdicts, got %+v", verdicts)
+		}
+	})
+
+	t.Run("Deny in a before hook without evaluation", func(t *testing.T) {
+		errTenant := errors.New("tenant mismatch")
+		var verdict error
+		w := &HookedWarden{
+			Warden: &Ladon{Manager: &slowManager{Manager: m}},
+			Before: []BeforeDecision{BeforeDecisionFunc(func(r *Request) error {
+				return errTenant
+			})},
+			After: []AfterDecision{AfterDecisionFunc(func(r *Request, v error) *Override {
+				verdict = v
+				return nil
+			})},
+		}
+
+		if err := w.IsAllowed(allowed); err != errTenant {
+			t.Fatalf("Expected %v, got %v", errTenant, err)
+		}
+		if verdict != errTenant {
+			t.Fatalf("Expected the after hook to observe %v, got %v", errTenant, verdict)
+		}
+	})
+
+	t.Run("Override verdicts explicitly only", func(t *testing.T) {
+		w := &HookedWarden{
+			Warden: l,
+			After: []AfterDecision{
+				AfterDecisionFunc(func(r *Request, verdict error) *Override {
+					if r.Resource == "users:1" {
+						return &Override{}
+					}
+					return nil
+				}),
+				AfterDecisionFunc(func(r *Request, verdict error) *Override {
+					return nil
+				}),
+			},
+		}
+
+		if err := w.IsAllowed(denied); err != nil {
+			t.Fatalf("Expected the override to allow the request: %s", err)
+		}
+		if err := w.IsAllowed(&Request{Subject: "alice", Resource: "users:2", Action: "get"}); errors.Cause(err) != ErrRequestDenied {
+			t.Fatalf("Expected %v, got %v", ErrRequestDenied, err)
+		}
+	})
+}
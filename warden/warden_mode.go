@@ -0,0 +1,69 @@
+package warden
+
+import (
+	"context"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/matcher"
+)
+
+// EvaluationMode selects how ModeWarden combines allowing policies.
+type EvaluationMode int
+
+const (
+	// AnyAllowGrants grants a request if at least one allowing policy applies, like ladon.Ladon. Allowing
+	// policies whose conditions aren't fulfilled are ignored.
+	AnyAllowGrants EvaluationMode = iota
+
+	// AllAllowsRequired grants a request only if the conditions of every allowing policy matching its
+	// action, subject and resource are fulfilled. A single matching allowing policy with an unfulfilled
+	// condition denies the request.
+	AllAllowsRequired
+
+	// PriorityWins lets the applying policies of the highest Priority decide a request: it is granted if
+	// they all allow it and denied with ladon.ErrRequestForcefullyDenied if any of them denies it, so deny
+	// still wins among policies of equal priority. A narrow allowing policy can thereby override a broad
+	// denying one by having a higher priority. Policies apply if their action, subject, resource and
+	// conditions match; allowing policies whose conditions aren't fulfilled are ignored, as in the
+	// AnyAllowGrants mode. Policies without a priority have priority zero, so this mode decides like
+	// AnyAllowGrants as long as no policy has a priority.
+	PriorityWins
+)
+
+// ModeWarden decides requests like ladon.Ladon, but lets Mode choose how allowing policies are combined.
+// Except in the PriorityWins mode, an applying denying policy wins over any allowing policy and the
+// request is denied with ladon.ErrRequestForcefullyDenied. Requests denied because of an unfulfilled allowing policy in the
+// AllAllowsRequired mode fail with ladon.ErrRequestDenied, as do requests no policy applies to.
+type ModeWarden struct {
+	Manager     Manager
+	Matcher     matcher.Matcher
+	AuditLogger AuditLogger
+	Mode        EvaluationMode
+}
+
+// NewModeWarden initializes a new ModeWarden using ladon.DefaultMatcher.
+func NewModeWarden(manager Manager, mode EvaluationMode) *ModeWarden {
+	return &ModeWarden{Manager: manager, Matcher: DefaultMatcher, Mode: mode}
+}
+
+// IsAllowed returns nil if subject s has the permission p on resource r with context c or an error otherwise.
+func (w *ModeWarden) IsAllowed(r *Request) error {
+	return w.IsAllowedContext(context.Background(), r)
+}
+
+// IsAllowedContext works like IsAllowed, but stops evaluating policies once ctx is done and returns
+// ctx.Err() instead.
+func (w *ModeWarden) IsAllowedContext(ctx context.Context, r *Request) error {
+	return isAllowed(ctx, w.Manager, w.evaluator(), r)
+}
+
+// DoPoliciesAllow returns nil if the policies allow the request and an error otherwise.
+func (w *ModeWarden) DoPoliciesAllow(r *Request, policies []Policy) error {
+	return w.evaluator().decide(context.Background(), r, policies)
+}
+
+func (w *ModeWarden) evaluator() *evaluator {
+	e := newEvaluator(w.Matcher, w.AuditLogger)
+	e.mode = w.Mode
+	return e
+}
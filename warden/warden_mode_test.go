@@ -0,0 +1,85 @@
+package warden
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+func TestModeWarden(t *testing.T) {
+	m := memory.NewMemoryManager()
+	for _, p := range []*DefaultPolicy{
+		{
+			ID:         "allow-internal-network",
+			Subjects:   []string{"<.*>"},
+			Resources:  []string{"articles:<.*>"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{"ip": &CIDRCondition{CIDR: "10.0.0.0/8"}},
+		},
+		{
+			ID:         "allow-owner",
+			Subjects:   []string{"<.*>"},
+			Resources:  []string{"articles:<.*>"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{"owner": &EqualsSubjectCondition{}},
+		},
+		{
+			ID:         "deny-bob-when-flagged",
+			Subjects:   []string{"bob"},
+			Resources:  []string{"<.*>"},
+			Actions:    []string{"<.*>"},
+			Effect:     DenyAccess,
+			Conditions: Conditions{"flagged": &BooleanCondition{BooleanValue: true}},
+		},
+	} {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		both     = &Request{Subject: "alice", Resource: "articles:1", Action: "get", Context: Context{"ip": "10.0.0.1", "owner": "alice"}}
+		ipOnly   = &Request{Subject: "alice", Resource: "articles:1", Action: "get", Context: Context{"ip": "10.0.0.1", "owner": "bob"}}
+		neither  = &Request{Subject: "alice", Resource: "articles:1", Action: "get", Context: Context{"ip": "192.168.0.1"}}
+		unknown  = &Request{Subject: "alice", Resource: "users:1", Action: "get"}
+		flagged  = &Request{Subject: "bob", Resource: "articles:1", Action: "get", Context: Context{"ip": "10.0.0.1", "owner": "bob", "flagged": true}}
+		accepted = &Request{Subject: "bob", Resource: "articles:1", Action: "get", Context: Context{"ip": "10.0.0.1", "owner": "bob", "flagged": false}}
+	)
+
+	for k, c := range []struct {
+		mode     EvaluationMode
+		request  *Request
+		expected error
+	}{
+		{mode: AnyAllowGrants, request: both, expected: nil},
+		{mode: AnyAllowGrants, request: ipOnly, expected: nil},
+		{mode: AnyAllowGrants, request: neither, expected: ErrRequestDenied},
+		{mode: AnyAllowGrants, request: unknown, expected: ErrRequestDenied},
+		{mode: AnyAllowGrants, request: flagged, expected: ErrRequestForcefullyDenied},
+		{mode: AnyAllowGrants, request: accepted, expected: nil},
+		{mode: AllAllowsRequired, request: both, expected: nil},
+		{mode: AllAllowsRequired, request: ipOnly, expected: ErrRequestDenied},
+		{mode: AllAllowsRequired, request: neither, expected: ErrRequestDenied},
+		{mode: AllAllowsRequired, request: unknown, expected: ErrRequestDenied},
+		{mode: AllAllowsRequired, request: flagged, expected: ErrRequestForcefullyDenied},
+		{mode: AllAllowsRequired, request: accepted, expected: nil},
+	} {
+		w := NewModeWarden(m, c.mode)
+		if err := w.IsAllowed(c.request); errors.Cause(err) != c.expected {
+			t.Errorf("Case %d: expected %v, got %v", k, c.expected, err)
+		}
+	}
+
+	t.Run("Match ladon in the AnyAllowGrants mode", func(t *testing.T) {
+		l := &Ladon{Manager: m}
+		for k, r := range []*Request{both, ipOnly, neither, unknown, flagged, accepted} {
+			if expected, actual := errors.Cause(l.IsAllowed(r)), errors.Cause(NewModeWarden(m, AnyAllowGrants).IsAllowed(r)); expected != actual {
+				t.Errorf("Case %d: expected %v, got %v", k, expected, actual)
+			}
+		}
+	})
+}
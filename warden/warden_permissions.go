@@ -0,0 +1,169 @@
+package warden
+
+import (
+	"sort"
+	"strings"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/matcher"
+)
+
+// Permission is an action a subject may perform on a resource. Resource and Action are taken from the
+// granting policies and thus may be patterns like `articles:<.*>`.
+type Permission struct {
+	Resource string `json:"resource"`
+	Action   string `json:"action"`
+
+	// Conditional is true if the permission doesn't hold for every request, because all granting policies
+	// have conditions, or because a denying policy with conditions covers it or a denying policy covers
+	// part of it, e.g. `articles:1` of `articles:<.*>`.
+	Conditional bool `json:"conditional"`
+
+	// Policies are the IDs of the allowing policies granting the permission, in ascending order.
+	Policies []string `json:"policies"`
+}
+
+// EffectivePermissions returns what subject may do according to the policies of w, e.g. to show users
+// their permissions. Every resource and action pair of the allowing policies matching subject is
+// returned, except pairs covered by an unconditional denying policy, sorted by resource and action.
+//
+// Conditions depend on the request context, so they are not evaluated. Permissions which might be
+// denied by a condition are marked as Conditional instead. Patterns can't be intersected, so a denying
+// pattern is taken to cover an allowing one if it matches its text, e.g. `<.*>` covers `articles:<.*>`,
+// and to cover part of it if the allowing pattern matches one of the denying literals. Other
+// overlapping patterns are not detected.
+func EffectivePermissions(w *Ladon, subject string) ([]Permission, error) {
+	var m matcher.Matcher = DefaultMatcher
+	if w.Matcher != nil {
+		m = w.Matcher
+	}
+
+	candidates, err := w.Manager.FindPoliciesForSubject(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	var allows, denies Policies
+	for _, p := range candidates {
+		if ok, err := m.Matches(p, p.GetSubjects(), subject); err != nil {
+			return nil, err
+		} else if !ok {
+			continue
+		}
+		if p.AllowAccess() {
+			allows = append(allows, p)
+		} else {
+			denies = append(denies, p)
+		}
+	}
+
+	type pair struct{ resource, action string }
+	var (
+		permissions = map[pair]*Permission{}
+		order       []pair
+	)
+	for _, allow := range allows {
+		for _, resource := range allow.GetResources() {
+		actions:
+			for _, action := range allow.GetActions() {
+				conditional := len(allow.GetConditions()) > 0
+				for _, deny := range denies {
+					covered, overlaps, err := covers(m, allow, deny, resource, action)
+					if err != nil {
+						return nil, err
+					}
+					if covered && len(deny.GetConditions()) == 0 {
+						continue actions
+					}
+					if covered || overlaps {
+						conditional = true
+					}
+				}
+
+				key := pair{resource: resource, action: action}
+				p, ok := permissions[key]
+				if !ok {
+					p = &Permission{Resource: resource, Action: action, Conditional: conditional}
+					permissions[key] = p
+					order = append(order, key)
+				}
+				// A permission granted unconditionally by one policy doesn't depend on the others.
+				p.Conditional = p.Conditional && conditional
+				p.Policies = append(p.Policies, allow.GetID())
+			}
+		}
+	}
+
+	result := make([]Permission, 0, len(order))
+	for _, key := range order {
+		p := permissions[key]
+		sort.Strings(p.Policies)
+		p.Policies = dedupeSorted(p.Policies)
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Resource != result[j].Resource {
+			return result[i].Resource < result[j].Resource
+		}
+		return result[i].Action < result[j].Action
+	})
+	return result, nil
+}
+
+// covers returns whether deny covers resource and action of allow completely, and whether it covers
+// part of them.
+func covers(m matcher.Matcher, allow, deny Policy, resource, action string) (covered, overlaps bool, err error) {
+	rCovered, rOverlaps, err := coversPattern(m, allow, deny, deny.GetResources(), resource)
+	if err != nil || !rOverlaps {
+		return false, false, err
+	}
+	aCovered, aOverlaps, err := coversPattern(m, allow, deny, deny.GetActions(), action)
+	if err != nil || !aOverlaps {
+		return false, false, err
+	}
+	return rCovered && aCovered, true, nil
+}
+
+// coversPattern returns whether the patterns of deny match everything pattern of allow matches, and
+// whether they match part of it. overlaps is true whenever covered is.
+func coversPattern(m matcher.Matcher, allow, deny Policy, patterns []string, pattern string) (covered, overlaps bool, err error) {
+	for _, p := range patterns {
+		if p == pattern {
+			return true, true, nil
+		}
+	}
+
+	// Matching a pattern's text tells whether e.g. `<.*>` covers `articles:<.*>`.
+	if ok, err := m.Matches(deny, patterns, pattern); err != nil || ok {
+		return ok, ok, err
+	}
+	if !isPattern(allow, pattern) {
+		return false, false, nil
+	}
+
+	for _, p := range patterns {
+		if isPattern(deny, p) {
+			continue
+		}
+		if ok, err := m.Matches(allow, []string{pattern}, p); err != nil {
+			return false, false, err
+		} else if ok {
+			return false, true, nil
+		}
+	}
+	return false, false, nil
+}
+
+func isPattern(p Policy, value string) bool {
+	return strings.IndexByte(value, p.GetStartDelimiter()) >= 0
+}
+
+func dedupeSorted(values []string) []string {
+	out := values[:0]
+	for i, v := range values {
+		if i == 0 || v != values[i-1] {
+			out = append(out, v)
+		}
+	}
+	return out
+}
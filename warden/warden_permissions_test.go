@@ -0,0 +1,121 @@
+package warden
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+)
+
+func TestEffectivePermissions(t *testing.T) {
+	m := memory.NewMemoryManager()
+	for _, p := range []*DefaultPolicy{
+		{
+			ID:         "read-articles",
+			Subjects:   []string{"alice", "bob"},
+			Resources:  []string{"articles:<.*>", "comments:1"},
+			Actions:    []string{"get", "list"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		},
+		{
+			ID:         "edit-own-articles",
+			Subjects:   []string{"alice"},
+			Resources:  []string{"articles:1", "articles:2"},
+			Actions:    []string{"update", "delete"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{},
+		},
+		{
+			ID:         "edit-from-office",
+			Subjects:   []string{"alice"},
+			Resources:  []string{"articles:3"},
+			Actions:    []string{"update"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{"ip": &CIDRCondition{CIDR: "10.0.0.0/8"}},
+		},
+		{
+			ID:         "also-edit-articles-1",
+			Subjects:   []string{"<.*>"},
+			Resources:  []string{"articles:1"},
+			Actions:    []string{"update"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{"ip": &CIDRCondition{CIDR: "10.0.0.0/8"}},
+		},
+		{
+			ID:         "never-delete-articles-2",
+			Subjects:   []string{"<.*>"},
+			Resources:  []string{"articles:2"},
+			Actions:    []string{"delete"},
+			Effect:     DenyAccess,
+			Conditions: Conditions{},
+		},
+		{
+			ID:         "hide-secret-articles",
+			Subjects:   []string{"alice"},
+			Resources:  []string{"articles:secret"},
+			Actions:    []string{"<.*>"},
+			Effect:     DenyAccess,
+			Conditions: Conditions{},
+		},
+		{
+			ID:         "freeze-on-weekends",
+			Subjects:   []string{"alice"},
+			Resources:  []string{"articles:<.*>"},
+			Actions:    []string{"update"},
+			Effect:     DenyAccess,
+			Conditions: Conditions{"weekend": &BooleanCondition{BooleanValue: true}},
+		},
+		{
+			ID:         "deny-bob-comments",
+			Subjects:   []string{"bob"},
+			Resources:  []string{"<.*>"},
+			Actions:    []string{"<.*>"},
+			Effect:     DenyAccess,
+			Conditions: Conditions{},
+		},
+	} {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w := &Ladon{Manager: m}
+
+	for k, c := range []struct {
+		subject  string
+		expected []Permission
+	}{
+		{
+			subject: "alice",
+			expected: []Permission{
+				{Resource: "articles:1", Action: "delete", Policies: []string{"edit-own-articles"}},
+				{Resource: "articles:1", Action: "update", Conditional: true, Policies: []string{"also-edit-articles-1", "edit-own-articles"}},
+				{Resource: "articles:2", Action: "update", Conditional: true, Policies: []string{"edit-own-articles"}},
+				{Resource: "articles:3", Action: "update", Conditional: true, Policies: []string{"edit-from-office"}},
+				{Resource: "articles:<.*>", Action: "get", Conditional: true, Policies: []string{"read-articles"}},
+				{Resource: "articles:<.*>", Action: "list", Conditional: true, Policies: []string{"read-articles"}},
+				{Resource: "comments:1", Action: "get", Policies: []string{"read-articles"}},
+				{Resource: "comments:1", Action: "list", Policies: []string{"read-articles"}},
+			},
+		},
+		{
+			subject:  "bob",
+			expected: []Permission{},
+		},
+		{
+			subject: "carol",
+			expected: []Permission{
+				{Resource: "articles:1", Action: "update", Conditional: true, Policies: []string{"also-edit-articles-1"}},
+			},
+		},
+	} {
+		actual, err := EffectivePermissions(w, c.subject)
+		if err != nil {
+			t.Fatalf("Case %d: %s", k, err)
+		}
+		if diff := cmp.Diff(c.expected, actual); diff != "" {
+			t.Errorf("Case %d: unexpected permissions for %s (-want +got):\n%s", k, c.subject, diff)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package warden
+
+import (
+	"encoding/json"
+
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// priorityKey is the key of the priority in a policy's meta data.
+const priorityKey = "priority"
+
+// Priority returns the priority of p, which the PriorityWins mode uses to break ties between allowing and
+// denying policies. ladon's DefaultPolicy has no field for it, so it is stored as the integer `priority`
+// in the policy's meta data, e.g.
+//
+//	{"priority": 10}
+//
+// Policies without a priority, or whose meta data isn't a JSON object, have priority zero.
+func Priority(p Policy) int {
+	var meta struct {
+		Priority int `json:"priority"`
+	}
+	if err := json.Unmarshal(p.GetMeta(), &meta); err != nil {
+		return 0
+	}
+	return meta.Priority
+}
+
+// SetPriority stores priority as the priority of p, keeping the rest of its meta data. The meta data must
+// be empty or a JSON object. A priority of zero removes the priority.
+func SetPriority(p *DefaultPolicy, priority int) error {
+	if priority == 0 {
+		return setMeta(p, priorityKey, nil)
+	}
+	return setMeta(p, priorityKey, priority)
+}
+
+// setMeta stores value under key in the meta data of p, which must be empty or a JSON object, keeping
+// the rest of it. A nil value removes the key.
+func setMeta(p *DefaultPolicy, key string, value interface{}) error {
+	meta := map[string]interface{}{}
+	if len(p.Meta) > 0 {
+		if err := json.Unmarshal(p.Meta, &meta); err != nil {
+			return errors.Wrapf(err, "meta data of policy %s is not a JSON object", p.ID)
+		}
+	}
+
+	if value == nil {
+		delete(meta, key)
+	} else {
+		meta[key] = value
+	}
+	if len(meta) == 0 {
+		p.Meta = nil
+		return nil
+	}
+
+	b, err := json.Marshal(meta)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	p.Meta = b
+	return nil
+}
@@ -0,0 +1,108 @@
+package warden
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+func TestPriorityWins(t *testing.T) {
+	prioritized := func(priority int, p *DefaultPolicy) *DefaultPolicy {
+		if err := SetPriority(p, priority); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	m := memory.NewMemoryManager()
+	for _, p := range []*DefaultPolicy{
+		prioritized(0, &DefaultPolicy{
+			ID:        "deny-articles",
+			Subjects:  []string{"<.*>"},
+			Resources: []string{"articles:<.*>"},
+			Actions:   []string{"<.*>"},
+			Effect:    DenyAccess,
+		}),
+		prioritized(10, &DefaultPolicy{
+			ID:        "allow-editors",
+			Subjects:  []string{"editor"},
+			Resources: []string{"articles:<.*>"},
+			Actions:   []string{"get", "update"},
+			Effect:    AllowAccess,
+		}),
+		prioritized(10, &DefaultPolicy{
+			ID:        "deny-editors-update",
+			Subjects:  []string{"editor"},
+			Resources: []string{"articles:<.*>"},
+			Actions:   []string{"update"},
+			Effect:    DenyAccess,
+		}),
+		prioritized(20, &DefaultPolicy{
+			ID:         "allow-editors-on-network",
+			Subjects:   []string{"editor"},
+			Resources:  []string{"articles:<.*>"},
+			Actions:    []string{"publish"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{"ip": &CIDRCondition{CIDR: "10.0.0.0/8"}},
+		}),
+		{
+			ID:        "allow-comments",
+			Subjects:  []string{"<.*>"},
+			Resources: []string{"comments:<.*>"},
+			Actions:   []string{"get"},
+			Effect:    AllowAccess,
+		},
+	} {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for k, c := range []struct {
+		request  *Request
+		expected error
+	}{
+		// A high-priority allow overrides a low-priority deny.
+		{request: &Request{Subject: "editor", Resource: "articles:1", Action: "get"}, expected: nil},
+		// Deny wins among policies of equal priority.
+		{request: &Request{Subject: "editor", Resource: "articles:1", Action: "update"}, expected: ErrRequestForcefullyDenied},
+		// Only the low-priority deny applies.
+		{request: &Request{Subject: "alice", Resource: "articles:1", Action: "get"}, expected: ErrRequestForcefullyDenied},
+		// A fulfilled high-priority allow overrides the deny, an unfulfilled one is ignored.
+		{request: &Request{Subject: "editor", Resource: "articles:1", Action: "publish", Context: Context{"ip": "10.0.0.1"}}, expected: nil},
+		{request: &Request{Subject: "editor", Resource: "articles:1", Action: "publish", Context: Context{"ip": "192.168.0.1"}}, expected: ErrRequestForcefullyDenied},
+		// Policies without a priority decide like ladon.
+		{request: &Request{Subject: "alice", Resource: "comments:1", Action: "get"}, expected: nil},
+		{request: &Request{Subject: "alice", Resource: "users:1", Action: "get"}, expected: ErrRequestDenied},
+	} {
+		for _, w := range []Warden{
+			NewModeWarden(m, PriorityWins),
+			&TracingWarden{Manager: m, Mode: PriorityWins, Workers: 2},
+		} {
+			if err := w.IsAllowed(c.request); errors.Cause(err) != c.expected {
+				t.Errorf("Case %d: expected %v from %T, got %v", k, c.expected, w, err)
+			}
+		}
+	}
+
+	t.Run("Keep the rest of the meta data", func(t *testing.T) {
+		p := &DefaultPolicy{ID: "1", Meta: []byte(`{"rulesets":["gateway"]}`)}
+		if err := SetPriority(p, 5); err != nil {
+			t.Fatal(err)
+		}
+		if Priority(p) != 5 || !inRuleset(p, "gateway") {
+			t.Fatalf("Unexpected meta data %s", p.Meta)
+		}
+		if err := SetPriority(p, 0); err != nil {
+			t.Fatal(err)
+		}
+		if string(p.Meta) != `{"rulesets":["gateway"]}` {
+			t.Fatalf("Expected the priority to be removed, got %s", p.Meta)
+		}
+		if err := SetPriority(&DefaultPolicy{ID: "2", Meta: []byte(`[]`)}, 1); err == nil {
+			t.Fatal("Expected an error for meta data which isn't a JSON object")
+		}
+	})
+}
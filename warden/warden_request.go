@@ -0,0 +1,99 @@
+package warden
+
+import (
+	. "github.com/ory/ladon"
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidRequest is the cause of errors returned by Validate.
+var ErrInvalidRequest = errors.New("Invalid access request")
+
+// RequestBuilder builds access requests.
+type RequestBuilder struct {
+	r Request
+}
+
+// NewRequest initializes a new RequestBuilder.
+func NewRequest() *RequestBuilder {
+	return &RequestBuilder{}
+}
+
+// Subject sets the request's subject.
+func (b *RequestBuilder) Subject(subject string) *RequestBuilder {
+	b.r.Subject = subject
+	return b
+}
+
+// Resource sets the request's resource.
+func (b *RequestBuilder) Resource(resource string) *RequestBuilder {
+	b.r.Resource = resource
+	return b
+}
+
+// Action sets the request's action.
+func (b *RequestBuilder) Action(action string) *RequestBuilder {
+	b.r.Action = action
+	return b
+}
+
+// With sets a single context value.
+func (b *RequestBuilder) With(key string, value interface{}) *RequestBuilder {
+	b.r.Context = MergeContext(b.r.Context, Context{key: value})
+	return b
+}
+
+// WithContext merges ctx into the request's context, overwriting existing keys.
+func (b *RequestBuilder) WithContext(ctx Context) *RequestBuilder {
+	b.r.Context = MergeContext(b.r.Context, ctx)
+	return b
+}
+
+// Build validates and returns the request.
+func (b *RequestBuilder) Build() (*Request, error) {
+	r := b.r
+	r.Context = MergeContext(r.Context)
+	if err := Validate(&r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// Validate returns an error caused by ErrInvalidRequest if r's subject, resource or action is empty.
+func Validate(r *Request) error {
+	switch {
+	case r == nil:
+		return errors.Wrap(ErrInvalidRequest, "request is nil")
+	case r.Subject == "":
+		return errors.Wrap(ErrInvalidRequest, "subject is empty")
+	case r.Resource == "":
+		return errors.Wrap(ErrInvalidRequest, "resource is empty")
+	case r.Action == "":
+		return errors.Wrap(ErrInvalidRequest, "action is empty")
+	}
+	return nil
+}
+
+// MergeContext returns a new context containing the values of all given contexts, later ones
+// overwriting earlier ones. None of the given contexts is modified, and the result is never nil.
+func MergeContext(contexts ...Context) Context {
+	merged := Context{}
+	for _, ctx := range contexts {
+		for k, v := range ctx {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// ValidatingWarden wraps a warden and rejects invalid requests before they are evaluated.
+type ValidatingWarden struct {
+	Warden Warden
+}
+
+// IsAllowed returns the error of Validate for invalid requests and the wrapped warden's verdict otherwise.
+func (w *ValidatingWarden) IsAllowed(r *Request) error {
+	if err := Validate(r); err != nil {
+		return err
+	}
+	return w.Warden.IsAllowed(r)
+}
@@ -0,0 +1,92 @@
+package warden
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+func TestRequestBuilder(t *testing.T) {
+	t.Run("Build a valid request", func(t *testing.T) {
+		shared := Context{"ip": "10.1.2.3"}
+		r, err := NewRequest().
+			Subject("alice").
+			Resource("articles:1").
+			Action("get").
+			WithContext(shared).
+			With("owner", "alice").
+			Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := &Request{
+			Subject:  "alice",
+			Resource: "articles:1",
+			Action:   "get",
+			Context:  Context{"ip": "10.1.2.3", "owner": "alice"},
+		}
+		if !cmp.Equal(expected, r) {
+			t.Fatalf("Unexpected request\n%s", cmp.Diff(expected, r))
+		}
+		if len(shared) != 1 {
+			t.Fatalf("Expected the merged context to be left untouched, got %+v", shared)
+		}
+	})
+
+	for k, b := range []*RequestBuilder{
+		NewRequest().Resource("articles:1").Action("get"),
+		NewRequest().Subject("alice").Action("get"),
+		NewRequest().Subject("alice").Resource("articles:1"),
+	} {
+		if _, err := b.Build(); errors.Cause(err) != ErrInvalidRequest {
+			t.Errorf("Case %d: expected %v, got %v", k, ErrInvalidRequest, err)
+		}
+	}
+}
+
+func TestMergeContext(t *testing.T) {
+	a := Context{"a": 1, "b": 1}
+	merged := MergeContext(a, nil, Context{"b": 2})
+
+	if !cmp.Equal(Context{"a": 1, "b": 2}, merged) {
+		t.Fatalf("Unexpected context\n%s", cmp.Diff(Context{"a": 1, "b": 2}, merged))
+	}
+	if a["b"] != 1 {
+		t.Fatal("Expected the first context to be left untouched")
+	}
+	if MergeContext() == nil {
+		t.Fatal("Expected an empty, non-nil context")
+	}
+}
+
+func TestValidatingWarden(t *testing.T) {
+	m := memory.NewMemoryManager()
+	if err := m.Create(&DefaultPolicy{
+		ID:        "allow-all",
+		Subjects:  []string{"<.*>"},
+		Resources: []string{"<.*>"},
+		Actions:   []string{"<.*>"},
+		Effect:    AllowAccess,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	w := &ValidatingWarden{Warden: &Ladon{Manager: m, Matcher: DefaultMatcher}}
+
+	if err := w.IsAllowed(&Request{Subject: "alice", Resource: "articles:1", Action: "get"}); err != nil {
+		t.Fatalf("Expected request to be allowed: %s", err)
+	}
+	for k, r := range []*Request{
+		nil,
+		{Resource: "articles:1", Action: "get"},
+		{Subject: "alice", Action: "get"},
+		{Subject: "alice", Resource: "articles:1"},
+	} {
+		if err := w.IsAllowed(r); errors.Cause(err) != ErrInvalidRequest {
+			t.Errorf("Case %d: expected %v, got %v", k, ErrInvalidRequest, err)
+		}
+	}
+}
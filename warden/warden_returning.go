@@ -0,0 +1,44 @@
+package warden
+
+import (
+	. "github.com/ory/ladon"
+)
+
+// IsAllowedReturning works like l.IsAllowed(r) but also returns the deciding policy: the policy which
+// explicitly denied the request, or the last policy which allowed it. It returns a nil policy if no
+// policy applies to the request. The deciding policy is the same one audit loggers report, and l's own
+// audit logger, if any, is still called.
+func IsAllowedReturning(l *Ladon, r *Request) (Policy, error) {
+	capture := &capturingAuditLogger{next: l.AuditLogger}
+	c := *l
+	c.AuditLogger = capture
+
+	err := c.IsAllowed(r)
+	return capture.decider, err
+}
+
+// capturingAuditLogger records the deciding policy of a single request.
+type capturingAuditLogger struct {
+	next    AuditLogger
+	decider Policy
+}
+
+func (a *capturingAuditLogger) LogRejectedAccessRequest(r *Request, p Policies, d Policies) {
+	a.capture(d)
+	if a.next != nil {
+		a.next.LogRejectedAccessRequest(r, p, d)
+	}
+}
+
+func (a *capturingAuditLogger) LogGrantedAccessRequest(r *Request, p Policies, d Policies) {
+	a.capture(d)
+	if a.next != nil {
+		a.next.LogGrantedAccessRequest(r, p, d)
+	}
+}
+
+func (a *capturingAuditLogger) capture(deciders Policies) {
+	if len(deciders) > 0 {
+		a.decider = deciders[len(deciders)-1]
+	}
+}
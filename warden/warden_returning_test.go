@@ -0,0 +1,70 @@
+package warden
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+// countingAuditLogger counts the decisions it is called for.
+type countingAuditLogger struct {
+	granted, rejected int
+}
+
+func (a *countingAuditLogger) LogRejectedAccessRequest(*Request, Policies, Policies) { a.rejected++ }
+func (a *countingAuditLogger) LogGrantedAccessRequest(*Request, Policies, Policies)  { a.granted++ }
+
+func TestIsAllowedReturning(t *testing.T) {
+	m := memory.NewMemoryManager()
+	for _, p := range []*DefaultPolicy{
+		{
+			ID:        "allow-articles",
+			Subjects:  []string{"<.*>"},
+			Resources: []string{"articles:<.*>"},
+			Actions:   []string{"get"},
+			Effect:    AllowAccess,
+		},
+		{
+			ID:        "deny-bob",
+			Subjects:  []string{"bob"},
+			Resources: []string{"<.*>"},
+			Actions:   []string{"<.*>"},
+			Effect:    DenyAccess,
+		},
+	} {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logger := &countingAuditLogger{}
+	l := &Ladon{Manager: m, Matcher: DefaultMatcher, AuditLogger: logger}
+
+	for k, c := range []struct {
+		request  *Request
+		policy   string
+		expected error
+	}{
+		{request: &Request{Subject: "alice", Resource: "articles:1", Action: "get"}, policy: "allow-articles"},
+		{request: &Request{Subject: "bob", Resource: "articles:1", Action: "get"}, policy: "deny-bob", expected: ErrRequestForcefullyDenied},
+		{request: &Request{Subject: "alice", Resource: "users:1", Action: "get"}, expected: ErrRequestDenied},
+	} {
+		p, err := IsAllowedReturning(l, c.request)
+		if errors.Cause(err) != c.expected {
+			t.Errorf("Case %d: expected %v, got %v", k, c.expected, err)
+		}
+
+		switch {
+		case c.policy == "" && p != nil:
+			t.Errorf("Case %d: expected no policy, got %s", k, p.GetID())
+		case c.policy != "" && (p == nil || p.GetID() != c.policy):
+			t.Errorf("Case %d: expected policy %s, got %+v", k, c.policy, p)
+		}
+	}
+
+	if logger.granted != 1 || logger.rejected != 2 {
+		t.Fatalf("Expected the audit logger to be called for every decision, got %+v", logger)
+	}
+}
@@ -0,0 +1,46 @@
+package warden
+
+import (
+	"encoding/json"
+
+	. "github.com/ory/ladon"
+)
+
+// RulesetMeta is the part of a policy's Meta read by IsAllowedWithinRuleset, e.g.
+//
+//	{"rulesets": ["gateway", "admin"]}
+type RulesetMeta struct {
+	Rulesets []string `json:"rulesets"`
+}
+
+// IsAllowedWithinRuleset works like l.IsAllowed(r) but only considers candidate policies whose Meta
+// lists the given ruleset. Policies without Meta, or with Meta which isn't a JSON object, belong to no
+// ruleset. If none of the candidates belongs to the ruleset, the request is denied with
+// ladon.ErrRequestDenied.
+func IsAllowedWithinRuleset(l *Ladon, r *Request, ruleset string) error {
+	candidates, err := l.Manager.FindRequestCandidates(r)
+	if err != nil {
+		return err
+	}
+
+	policies := Policies{}
+	for _, p := range candidates {
+		if inRuleset(p, ruleset) {
+			policies = append(policies, p)
+		}
+	}
+	return l.DoPoliciesAllow(r, policies)
+}
+
+func inRuleset(p Policy, ruleset string) bool {
+	var meta RulesetMeta
+	if err := json.Unmarshal(p.GetMeta(), &meta); err != nil {
+		return false
+	}
+	for _, r := range meta.Rulesets {
+		if r == ruleset {
+			return true
+		}
+	}
+	return false
+}
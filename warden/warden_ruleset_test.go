@@ -0,0 +1,69 @@
+package warden
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+func TestIsAllowedWithinRuleset(t *testing.T) {
+	m := memory.NewMemoryManager()
+	for _, p := range []*DefaultPolicy{
+		{
+			ID:        "gateway-articles",
+			Subjects:  []string{"<.*>"},
+			Resources: []string{"articles:<.*>"},
+			Actions:   []string{"get"},
+			Effect:    AllowAccess,
+			Meta:      []byte(`{"rulesets":["gateway"]}`),
+		},
+		{
+			ID:        "admin-users",
+			Subjects:  []string{"<.*>"},
+			Resources: []string{"users:<.*>"},
+			Actions:   []string{"get"},
+			Effect:    AllowAccess,
+			Meta:      []byte(`{"rulesets":["admin"]}`),
+		},
+		{
+			ID:        "untagged-comments",
+			Subjects:  []string{"<.*>"},
+			Resources: []string{"comments:<.*>"},
+			Actions:   []string{"get"},
+			Effect:    AllowAccess,
+		},
+		{
+			ID:        "admin-deny-bob",
+			Subjects:  []string{"bob"},
+			Resources: []string{"<.*>"},
+			Actions:   []string{"<.*>"},
+			Effect:    DenyAccess,
+			Meta:      []byte(`{"rulesets":["admin","audit"]}`),
+		},
+	} {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l := &Ladon{Manager: m, Matcher: DefaultMatcher}
+
+	for k, c := range []struct {
+		request  *Request
+		ruleset  string
+		expected error
+	}{
+		{request: &Request{Subject: "alice", Resource: "articles:1", Action: "get"}, ruleset: "gateway"},
+		{request: &Request{Subject: "alice", Resource: "articles:1", Action: "get"}, ruleset: "admin", expected: ErrRequestDenied},
+		{request: &Request{Subject: "alice", Resource: "users:1", Action: "get"}, ruleset: "gateway", expected: ErrRequestDenied},
+		{request: &Request{Subject: "alice", Resource: "comments:1", Action: "get"}, ruleset: "gateway", expected: ErrRequestDenied},
+		{request: &Request{Subject: "bob", Resource: "articles:1", Action: "get"}, ruleset: "gateway"},
+		{request: &Request{Subject: "bob", Resource: "users:1", Action: "get"}, ruleset: "admin", expected: ErrRequestForcefullyDenied},
+		{request: &Request{Subject: "alice", Resource: "articles:1", Action: "get"}, ruleset: "unknown", expected: ErrRequestDenied},
+	} {
+		if err := IsAllowedWithinRuleset(l, c.request, c.ruleset); errors.Cause(err) != c.expected {
+			t.Errorf("Case %d: expected %v, got %v", k, c.expected, err)
+		}
+	}
+}
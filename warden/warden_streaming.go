@@ -0,0 +1,76 @@
+package warden
+
+import (
+	"context"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/matcher"
+	"github.com/pkg/errors"
+)
+
+// CandidateStreamer is implemented by managers which can pass request candidates to a callback as they
+// read them, like redis.RedisManager. Streaming stops as soon as fn returns an error, which is returned.
+type CandidateStreamer interface {
+	StreamRequestCandidates(r *Request, fn func(Policy) error) error
+}
+
+// errDenied stops streaming once a policy denies the request.
+var errDenied = errors.New("request denied by streamed policy")
+
+// StreamingWarden decides requests like ladon.Ladon, but evaluates candidates as the manager streams
+// them if it implements CandidateStreamer. The first applying denying policy stops the stream, so the
+// remaining candidates are neither read nor decoded. Managers which can't stream are asked for all
+// candidates with FindRequestCandidates.
+//
+// The audit logger is passed the candidates read until the decision, not all of them.
+type StreamingWarden struct {
+	Manager     Manager
+	Matcher     matcher.Matcher
+	AuditLogger AuditLogger
+}
+
+// NewStreamingWarden initializes a new StreamingWarden using ladon.DefaultMatcher.
+func NewStreamingWarden(manager Manager) *StreamingWarden {
+	return &StreamingWarden{Manager: manager, Matcher: DefaultMatcher}
+}
+
+// IsAllowed returns nil if subject s has the permission p on resource r with context c or an error otherwise.
+func (w *StreamingWarden) IsAllowed(r *Request) error {
+	var (
+		e    = newEvaluator(w.Matcher, w.AuditLogger)
+		t    = e.tally()
+		read = Policies{}
+	)
+	if err := e.validate(r); err != nil {
+		return err
+	}
+	evaluate := func(p Policy) error {
+		read = append(read, p)
+		o, err := e.evaluate(context.Background(), p, r)
+		if err != nil {
+			return err
+		}
+		if t.add(p, o) {
+			return errDenied
+		}
+		return nil
+	}
+
+	var err error
+	if s, ok := w.Manager.(CandidateStreamer); ok {
+		err = s.StreamRequestCandidates(r, evaluate)
+	} else {
+		var policies Policies
+		if policies, err = w.Manager.FindRequestCandidates(r); err == nil {
+			for _, p := range policies {
+				if err = evaluate(p); err != nil {
+					break
+				}
+			}
+		}
+	}
+	if err != nil && err != errDenied {
+		return err
+	}
+	return e.verdict(r, read, t)
+}
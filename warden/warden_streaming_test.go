@@ -0,0 +1,99 @@
+package warden
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+// streamingManager generates its candidates on demand and counts how many were read.
+type streamingManager struct {
+	*memory.MemoryManager
+	candidates func(i int) Policy
+	total      int
+	read       int
+}
+
+func (m *streamingManager) StreamRequestCandidates(r *Request, fn func(Policy) error) error {
+	for i := 0; i < m.total; i++ {
+		m.read++
+		if err := fn(m.candidates(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestStreamingWarden(t *testing.T) {
+	policy := func(id int, effect string) Policy {
+		return &DefaultPolicy{
+			ID:         fmt.Sprintf("policy-%d", id),
+			Subjects:   []string{"group:everyone"},
+			Resources:  []string{"articles:<.*>"},
+			Actions:    []string{"get"},
+			Effect:     effect,
+			Conditions: Conditions{},
+		}
+	}
+	r := &Request{Subject: "group:everyone", Resource: "articles:1", Action: "get"}
+
+	for k, c := range []struct {
+		candidates func(i int) Policy
+		expected   error
+		read       int
+	}{
+		{
+			// An early deny stops reading the remaining candidates.
+			candidates: func(i int) Policy {
+				if i == 2 {
+					return policy(i, DenyAccess)
+				}
+				return policy(i, AllowAccess)
+			},
+			expected: ErrRequestForcefullyDenied,
+			read:     3,
+		},
+		{
+			// Allowing requires reading all of them.
+			candidates: func(i int) Policy { return policy(i, AllowAccess) },
+			expected:   nil,
+			read:       10000,
+		},
+		{
+			// Denies whose conditions aren't fulfilled don't count.
+			candidates: func(i int) Policy {
+				p := policy(i, DenyAccess).(*DefaultPolicy)
+				p.Conditions = Conditions{"flagged": &BooleanCondition{BooleanValue: true}}
+				return p
+			},
+			expected: ErrRequestDenied,
+			read:     10000,
+		},
+	} {
+		m := &streamingManager{MemoryManager: memory.NewMemoryManager(), candidates: c.candidates, total: 10000}
+		if err := NewStreamingWarden(m).IsAllowed(r); errors.Cause(err) != c.expected {
+			t.Fatalf("Case %d: expected %v, got %v", k, c.expected, err)
+		}
+		if m.read != c.read {
+			t.Errorf("Case %d: expected %d candidates to be read, got %d", k, c.read, m.read)
+		}
+	}
+
+	t.Run("Fall back to FindRequestCandidates", func(t *testing.T) {
+		m := memory.NewMemoryManager()
+		for _, p := range []Policy{policy(1, AllowAccess), policy(2, DenyAccess)} {
+			if err := m.Create(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := NewStreamingWarden(m).IsAllowed(r); errors.Cause(err) != ErrRequestForcefullyDenied {
+			t.Fatalf("Expected %v, got %v", ErrRequestForcefullyDenied, err)
+		}
+		if err := NewStreamingWarden(m).IsAllowed(&Request{Subject: "group:everyone", Resource: "users:1", Action: "get"}); errors.Cause(err) != ErrRequestDenied {
+			t.Fatalf("Expected %v, got %v", ErrRequestDenied, err)
+		}
+	})
+}
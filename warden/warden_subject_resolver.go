@@ -0,0 +1,81 @@
+package warden
+
+import (
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/condition"
+	"github.com/pkg/errors"
+)
+
+// SubjectResolver returns the effective subjects of a subject, e.g. the groups a user is a member of.
+type SubjectResolver interface {
+	Resolve(subject string) ([]string, error)
+}
+
+// SubjectResolverFunc adapts a function to the SubjectResolver interface.
+type SubjectResolverFunc func(subject string) ([]string, error)
+
+// Resolve calls f(subject).
+func (f SubjectResolverFunc) Resolve(subject string) ([]string, error) {
+	return f(subject)
+}
+
+// ResolvingWarden wraps a warden and evaluates a request for the request's subject and each subject
+// returned by Resolver, so policies written against groups apply to their members. A request is denied
+// if a policy denies it for any of the subjects, and allowed if a policy allows it for any of them.
+//
+// Resolver is called once per request. Conditions see the effective subject as the request's subject and
+// under condition.MatchedSubjectKey, so a condition.MatchedSubjectCondition can branch on it. An audit
+// logger configured on the wrapped warden logs one decision per effective subject.
+type ResolvingWarden struct {
+	Warden   Warden
+	Resolver SubjectResolver
+}
+
+// NewResolvingWarden initializes a new ResolvingWarden.
+func NewResolvingWarden(w Warden, resolver SubjectResolver) *ResolvingWarden {
+	return &ResolvingWarden{Warden: w, Resolver: resolver}
+}
+
+// IsAllowed returns nil if the request is allowed for any of the effective subjects and denied for none.
+func (w *ResolvingWarden) IsAllowed(r *Request) error {
+	resolved, err := w.Resolver.Resolve(r.Subject)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	var (
+		subjects = append([]string{r.Subject}, resolved...)
+		seen     = make(map[string]bool, len(subjects))
+		allowed  = false
+		denied   error
+	)
+	for _, s := range subjects {
+		if seen[s] {
+			continue
+		}
+		seen[s] = true
+
+		e := *r
+		e.Subject = s
+		e.Context = Context{condition.MatchedSubjectKey: s}
+		for k, v := range r.Context {
+			if k != condition.MatchedSubjectKey {
+				e.Context[k] = v
+			}
+		}
+		switch err := w.Warden.IsAllowed(&e); errors.Cause(err) {
+		case nil:
+			allowed = true
+		case ErrRequestDenied:
+			denied = err
+		default:
+			// Explicit denials win, as do errors of the wrapped warden.
+			return err
+		}
+	}
+
+	if allowed {
+		return nil
+	}
+	return denied
+}
@@ -0,0 +1,109 @@
+package warden
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/condition"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+func TestResolvingWarden(t *testing.T) {
+	m := memory.NewMemoryManager()
+	for _, p := range []*DefaultPolicy{
+		{
+			ID:        "eng-articles",
+			Subjects:  []string{"group:eng"},
+			Resources: []string{"articles:<.*>"},
+			Actions:   []string{"get"},
+			Effect:    AllowAccess,
+		},
+		{
+			ID:        "deny-contractors",
+			Subjects:  []string{"group:contractors"},
+			Resources: []string{"articles:secret"},
+			Actions:   []string{"<.*>"},
+			Effect:    DenyAccess,
+		},
+	} {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	calls := 0
+	w := NewResolvingWarden(&Ladon{Manager: m, Matcher: DefaultMatcher}, SubjectResolverFunc(func(subject string) ([]string, error) {
+		calls++
+		switch subject {
+		case "user:alice":
+			return []string{"user:alice", "group:eng"}, nil
+		case "user:mallory":
+			return []string{"group:eng", "group:contractors"}, nil
+		}
+		return nil, nil
+	}))
+
+	for k, c := range []struct {
+		request  *Request
+		expected error
+	}{
+		{request: &Request{Subject: "user:alice", Resource: "articles:1", Action: "get"}},
+		{request: &Request{Subject: "user:alice", Resource: "articles:1", Action: "delete"}, expected: ErrRequestDenied},
+		{request: &Request{Subject: "user:bob", Resource: "articles:1", Action: "get"}, expected: ErrRequestDenied},
+		{request: &Request{Subject: "user:mallory", Resource: "articles:1", Action: "get"}},
+		{request: &Request{Subject: "user:mallory", Resource: "articles:secret", Action: "get"}, expected: ErrRequestForcefullyDenied},
+	} {
+		calls = 0
+		if err := w.IsAllowed(c.request); errors.Cause(err) != c.expected {
+			t.Errorf("Case %d: expected %v, got %v", k, c.expected, err)
+		}
+		if calls != 1 {
+			t.Errorf("Case %d: expected the resolver to be called once, got %d", k, calls)
+		}
+	}
+
+	t.Run("Return resolver errors", func(t *testing.T) {
+		w := NewResolvingWarden(&Ladon{Manager: m, Matcher: DefaultMatcher}, SubjectResolverFunc(func(string) ([]string, error) {
+			return nil, errors.New("directory unavailable")
+		}))
+		if err := w.IsAllowed(&Request{Subject: "user:alice", Resource: "articles:1", Action: "get"}); err == nil {
+			t.Fatal("Expected an error")
+		}
+	})
+
+	t.Run("Expose the matched subject to conditions", func(t *testing.T) {
+		m := memory.NewMemoryManager()
+		if err := m.Create(&DefaultPolicy{
+			ID:        "branching",
+			Subjects:  []string{"group:a", "group:b"},
+			Resources: []string{"articles:<.*>"},
+			Actions:   []string{"get"},
+			Effect:    AllowAccess,
+			Conditions: Conditions{"branch": &condition.MatchedSubjectCondition{Branches: []condition.SubjectBranch{
+				{Subject: "group:a", Conditions: []condition.KeyedCondition{{Key: "ip", Condition: &CIDRCondition{CIDR: "10.0.0.0/8"}}}},
+				{Subject: "group:b"},
+			}}},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		groups := map[string][]string{"user:alice": {"group:a"}, "user:bob": {"group:b"}}
+		w := NewResolvingWarden(&Ladon{Manager: m, Matcher: DefaultMatcher}, SubjectResolverFunc(func(subject string) ([]string, error) {
+			return groups[subject], nil
+		}))
+
+		for k, c := range []struct {
+			request  *Request
+			expected error
+		}{
+			{request: &Request{Subject: "user:alice", Resource: "articles:1", Action: "get", Context: Context{"ip": "10.1.2.3"}}},
+			{request: &Request{Subject: "user:alice", Resource: "articles:1", Action: "get", Context: Context{"ip": "192.168.1.1"}}, expected: ErrRequestDenied},
+			{request: &Request{Subject: "user:bob", Resource: "articles:1", Action: "get", Context: Context{"ip": "192.168.1.1"}}},
+			{request: &Request{Subject: "user:alice", Resource: "articles:1", Action: "get", Context: Context{"ip": "192.168.1.1", condition.MatchedSubjectKey: "group:b"}}, expected: ErrRequestDenied},
+		} {
+			if err := w.IsAllowed(c.request); errors.Cause(err) != c.expected {
+				t.Errorf("Case %d: expected %v, got %v", k, c.expected, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,53 @@
+package warden
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+// decidersAuditLogger records the IDs of the policies which decided granted requests.
+type decidersAuditLogger struct {
+	countingAuditLogger
+	deciders []string
+}
+
+func (a *decidersAuditLogger) LogGrantedAccessRequest(r *Request, p Policies, d Policies) {
+	a.countingAuditLogger.LogGrantedAccessRequest(r, p, d)
+	for _, policy := range d {
+		a.deciders = append(a.deciders, policy.GetID())
+	}
+}
+
+func TestSuperuserWarden(t *testing.T) {
+	m := memory.NewMemoryManager()
+	if err := m.Create(&DefaultPolicy{
+		ID:        "deny-all",
+		Subjects:  []string{"<.*>"},
+		Resources: []string{"<.*>"},
+		Actions:   []string{"<.*>"},
+		Effect:    DenyAccess,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	logger := &decidersAuditLogger{}
+	w := NewSuperuserWarden(&Ladon{Manager: m, Matcher: DefaultMatcher}, logger, "admin")
+
+	if err := w.IsAllowed(&Request{Subject: "admin", Resource: "articles:1", Action: "delete"}); err != nil {
+		t.Fatalf("Expected the superuser to be allowed: %s", err)
+	}
+	if logger.granted != 1 || len(logger.deciders) != 1 || logger.deciders[0] != BreakGlassPolicyID {
+		t.Fatalf("Expected a single break-glass decision to be logged, got %d decisions by %v", logger.granted, logger.deciders)
+	}
+
+	for _, subject := range []string{"alice", "Admin", "admin2"} {
+		if err := w.IsAllowed(&Request{Subject: subject, Resource: "articles:1", Action: "delete"}); errors.Cause(err) != ErrRequestForcefullyDenied {
+			t.Errorf("Expected %s to be forcefully denied, got %v", subject, err)
+		}
+	}
+	if logger.granted != 1 || logger.rejected != 0 {
+		t.Fatalf("Expected no further decisions to be logged by the superuser warden, got %d granted and %d rejected", logger.granted, logger.rejected)
+	}
+}
@@ -0,0 +1,60 @@
+package warden
+
+import (
+	"testing"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/condition"
+	"github.com/ory/ladon-community/matcher"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+func TestTemplateCaptures(t *testing.T) {
+	m := memory.NewMemoryManager()
+	if err := m.Create(&DefaultPolicy{
+		ID:        "project-p1",
+		Subjects:  []string{"<.*>"},
+		Resources: []string{"projects:{projectID}:issues:{issueID}"},
+		Actions:   []string{"get"},
+		Effect:    AllowAccess,
+		Conditions: Conditions{"project": &condition.AttributeCondition{
+			Attribute: matcher.CapturesKey + ".projectID",
+			Operator:  condition.OperatorEq,
+			Operand:   "p1",
+		}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for k, c := range []struct {
+		request  *Request
+		expected error
+	}{
+		// The captures of the policy's resource reach its conditions.
+		{request: &Request{Subject: "alice", Resource: "projects:p1:issues:1", Action: "get"}, expected: nil},
+		{request: &Request{Subject: "alice", Resource: "projects:p2:issues:1", Action: "get"}, expected: ErrRequestDenied},
+		// Callers can't pass captures themselves.
+		{
+			request:  &Request{Subject: "alice", Resource: "projects:p2:issues:1", Action: "get", Context: Context{matcher.CapturesKey: map[string]interface{}{"projectID": "p1"}}},
+			expected: ErrInvalidRequest,
+		},
+	} {
+		for _, w := range []Warden{
+			&ModeWarden{Manager: m, Matcher: matcher.NewTemplateMatcher(nil)},
+			&TracingWarden{Manager: m, Matcher: matcher.NewTemplateMatcher(nil), Workers: 2},
+		} {
+			if err := w.IsAllowed(c.request); errors.Cause(err) != c.expected {
+				t.Errorf("Case %d: expected %v from %T, got %v", k, c.expected, w, err)
+			}
+		}
+	}
+
+	t.Run("Leave the key to callers of other matchers", func(t *testing.T) {
+		w := &ModeWarden{Manager: m, Matcher: DefaultMatcher}
+		r := &Request{Subject: "alice", Resource: "projects:p1:issues:1", Action: "get", Context: Context{matcher.CapturesKey: "anything"}}
+		if err := w.IsAllowed(r); errors.Cause(err) != ErrRequestDenied {
+			t.Fatalf("Expected the template not to match, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package warden
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+)
+
+func TestTestPolicy(t *testing.T) {
+	policy := &DefaultPolicy{
+		ID:         "articles",
+		Subjects:   []string{"<.*>"},
+		Resources:  []string{"articles:<.*>"},
+		Actions:    []string{"get"},
+		Effect:     AllowAccess,
+		Conditions: Conditions{"ip": &CIDRCondition{CIDR: "10.0.0.0/8"}},
+	}
+	deny := &DefaultPolicy{
+		ID:        "deny-bob",
+		Subjects:  []string{"bob"},
+		Resources: []string{"<.*>"},
+		Actions:   []string{"<.*>"},
+		Effect:    DenyAccess,
+	}
+
+	for k, c := range []struct {
+		policy   Policy
+		request  *Request
+		expected *Decision
+	}{
+		{
+			policy:   policy,
+			request:  &Request{Subject: "alice", Resource: "articles:1", Action: "get", Context: Context{"ip": "10.1.2.3"}},
+			expected: &Decision{Applies: true, Allowed: true},
+		},
+		{
+			policy:   policy,
+			request:  &Request{Subject: "alice", Resource: "articles:1", Action: "get", Context: Context{"ip": "192.168.1.1"}},
+			expected: &Decision{},
+		},
+		{
+			policy:   policy,
+			request:  &Request{Subject: "alice", Resource: "users:1", Action: "get", Context: Context{"ip": "10.1.2.3"}},
+			expected: &Decision{},
+		},
+		{
+			policy:   deny,
+			request:  &Request{Subject: "bob", Resource: "articles:1", Action: "get"},
+			expected: &Decision{Applies: true},
+		},
+	} {
+		d, err := TestPolicy(c.policy, c.request)
+		if err != nil {
+			t.Fatalf("Case %d failed: %s", k, err)
+		}
+		if !cmp.Equal(c.expected, d) {
+			t.Errorf("Case %d: unexpected decision\n%s", k, cmp.Diff(c.expected, d))
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package warden
+
+import (
+	"context"
+
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon-community/matcher"
+)
+
+// The names and attributes of the spans started by TracingWarden.
+const (
+	SpanIsAllowed = "ladon.IsAllowed"
+	SpanCondition = "ladon.Condition"
+
+	AttributeSubject   = "ladon.subject"
+	AttributeResource  = "ladon.resource"
+	AttributeAction    = "ladon.action"
+	AttributeAllowed   = "ladon.allowed"
+	AttributePolicyID  = "ladon.policy.id"
+	AttributeKey       = "ladon.condition.key"
+	AttributeType      = "ladon.condition.type"
+	AttributeFulfilled = "ladon.condition.fulfilled"
+)
+
+// Tracer starts spans, e.g. by wrapping an OpenTelemetry trace.Tracer. Start returns a context carrying
+// the new span, so spans started with it are its children.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a single traced operation.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	End()
+}
+
+// NoopTracer starts spans which record nothing.
+type NoopTracer struct{}
+
+// Start returns ctx and a span which records nothing.
+func (NoopTracer) Start(ctx context.Context, _ string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, interface{}) {}
+func (noopSpan) End()                             {}
+
+// TracingWarden decides requests like ladon.Ladon and traces each decision with Tracer, which defaults
+// to NoopTracer. A decision is a SpanIsAllowed span carrying the request and the verdict, with a child
+// SpanCondition span for every condition evaluated, carrying the policy ID, the condition's key and
+// type and whether it was fulfilled. Conditions of policies whose actions, subjects or resources don't
+// match aren't evaluated and thus not traced, and neither are the conditions of a policy after the first
+// unfulfilled one.
+//
+// TracingWarden evaluates policies like ModeWarden and ConcurrentWarden do, so their features can be
+// combined with tracing through Mode and Workers.
+type TracingWarden struct {
+	Manager     Manager
+	Matcher     matcher.Matcher
+	AuditLogger AuditLogger
+	Tracer      Tracer
+
+	// Mode chooses how allowing policies are combined, see ModeWarden.
+	Mode EvaluationMode
+
+	// Workers is the number of policies evaluated in parallel, see ConcurrentWarden. Policies are
+	// evaluated in order if it is zero.
+	Workers int
+}
+
+// NewTracingWarden initializes a new TracingWarden using ladon.DefaultMatcher.
+func NewTracingWarden(manager Manager, tracer Tracer) *TracingWarden {
+	return &TracingWarden{Manager: manager, Matcher: DefaultMatcher, Tracer: tracer}
+}
+
+// IsAllowed calls IsAllowedContext with a background context.
+func (w *TracingWarden) IsAllowed(r *Request) error {
+	return w.IsAllowedContext(context.Background(), r)
+}
+
+// IsAllowedContext returns nil if subject s has the permission p on resource r with context c or an error
+// otherwise. The SpanIsAllowed span is a child of the span in ctx, if any. Once ctx is done, no further
+// policies are evaluated and ctx.Err() is returned.
+func (w *TracingWarden) IsAllowedContext(ctx context.Context, r *Request) (err error) {
+	ctx, span := w.tracer().Start(ctx, SpanIsAllowed)
+	span.SetAttribute(AttributeSubject, r.Subject)
+	span.SetAttribute(AttributeResource, r.Resource)
+	span.SetAttribute(AttributeAction, r.Action)
+	defer func() {
+		span.SetAttribute(AttributeAllowed, err == nil)
+		span.End()
+	}()
+
+	e := newEvaluator(w.Matcher, w.AuditLogger)
+	e.mode = w.Mode
+	e.workers = w.Workers
+	e.tracer = w.tracer()
+	return isAllowed(ctx, w.Manager, e, r)
+}
+
+func (w *TracingWarden) tracer() Tracer {
+	if w.Tracer == nil {
+		return NoopTracer{}
+	}
+	return w.Tracer
+}
@@ -0,0 +1,186 @@
+package warden
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ory/ladon"
+	"github.com/ory/ladon/manager/memory"
+	"github.com/pkg/errors"
+)
+
+type recordedSpan struct {
+	Name       string
+	Parent     string
+	Attributes map[string]interface{}
+	Ended      bool
+}
+
+type spanKey struct{}
+
+// recordingTracer records all spans together with the name of their parent span.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	parent, _ := ctx.Value(spanKey{}).(string)
+	s := &recordedSpan{Name: name, Parent: parent, Attributes: map[string]interface{}{}}
+	t.spans = append(t.spans, s)
+	return context.WithValue(ctx, spanKey{}, name), s
+}
+
+func (s *recordedSpan) SetAttribute(key string, value interface{}) {
+	s.Attributes[key] = value
+}
+
+func (s *recordedSpan) End() {
+	s.Ended = true
+}
+
+func TestTracingWarden(t *testing.T) {
+	m := memory.NewMemoryManager()
+	for _, p := range []*DefaultPolicy{
+		{
+			ID:         "allow-owner-on-network",
+			Subjects:   []string{"<.*>"},
+			Resources:  []string{"articles:<.*>"},
+			Actions:    []string{"get"},
+			Effect:     AllowAccess,
+			Conditions: Conditions{"owner": &EqualsSubjectCondition{}, "ip": &CIDRCondition{CIDR: "10.0.0.0/8"}},
+		},
+		{
+			ID:         "deny-delete",
+			Subjects:   []string{"<.*>"},
+			Resources:  []string{"<.*>"},
+			Actions:    []string{"delete"},
+			Effect:     DenyAccess,
+			Conditions: Conditions{"flagged": &BooleanCondition{BooleanValue: true}},
+		},
+	} {
+		if err := m.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	conditionSpan := func(key, typ string, fulfilled bool) recordedSpan {
+		return recordedSpan{Name: SpanCondition, Parent: SpanIsAllowed, Ended: true, Attributes: map[string]interface{}{
+			AttributePolicyID:  "allow-owner-on-network",
+			AttributeKey:       key,
+			AttributeType:      typ,
+			AttributeFulfilled: fulfilled,
+		}}
+	}
+
+	for k, c := range []struct {
+		request    *Request
+		expected   error
+		conditions []recordedSpan
+	}{
+		{
+			request:  &Request{Subject: "alice", Resource: "articles:1", Action: "get", Context: Context{"owner": "alice", "ip": "10.0.0.1"}},
+			expected: nil,
+			conditions: []recordedSpan{
+				conditionSpan("ip", "CIDRCondition", true),
+				conditionSpan("owner", "EqualsSubjectCondition", true),
+			},
+		},
+		{
+			// Only the policy matching the action has its conditions evaluated.
+			request:  &Request{Subject: "alice", Resource: "articles:1", Action: "publish"},
+			expected: ErrRequestDenied,
+		},
+	} {
+		tracer := &recordingTracer{}
+		w := NewTracingWarden(m, tracer)
+		if err := w.IsAllowedContext(context.Background(), c.request); errors.Cause(err) != c.expected {
+			t.Fatalf("Case %d: expected %v, got %v", k, c.expected, err)
+		}
+
+		if len(tracer.spans) == 0 {
+			t.Fatalf("Case %d: expected spans", k)
+		}
+		root := tracer.spans[0]
+		expectedRoot := &recordedSpan{Name: SpanIsAllowed, Ended: true, Attributes: map[string]interface{}{
+			AttributeSubject:  c.request.Subject,
+			AttributeResource: c.request.Resource,
+			AttributeAction:   c.request.Action,
+			AttributeAllowed:  c.expected == nil,
+		}}
+		if diff := cmp.Diff(expectedRoot, root); diff != "" {
+			t.Errorf("Case %d: unexpected root span (-want +got):\n%s", k, diff)
+		}
+
+		var conditions []recordedSpan
+		for _, s := range tracer.spans[1:] {
+			conditions = append(conditions, *s)
+		}
+		sort.Slice(conditions, func(i, j int) bool {
+			return conditions[i].Attributes[AttributeKey].(string) < conditions[j].Attributes[AttributeKey].(string)
+		})
+		if diff := cmp.Diff(c.conditions, conditions); diff != "" {
+			t.Errorf("Case %d: unexpected condition spans (-want +got):\n%s", k, diff)
+		}
+	}
+
+	t.Run("Stop tracing at the first unfulfilled condition", func(t *testing.T) {
+		tracer := &recordingTracer{}
+		r := &Request{Subject: "alice", Resource: "articles:1", Action: "get", Context: Context{"owner": "bob", "ip": "192.168.0.1"}}
+		if err := NewTracingWarden(m, tracer).IsAllowed(r); errors.Cause(err) != ErrRequestDenied {
+			t.Fatalf("Expected %v, got %v", ErrRequestDenied, err)
+		}
+		if n := len(tracer.spans) - 1; n != 1 {
+			t.Fatalf("Expected a single condition span, got %d", n)
+		}
+		if fulfilled := tracer.spans[1].Attributes[AttributeFulfilled]; fulfilled != false {
+			t.Fatalf("Expected the condition span to record the failure, got %v", fulfilled)
+		}
+	})
+
+	t.Run("Combine tracing with an evaluation mode and workers", func(t *testing.T) {
+		m := memory.NewMemoryManager()
+		for _, p := range []*DefaultPolicy{
+			{ID: "allow-network", Subjects: []string{"<.*>"}, Resources: []string{"<.*>"}, Actions: []string{"get"}, Effect: AllowAccess, Conditions: Conditions{"ip": &CIDRCondition{CIDR: "10.0.0.0/8"}}},
+			{ID: "allow-owner", Subjects: []string{"<.*>"}, Resources: []string{"<.*>"}, Actions: []string{"get"}, Effect: AllowAccess, Conditions: Conditions{"owner": &EqualsSubjectCondition{}}},
+		} {
+			if err := m.Create(p); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Only one of the two allowing policies is fulfilled.
+		r := &Request{Subject: "alice", Resource: "articles:1", Action: "get", Context: Context{"owner": "bob", "ip": "10.0.0.1"}}
+		for k, c := range []struct {
+			mode     EvaluationMode
+			workers  int
+			expected error
+		}{
+			{mode: AnyAllowGrants, expected: nil},
+			{mode: AllAllowsRequired, expected: ErrRequestDenied},
+			{mode: AnyAllowGrants, workers: 2, expected: nil},
+			{mode: AllAllowsRequired, workers: 2, expected: ErrRequestDenied},
+		} {
+			tracer := &recordingTracer{}
+			w := &TracingWarden{Manager: m, Tracer: tracer, Mode: c.mode, Workers: c.workers}
+			if err := w.IsAllowed(r); errors.Cause(err) != c.expected {
+				t.Errorf("Case %d: expected %v, got %v", k, c.expected, err)
+			}
+			if len(tracer.spans) < 2 {
+				t.Errorf("Case %d: expected condition spans, got %d spans", k, len(tracer.spans))
+			}
+		}
+	})
+
+	t.Run("Default to the no-op tracer", func(t *testing.T) {
+		w := &TracingWarden{Manager: m}
+		if err := w.IsAllowed(&Request{Subject: "alice", Resource: "articles:1", Action: "delete", Context: Context{"flagged": true}}); errors.Cause(err) != ErrRequestForcefullyDenied {
+			t.Fatalf("Expected %v, got %v", ErrRequestForcefullyDenied, err)
+		}
+	})
+}